@@ -9,6 +9,7 @@ import (
 	"github.com/ilyin-ad/flutter-code-mentor/internal/server"
 	"github.com/ilyin-ad/flutter-code-mentor/internal/service"
 	"github.com/ilyin-ad/flutter-code-mentor/internal/usecase"
+	"github.com/ilyin-ad/flutter-code-mentor/internal/worker"
 	"go.uber.org/fx"
 	"go.uber.org/fx/fxevent"
 	"go.uber.org/zap"
@@ -27,6 +28,7 @@ func BuildApp() fx.Option {
 		usecase.FxModule(),
 		handler.FxModule(),
 		server.FxModule(),
+		worker.FxModule(),
 		scheduler.FxModule(),
 
 		fx.Provide(func() (*zap.Logger, error) {
@@ -3,15 +3,18 @@ module github.com/ilyin-ad/flutter-code-mentor
 go 1.25.4
 
 require (
+	github.com/caarlos0/env/v11 v11.3.1
+	github.com/go-co-op/gocron/v2 v2.19.1
+	github.com/golang-jwt/jwt/v5 v5.3.1
 	github.com/labstack/echo/v4 v4.14.0
 	github.com/oapi-codegen/runtime v1.1.2
 	go.uber.org/fx v1.23.0
 	go.uber.org/zap v1.27.0
+	golang.org/x/time v0.14.0
 )
 
 require (
-	github.com/caarlos0/env/v11 v11.3.1 // indirect
-	github.com/go-co-op/gocron/v2 v2.19.1 // indirect
+	github.com/apapsch/go-jsonmerge/v2 v2.0.0 // indirect
 	github.com/google/uuid v1.6.0 // indirect
 	github.com/jackc/pgpassfile v1.0.0 // indirect
 	github.com/jackc/pgservicefile v0.0.0-20240606120523-5a60cdf6a761 // indirect
@@ -22,7 +25,6 @@ require (
 	github.com/sethvargo/go-retry v0.3.0 // indirect
 	go.uber.org/dig v1.18.0 // indirect
 	go.uber.org/multierr v1.11.0 // indirect
-	golang.org/x/time v0.14.0 // indirect
 )
 
 require (
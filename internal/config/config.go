@@ -3,6 +3,8 @@ package config
 import (
 	"fmt"
 	"log"
+	"strings"
+	"time"
 
 	"github.com/caarlos0/env/v11"
 	"github.com/joho/godotenv"
@@ -11,10 +13,67 @@ import (
 type Config struct {
 	Database       DatabaseConfig
 	Server         ServerConfig
-	DeepSeekAPIKey string `env:"DEEPSEEK_API_KEY,required"`
+	Auth           AuthConfig
+	AI             AIConfig
+	AIBudget       AIBudgetConfig
+	ReviewQueue    ReviewQueueConfig
+	ReviewCache    ReviewCacheConfig
+	ReviewFiles    ReviewFilesConfig
+	Repository     RepositoryConfig
+	Workspace      WorkspaceConfig
+	Archive        ArchiveConfig
+	Lint           LintConfig
+	Scoring        ScoringConfig
+	DeepSeekAPIKey string `env:"DEEPSEEK_API_KEY"`
 	DeepSeekAPIURL string `env:"DEEPSEEK_API_URL" envDefault:"https://api.deepseek.com/chat/completions"`
 }
 
+type AIConfig struct {
+	Provider string        `env:"AI_PROVIDER" envDefault:"deepseek"`
+	APIKey   string        `env:"AI_API_KEY"`
+	APIURL   string        `env:"AI_API_URL"`
+	Model    string        `env:"AI_MODEL"`
+	Timeout  time.Duration `env:"AI_TIMEOUT" envDefault:"60s"`
+
+	RepairEnabled bool `env:"AI_REPAIR_ENABLED" envDefault:"true"`
+
+	// MaxPromptTokens is the estimated prompt size above which a project
+	// review is split into several calls.
+	MaxPromptTokens int `env:"AI_MAX_PROMPT_TOKENS" envDefault:"48000"`
+
+	// InputPricePerMTok and OutputPricePerMTok are the prices of a million
+	// prompt and completion tokens, used to estimate the cost of reviews.
+	InputPricePerMTok  float64 `env:"AI_INPUT_PRICE_PER_MTOK" envDefault:"0"`
+	OutputPricePerMTok float64 `env:"AI_OUTPUT_PRICE_PER_MTOK" envDefault:"0"`
+
+	// RequestsPerMinute and RequestBurst shape the calls to the provider;
+	// zero requests per minute means no limit.
+	RequestsPerMinute int `env:"AI_REQUESTS_PER_MINUTE" envDefault:"60"`
+	RequestBurst      int `env:"AI_REQUEST_BURST" envDefault:"3"`
+
+	// MaxRetries is how often a call failing with 429, 5xx or a network
+	// error is repeated, waiting per Retry-After or with jittered
+	// exponential backoff between RetryBaseDelay and RetryMaxDelay.
+	MaxRetries     int           `env:"AI_MAX_RETRIES" envDefault:"3"`
+	RetryBaseDelay time.Duration `env:"AI_RETRY_BASE_DELAY" envDefault:"1s"`
+	RetryMaxDelay  time.Duration `env:"AI_RETRY_MAX_DELAY" envDefault:"30s"`
+
+	// After BreakerThreshold failed calls in a row, counting a call with
+	// its retries once and not counting 429s, the provider is considered
+	// down: calls are refused and review workers pause for
+	// BreakerCooldown, after which a single call probes it again.
+	BreakerThreshold int           `env:"AI_BREAKER_THRESHOLD" envDefault:"5"`
+	BreakerCooldown  time.Duration `env:"AI_BREAKER_COOLDOWN" envDefault:"1m"`
+}
+
+// AIBudgetConfig holds the default limits of the estimated AI cost per
+// course and day or month, in the currency of the AI prices. Courses can
+// set their own; zero means no limit. Reviews over budget wait in the queue.
+type AIBudgetConfig struct {
+	CourseDailyLimit   float64 `env:"AI_COURSE_DAILY_BUDGET" envDefault:"0"`
+	CourseMonthlyLimit float64 `env:"AI_COURSE_MONTHLY_BUDGET" envDefault:"0"`
+}
+
 type DatabaseConfig struct {
 	Host     string `env:"DB_HOST" envDefault:"localhost"`
 	Port     string `env:"DB_PORT" envDefault:"5432"`
@@ -24,6 +83,85 @@ type DatabaseConfig struct {
 	SSLMode  string `env:"DB_SSLMODE" envDefault:"disable"`
 }
 
+type ReviewQueueConfig struct {
+	MaxAttempts    int           `env:"REVIEW_MAX_ATTEMPTS" envDefault:"5"`
+	RetryBaseDelay time.Duration `env:"REVIEW_RETRY_BASE_DELAY" envDefault:"30s"`
+	RetryMaxDelay  time.Duration `env:"REVIEW_RETRY_MAX_DELAY" envDefault:"1h"`
+	LockTimeout    time.Duration `env:"REVIEW_JOB_LOCK_TIMEOUT" envDefault:"10m"`
+	BatchSize      int           `env:"REVIEW_BATCH_SIZE" envDefault:"10"`
+	Workers        int           `env:"REVIEW_WORKERS" envDefault:"3"`
+	DispatchBuffer int           `env:"REVIEW_DISPATCH_BUFFER" envDefault:"100"`
+	SweepInterval  time.Duration `env:"REVIEW_SWEEP_INTERVAL" envDefault:"5m"`
+}
+
+// ReviewCacheConfig controls the reuse of AI reviews for submissions with
+// the same files, task, criteria, prompt and model.
+type ReviewCacheConfig struct {
+	Enabled bool          `env:"REVIEW_CACHE_ENABLED" envDefault:"true"`
+	TTL     time.Duration `env:"REVIEW_CACHE_TTL" envDefault:"168h"`
+}
+
+// ReviewFilesConfig holds the default limits for repository reviews. Tasks
+// can override them with their own file selection rules.
+type ReviewFilesConfig struct {
+	MaxFileSizeKB int `env:"REVIEW_MAX_FILE_SIZE_KB" envDefault:"100"`
+	MaxFiles      int `env:"REVIEW_MAX_FILES" envDefault:"200"`
+}
+
+// RepositoryConfig lists the git hosts accepted besides GitHub, GitLab and
+// Bitbucket. Entries are host names, optionally with scheme and port, e.g.
+// "git.university.edu" or "http://gitlab.local:8080". AllowLocalURLs enables
+// file:// links for development and tests.
+type RepositoryConfig struct {
+	SelfHostedHosts []string `env:"GIT_SELF_HOSTED_HOSTS" envSeparator:","`
+	AllowLocalURLs  bool     `env:"GIT_ALLOW_LOCAL_URLS" envDefault:"false"`
+}
+
+// WorkspaceConfig limits the directories repositories are cloned into.
+// Dir defaults to a subdirectory of the system temp dir. Workspaces older
+// than OrphanAge are removed on startup.
+type WorkspaceConfig struct {
+	Dir             string        `env:"WORKSPACE_DIR"`
+	DiskQuotaMB     int           `env:"WORKSPACE_DISK_QUOTA_MB" envDefault:"2048"`
+	OrphanAge       time.Duration `env:"WORKSPACE_ORPHAN_AGE" envDefault:"30m"`
+	CloneMaxSizeMB  int           `env:"CLONE_MAX_SIZE_MB" envDefault:"200"`
+	CloneTimeout    time.Duration `env:"CLONE_TIMEOUT" envDefault:"2m"`
+	CloneSubmodules bool          `env:"CLONE_SUBMODULES" envDefault:"false"`
+}
+
+// ArchiveConfig limits zip archive submissions. MaxCompressionRatio guards
+// against zip bombs: no entry may expand more than that many times.
+type ArchiveConfig struct {
+	MaxUploadMB         int `env:"ARCHIVE_MAX_UPLOAD_MB" envDefault:"20"`
+	MaxUncompressedMB   int `env:"ARCHIVE_MAX_UNCOMPRESSED_MB" envDefault:"100"`
+	MaxEntries          int `env:"ARCHIVE_MAX_ENTRIES" envDefault:"5000"`
+	MaxCompressionRatio int `env:"ARCHIVE_MAX_COMPRESSION_RATIO" envDefault:"100"`
+}
+
+// LintConfig selects the rules of the static analysis pre-pass that runs
+// before the AI review. MaxIssues caps the findings stored per review.
+type LintConfig struct {
+	Enabled   bool     `env:"LINT_ENABLED" envDefault:"true"`
+	Rules     []string `env:"LINT_RULES" envSeparator:"," envDefault:"avoid_print,empty_catches,prefer_const_constructors,unnecessary_new,unused_import"`
+	MaxIssues int      `env:"LINT_MAX_ISSUES" envDefault:"50"`
+}
+
+// ScoringConfig controls how criterion verdicts become a suggested score.
+// PartialCredit is the share of a criterion's weight given for a partially
+// met criterion; MandatoryFailureCap is the share of the task's max score a
+// submission can get at most when a mandatory criterion is not met.
+type ScoringConfig struct {
+	PartialCredit       float64 `env:"SCORING_PARTIAL_CREDIT" envDefault:"0.5"`
+	MandatoryFailureCap float64 `env:"SCORING_MANDATORY_FAILURE_CAP" envDefault:"0.5"`
+}
+
+type AuthConfig struct {
+	JWTSecret       string        `env:"JWT_SECRET,required"`
+	Issuer          string        `env:"JWT_ISSUER" envDefault:"flutter-code-mentor"`
+	AccessTokenTTL  time.Duration `env:"JWT_ACCESS_TTL" envDefault:"15m"`
+	RefreshTokenTTL time.Duration `env:"JWT_REFRESH_TTL" envDefault:"720h"`
+}
+
 type ServerConfig struct {
 	Port string `env:"SERVER_PORT" envDefault:"8080"`
 }
@@ -41,6 +179,15 @@ func Load() *Config {
 		log.Fatalf("Failed to parse config: %v", err)
 	}
 
+	if strings.EqualFold(cfg.AI.Provider, "deepseek") {
+		if cfg.AI.APIKey == "" {
+			cfg.AI.APIKey = cfg.DeepSeekAPIKey
+		}
+		if cfg.AI.APIURL == "" {
+			cfg.AI.APIURL = cfg.DeepSeekAPIURL
+		}
+	}
+
 	return cfg
 }
 
@@ -7,6 +7,7 @@ type SubmissionType string
 const (
 	SubmissionTypeCode       SubmissionType = "code"
 	SubmissionTypeGithubLink SubmissionType = "github_link"
+	SubmissionTypeZipArchive SubmissionType = "zip_archive"
 )
 
 type SubmissionStatus string
@@ -29,6 +30,18 @@ type Submission struct {
 	Score          *float64         `db:"score"`
 	Status         SubmissionStatus `db:"status"`
 	SubmissionType SubmissionType   `db:"submission_type"`
+
+	PreviousSubmissionID *int `db:"previous_submission_id"`
+
+	GitRef    *string `db:"git_ref"`
+	CommitSHA *string `db:"commit_sha"`
+
+	ArchiveName   *string `db:"archive_name"`
+	ArchiveSHA256 *string `db:"archive_sha256"`
+
+	// MultiFile marks a code submission pasted as several files; its
+	// content lives in submission_files instead of Code.
+	MultiFile bool `db:"multi_file"`
 }
 
 type Task struct {
@@ -40,6 +53,17 @@ type Task struct {
 	MaxScore    int        `db:"max_score"`
 	CreatedAt   time.Time  `db:"created_at"`
 	UpdatedAt   *time.Time `db:"updated_at"`
+
+	FileRules FileSelectionRules
+}
+
+// FileSelectionRules narrow down which repository files are reviewed for a
+// task. Empty patterns and nil limits fall back to the service defaults.
+type FileSelectionRules struct {
+	IncludePatterns []string `db:"include_patterns"`
+	ExcludePatterns []string `db:"exclude_patterns"`
+	MaxFileSizeKB   *int     `db:"max_file_size_kb"`
+	MaxFiles        *int     `db:"max_files"`
 }
 
 type User struct {
@@ -51,6 +75,23 @@ type User struct {
 	LastName     string     `db:"last_name"`
 	CreatedAt    time.Time  `db:"created_at"`
 	LastLogin    *time.Time `db:"last_login"`
+	Language     *Language  `db:"language"`
+}
+
+// Language is the language AI feedback and API messages are written in.
+type Language string
+
+const (
+	LanguageEnglish Language = "en"
+	LanguageRussian Language = "ru"
+
+	// DefaultLanguage is used when neither the user nor the course has
+	// chosen a language.
+	DefaultLanguage = LanguageEnglish
+)
+
+func (l Language) Valid() bool {
+	return l == LanguageEnglish || l == LanguageRussian
 }
 
 type TaskStatus string
@@ -68,9 +109,35 @@ type Course struct {
 	StartDate   time.Time  `db:"start_date"`
 	EndDate     *time.Time `db:"end_date"`
 	IsActive    bool       `db:"is_active"`
+	InviteCode  *string    `db:"invite_code"`
+	Language    *Language  `db:"language"`
 	CreatedAt   time.Time  `db:"created_at"`
 }
 
+type EnrollmentStatus string
+
+const (
+	EnrollmentActive    EnrollmentStatus = "active"
+	EnrollmentCompleted EnrollmentStatus = "completed"
+	EnrollmentDropped   EnrollmentStatus = "dropped"
+	EnrollmentFailed    EnrollmentStatus = "failed"
+)
+
+type CourseEnrollment struct {
+	StudentID        int              `db:"student_id"`
+	CourseID         int              `db:"course_id"`
+	EnrolledAt       time.Time        `db:"enrolled_at"`
+	CompletionStatus EnrollmentStatus `db:"completion_status"`
+	FinalScore       *float64         `db:"final_score"`
+}
+
+type RosterEntry struct {
+	CourseEnrollment
+	Email     string `db:"email"`
+	FirstName string `db:"first_name"`
+	LastName  string `db:"last_name"`
+}
+
 type CodeReview struct {
 	ID              int       `db:"id"`
 	SubmissionID    int       `db:"submission_id"`
@@ -78,7 +145,114 @@ type CodeReview struct {
 	OverallStatus   string    `db:"overall_status"`
 	AIConfidence    *float64  `db:"ai_confidence"`
 	ExecutionTimeMs *int      `db:"execution_time_ms"`
+	SuggestedScore  *float64  `db:"suggested_score"`
 	CreatedAt       time.Time `db:"created_at"`
+
+	// PromptTemplateID and PromptTemplateVersion identify the teacher's
+	// prompt template the review used; nil for the built-in prompt.
+	PromptTemplateID      *int `db:"prompt_template_id"`
+	PromptTemplateVersion *int `db:"prompt_template_version"`
+
+	// PromptTokens, CompletionTokens and EstimatedCost meter the model
+	// calls of the review; zero for a review reused from the cache and nil
+	// for reviews made before metering.
+	PromptTokens     *int     `db:"prompt_tokens"`
+	CompletionTokens *int     `db:"completion_tokens"`
+	EstimatedCost    *float64 `db:"estimated_cost"`
+}
+
+// AIModelCall is one metered request to the model for a submission. Every
+// call is recorded, including those of failed and repeated review attempts.
+type AIModelCall struct {
+	ID               int       `db:"id"`
+	SubmissionID     int       `db:"submission_id"`
+	AIModel          string    `db:"ai_model"`
+	PromptTokens     int       `db:"prompt_tokens"`
+	CompletionTokens int       `db:"completion_tokens"`
+	EstimatedCost    float64   `db:"estimated_cost"`
+	CreatedAt        time.Time `db:"created_at"`
+}
+
+// AIUsage sums the model calls of a course or a student. Reviews counts the
+// submissions they were made for.
+type AIUsage struct {
+	Reviews          int     `db:"reviews"`
+	PromptTokens     int     `db:"prompt_tokens"`
+	CompletionTokens int     `db:"completion_tokens"`
+	EstimatedCost    float64 `db:"estimated_cost"`
+}
+
+type StudentAIUsage struct {
+	StudentID int    `db:"student_id"`
+	FirstName string `db:"first_name"`
+	LastName  string `db:"last_name"`
+	AIUsage
+}
+
+// AIBudget limits the estimated AI cost of a course per day and per month.
+// A nil limit falls back to the configured default; zero means no limit.
+type AIBudget struct {
+	CourseID     int       `db:"course_id"`
+	DailyLimit   *float64  `db:"daily_limit"`
+	MonthlyLimit *float64  `db:"monthly_limit"`
+	UpdatedAt    time.Time `db:"updated_at"`
+}
+
+// AISpend is the estimated AI cost of a course so far today and this month.
+// NextDay and NextMonth are when the periods end, by the same clock the
+// spend is counted with.
+type AISpend struct {
+	Today     float64
+	ThisMonth float64
+	NextDay   time.Time
+	NextMonth time.Time
+}
+
+// PromptScope is what a prompt template overrides the review prompt for.
+type PromptScope string
+
+const (
+	PromptScopeCourse PromptScope = "course"
+	PromptScopeTask   PromptScope = "task"
+)
+
+// PromptTemplate overrides the review prompt for all tasks of a course or
+// for one task. Every change is stored as a new version and only the latest
+// one is active; a task template takes precedence over a course template.
+type PromptTemplate struct {
+	ID           int       `db:"id"`
+	CourseID     *int      `db:"course_id"`
+	TaskID       *int      `db:"task_id"`
+	Version      int       `db:"version"`
+	SystemPrompt *string   `db:"system_prompt"`
+	UserPrompt   string    `db:"user_prompt"`
+	IsActive     bool      `db:"is_active"`
+	CreatedBy    *int      `db:"created_by"`
+	CreatedAt    time.Time `db:"created_at"`
+}
+
+// ReviewCacheEntry is a stored AI review result, reused for submissions
+// with the same cache key until it expires. Result is the JSON encoded
+// review as the model returned it, before it was anchored to the files.
+type ReviewCacheEntry struct {
+	CacheKey  string    `db:"cache_key"`
+	TaskID    int       `db:"task_id"`
+	AIModel   string    `db:"ai_model"`
+	Result    []byte    `db:"result"`
+	HitCount  int       `db:"hit_count"`
+	CreatedAt time.Time `db:"created_at"`
+	ExpiresAt time.Time `db:"expires_at"`
+}
+
+// FeedbackTypes are the kinds of review feedback, whether written by the AI
+// or by a teacher.
+var FeedbackTypes = map[string]bool{
+	"critical_error": true,
+	"logic_error":    true,
+	"style_issue":    true,
+	"performance":    true,
+	"security_risk":  true,
+	"improvement":    true,
 }
 
 type ReviewFeedback struct {
@@ -95,9 +269,27 @@ type ReviewFeedback struct {
 	IsResolved      bool      `db:"is_resolved"`
 	TeacherComment  *string   `db:"teacher_comment"`
 	TeacherApproved *bool     `db:"teacher_approved"`
+	AuthorID        *int      `db:"author_id"`
 	CreatedAt       time.Time `db:"created_at"`
+
+	Origin   FeedbackOrigin `db:"origin"`
+	LintRule *string        `db:"lint_rule"`
+
+	// IsAnchored tells whether the snippet was found in the submitted files
+	// at the stored lines. It is nil for feedback that was not checked.
+	IsAnchored *bool `db:"is_anchored"`
 }
 
+// FeedbackOrigin tells who reported an issue. Static analysis findings come
+// from the lint pre-pass that runs before the AI review.
+type FeedbackOrigin string
+
+const (
+	FeedbackOriginAI             FeedbackOrigin = "ai"
+	FeedbackOriginTeacher        FeedbackOrigin = "teacher"
+	FeedbackOriginStaticAnalysis FeedbackOrigin = "static_analysis"
+)
+
 type TaskCriteria struct {
 	ID                   int       `db:"id"`
 	TaskID               int       `db:"task_id"`
@@ -107,3 +299,50 @@ type TaskCriteria struct {
 	Weight               int       `db:"weight"`
 	CreatedAt            time.Time `db:"created_at"`
 }
+
+type CriterionVerdict string
+
+const (
+	VerdictMet          CriterionVerdict = "met"
+	VerdictPartiallyMet CriterionVerdict = "partially_met"
+	VerdictNotMet       CriterionVerdict = "not_met"
+)
+
+type CriterionResult struct {
+	ID          int              `db:"id"`
+	ReviewID    int              `db:"review_id"`
+	CriterionID int              `db:"criterion_id"`
+	Verdict     CriterionVerdict `db:"verdict"`
+	Evidence    *string          `db:"evidence"`
+	CreatedAt   time.Time        `db:"created_at"`
+}
+
+type CriterionReport struct {
+	CriterionResult
+	CriterionName string `db:"criterion_name"`
+	IsMandatory   bool   `db:"is_mandatory"`
+	Weight        int    `db:"weight"`
+}
+
+type ReviewJobStatus string
+
+const (
+	ReviewJobQueued  ReviewJobStatus = "queued"
+	ReviewJobRunning ReviewJobStatus = "running"
+	ReviewJobDone    ReviewJobStatus = "done"
+	ReviewJobFailed  ReviewJobStatus = "failed"
+)
+
+type ReviewJob struct {
+	ID            int             `db:"id"`
+	SubmissionID  int             `db:"submission_id"`
+	Status        ReviewJobStatus `db:"status"`
+	Attempts      int             `db:"attempts"`
+	LastError     *string         `db:"last_error"`
+	NextAttemptAt time.Time       `db:"next_attempt_at"`
+	LockedBy      *string         `db:"locked_by"`
+	LockedUntil   *time.Time      `db:"locked_until"`
+	ClaimSeq      int             `db:"claim_seq"`
+	CreatedAt     time.Time       `db:"created_at"`
+	UpdatedAt     time.Time       `db:"updated_at"`
+}
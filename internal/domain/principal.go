@@ -0,0 +1,53 @@
+package domain
+
+import "context"
+
+const (
+	RoleStudent = "student"
+	RoleTeacher = "teacher"
+	RoleAdmin   = "admin"
+)
+
+// Principal is the authenticated user a request is executed on behalf of.
+type Principal struct {
+	UserID int
+	Role   string
+}
+
+func (p *Principal) HasRole(roles ...string) bool {
+	for _, role := range roles {
+		if p.Role == role {
+			return true
+		}
+	}
+	return false
+}
+
+type principalKey struct{}
+
+func WithPrincipal(ctx context.Context, principal *Principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, principal)
+}
+
+func PrincipalFromContext(ctx context.Context) (*Principal, bool) {
+	principal, ok := ctx.Value(principalKey{}).(*Principal)
+	return principal, ok && principal != nil
+}
+
+type languageLookupKey struct{}
+
+// WithLanguageLookup attaches a lookup of the language the current user has
+// chosen. It is only called when a response is localized.
+func WithLanguageLookup(ctx context.Context, lookup func() Language) context.Context {
+	return context.WithValue(ctx, languageLookupKey{}, lookup)
+}
+
+// LanguageFromContext returns the language the current user has chosen, or
+// "" if they have not chosen one or the request is anonymous.
+func LanguageFromContext(ctx context.Context) Language {
+	lookup, ok := ctx.Value(languageLookupKey{}).(func() Language)
+	if !ok || lookup == nil {
+		return ""
+	}
+	return lookup()
+}
@@ -0,0 +1,123 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/ilyin-ad/flutter-code-mentor/api"
+	"github.com/ilyin-ad/flutter-code-mentor/internal/domain"
+	"github.com/ilyin-ad/flutter-code-mentor/internal/usecase"
+	"github.com/labstack/echo/v4"
+	"go.uber.org/zap"
+)
+
+type AIUsageHandler struct {
+	aiUsageUseCase usecase.AIUsageUseCase
+	logger         *zap.Logger
+}
+
+func NewAIUsageHandler(aiUsageUseCase usecase.AIUsageUseCase, logger *zap.Logger) *AIUsageHandler {
+	return &AIUsageHandler{
+		aiUsageUseCase: aiUsageUseCase,
+		logger:         logger,
+	}
+}
+
+type AIBudgetRequest struct {
+	DailyLimit   *float64 `json:"daily_limit"`
+	MonthlyLimit *float64 `json:"monthly_limit"`
+}
+
+func (h *AIUsageHandler) GetCoursesIdAiUsage(ctx echo.Context, id api.IdPath, params api.GetCoursesIdAiUsageParams) error {
+	usage, err := h.aiUsageUseCase.GetCourseUsage(ctx.Request().Context(), id, params.From, params.To)
+	if err != nil {
+		return h.handleError(ctx, err)
+	}
+
+	students := make([]api.StudentAIUsage, len(usage.Students))
+	for i, student := range usage.Students {
+		students[i] = api.StudentAIUsage{
+			StudentId:        &student.StudentID,
+			FirstName:        &student.FirstName,
+			LastName:         &student.LastName,
+			Reviews:          &student.Reviews,
+			PromptTokens:     &student.PromptTokens,
+			CompletionTokens: &student.CompletionTokens,
+			EstimatedCost:    &student.EstimatedCost,
+		}
+	}
+
+	total := toAIUsageResponse(usage.Total)
+	budget := toAIBudgetResponse(usage.Budget)
+
+	return ctx.JSON(http.StatusOK, api.CourseAIUsageResponse{
+		CourseId: &usage.CourseID,
+		From:     usage.From,
+		To:       usage.To,
+		Total:    &total,
+		Students: &students,
+		Budget:   &budget,
+	})
+}
+
+func (h *AIUsageHandler) PutCoursesIdAiBudget(ctx echo.Context, id api.IdPath) error {
+	var req AIBudgetRequest
+	if err := ctx.Bind(&req); err != nil {
+		return invalidRequestBody(ctx)
+	}
+
+	status, err := h.aiUsageUseCase.SetCourseBudget(ctx.Request().Context(), &usecase.SetAIBudgetRequest{
+		CourseID:     id,
+		DailyLimit:   req.DailyLimit,
+		MonthlyLimit: req.MonthlyLimit,
+	})
+	if err != nil {
+		return h.handleError(ctx, err)
+	}
+
+	return ctx.JSON(http.StatusOK, toAIBudgetResponse(status))
+}
+
+func toAIUsageResponse(usage *domain.AIUsage) api.AIUsage {
+	return api.AIUsage{
+		Reviews:          &usage.Reviews,
+		PromptTokens:     &usage.PromptTokens,
+		CompletionTokens: &usage.CompletionTokens,
+		EstimatedCost:    &usage.EstimatedCost,
+	}
+}
+
+func toAIBudgetResponse(status *usecase.AIBudgetStatus) api.AIBudgetResponse {
+	return api.AIBudgetResponse{
+		DailyLimit:     &status.DailyLimit,
+		MonthlyLimit:   &status.MonthlyLimit,
+		SpentToday:     &status.SpentToday,
+		SpentThisMonth: &status.SpentThisMonth,
+		Paused:         &status.Paused,
+		ResumesAt:      status.ResumesAt,
+	}
+}
+
+func (h *AIUsageHandler) handleError(ctx echo.Context, err error) error {
+	var validationErr *usecase.ValidationError
+	if errors.As(err, &validationErr) {
+		return validationErrorResponse(ctx, http.StatusBadRequest, validationErr)
+	}
+
+	if errors.Is(err, usecase.ErrCourseNotFound) {
+		return ctx.JSON(http.StatusNotFound, api.NotFound{
+			Error: stringPtr("Course not found"),
+		})
+	}
+
+	if errors.Is(err, usecase.ErrUnauthorized) {
+		return ctx.JSON(http.StatusForbidden, api.ApiError{
+			Error: stringPtr("Access denied"),
+		})
+	}
+
+	h.logger.Error("AI usage request failed", zap.Error(err))
+	return ctx.JSON(http.StatusInternalServerError, map[string]string{
+		"error": "Internal server error",
+	})
+}
@@ -0,0 +1,92 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/ilyin-ad/flutter-code-mentor/api"
+	"github.com/ilyin-ad/flutter-code-mentor/internal/usecase"
+	"github.com/labstack/echo/v4"
+	"go.uber.org/zap"
+)
+
+type AuthHandler struct {
+	authUseCase usecase.AuthUseCase
+	logger      *zap.Logger
+}
+
+func NewAuthHandler(authUseCase usecase.AuthUseCase, logger *zap.Logger) *AuthHandler {
+	return &AuthHandler{
+		authUseCase: authUseCase,
+		logger:      logger,
+	}
+}
+
+type LoginRequest struct {
+	Email    string `json:"email" validate:"required,email"`
+	Password string `json:"password" validate:"required"`
+}
+
+type RefreshRequest struct {
+	RefreshToken string `json:"refresh_token" validate:"required"`
+}
+
+func (h *AuthHandler) PostAuthLogin(ctx echo.Context) error {
+	var req LoginRequest
+	if err := ctx.Bind(&req); err != nil || req.Email == "" || req.Password == "" {
+		return invalidRequestBody(ctx)
+	}
+
+	resp, err := h.authUseCase.Login(ctx.Request().Context(), req.Email, req.Password)
+	if err != nil {
+		return h.handleError(ctx, err)
+	}
+
+	h.logger.Info("User logged in", zap.Int("user_id", resp.UserID))
+
+	return ctx.JSON(http.StatusOK, toTokenResponse(resp))
+}
+
+func (h *AuthHandler) PostAuthRefresh(ctx echo.Context) error {
+	var req RefreshRequest
+	if err := ctx.Bind(&req); err != nil || req.RefreshToken == "" {
+		return invalidRequestBody(ctx)
+	}
+
+	resp, err := h.authUseCase.Refresh(ctx.Request().Context(), req.RefreshToken)
+	if err != nil {
+		return h.handleError(ctx, err)
+	}
+
+	return ctx.JSON(http.StatusOK, toTokenResponse(resp))
+}
+
+func toTokenResponse(resp *usecase.AuthResponse) api.TokenResponse {
+	return api.TokenResponse{
+		AccessToken:  &resp.AccessToken,
+		RefreshToken: &resp.RefreshToken,
+		TokenType:    stringPtr("Bearer"),
+		ExpiresIn:    &resp.ExpiresIn,
+		UserId:       &resp.UserID,
+		Role:         &resp.Role,
+	}
+}
+
+func (h *AuthHandler) handleError(ctx echo.Context, err error) error {
+	if errors.Is(err, usecase.ErrInvalidCredentials) {
+		return ctx.JSON(http.StatusUnauthorized, api.ApiError{
+			Error: stringPtr("Invalid email or password"),
+		})
+	}
+
+	if errors.Is(err, usecase.ErrInvalidToken) {
+		return ctx.JSON(http.StatusUnauthorized, api.ApiError{
+			Error: stringPtr("Invalid or expired refresh token"),
+		})
+	}
+
+	h.logger.Error("Authentication failed", zap.Error(err))
+	return ctx.JSON(http.StatusInternalServerError, map[string]string{
+		"error": "Internal server error",
+	})
+}
@@ -24,12 +24,13 @@ func NewCourseHandler(courseUseCase usecase.CourseUseCase, logger *zap.Logger) *
 }
 
 type CreateCourseRequest struct {
-	TeacherID   int        `json:"teacher_id" validate:"required,min=1"`
+	TeacherID   int        `json:"teacher_id,omitempty"`
 	Title       string     `json:"title" validate:"required,min=3,max=100"`
 	Description *string    `json:"description,omitempty"`
 	StartDate   time.Time  `json:"start_date" validate:"required"`
 	EndDate     *time.Time `json:"end_date,omitempty"`
 	IsActive    *bool      `json:"is_active,omitempty"`
+	Language    *string    `json:"language,omitempty"`
 }
 
 func (h *CourseHandler) PostCourses(ctx echo.Context) error {
@@ -41,9 +42,7 @@ func (h *CourseHandler) PostCourses(ctx echo.Context) error {
 	var req CreateCourseRequest
 	if err := ctx.Bind(&req); err != nil {
 		h.logger.Warn("Invalid request body", zap.Error(err))
-		return ctx.JSON(http.StatusBadRequest, api.ValidationError{
-			Error: stringPtr("Invalid request body"),
-		})
+		return invalidRequestBody(ctx)
 	}
 
 	isActive := true
@@ -58,6 +57,7 @@ func (h *CourseHandler) PostCourses(ctx echo.Context) error {
 		StartDate:   req.StartDate,
 		EndDate:     req.EndDate,
 		IsActive:    isActive,
+		Language:    req.Language,
 	}
 
 	resp, err := h.courseUseCase.CreateCourse(ctx.Request().Context(), usecaseReq)
@@ -77,29 +77,46 @@ func (h *CourseHandler) PostCourses(ctx echo.Context) error {
 		StartDate:   &resp.StartDate,
 		EndDate:     resp.EndDate,
 		IsActive:    &resp.IsActive,
+		Language:    (*api.Language)(resp.Language),
 		CreatedAt:   &resp.CreatedAt,
 	}
 
 	return ctx.JSON(http.StatusCreated, response)
 }
 
+func (h *CourseHandler) PutCoursesIdLanguage(ctx echo.Context, id api.IdPath) error {
+	var req LanguageRequest
+	if err := ctx.Bind(&req); err != nil {
+		return invalidRequestBody(ctx)
+	}
+
+	course, err := h.courseUseCase.SetLanguage(ctx.Request().Context(), id, req.Language)
+	if err != nil {
+		if errors.Is(err, usecase.ErrUnauthorized) {
+			return ctx.JSON(http.StatusForbidden, api.ApiError{
+				Error: stringPtr("Access denied"),
+			})
+		}
+		return h.handleError(ctx, err)
+	}
+
+	return ctx.JSON(http.StatusOK, api.CourseResponse{
+		CourseId:    &course.ID,
+		TeacherId:   &course.TeacherID,
+		Title:       &course.Title,
+		Description: course.Description,
+		StartDate:   &course.StartDate,
+		EndDate:     course.EndDate,
+		IsActive:    &course.IsActive,
+		Language:    (*api.Language)(course.Language),
+		CreatedAt:   &course.CreatedAt,
+	})
+}
+
 func (h *CourseHandler) handleError(ctx echo.Context, err error) error {
 	var validationErr *usecase.ValidationError
 	if errors.As(err, &validationErr) {
-		details := make([]struct {
-			Field   *string `json:"field,omitempty"`
-			Message *string `json:"message,omitempty"`
-		}, len(validationErr.Details))
-
-		for i, detail := range validationErr.Details {
-			details[i].Field = stringPtr(detail.Field)
-			details[i].Message = stringPtr(detail.Message)
-		}
-
-		return ctx.JSON(http.StatusBadRequest, api.ValidationError{
-			Error:   stringPtr(validationErr.Message),
-			Details: &details,
-		})
+		return validationErrorResponse(ctx, http.StatusBadRequest, validationErr)
 	}
 
 	if errors.Is(err, usecase.ErrUserNotFound) {
@@ -108,9 +125,15 @@ func (h *CourseHandler) handleError(ctx echo.Context, err error) error {
 		})
 	}
 
+	if errors.Is(err, usecase.ErrCourseNotFound) {
+		return ctx.JSON(http.StatusNotFound, api.NotFound{
+			Error: stringPtr("Course not found"),
+		})
+	}
+
 	if errors.Is(err, usecase.ErrUnauthorized) {
 		return ctx.JSON(http.StatusForbidden, api.ApiError{
-			Error: stringPtr("Only teachers can create courses"),
+			Error: stringPtr("Only teachers and admins can create courses"),
 		})
 	}
 
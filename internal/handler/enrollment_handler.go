@@ -0,0 +1,196 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/ilyin-ad/flutter-code-mentor/api"
+	"github.com/ilyin-ad/flutter-code-mentor/internal/domain"
+	"github.com/ilyin-ad/flutter-code-mentor/internal/usecase"
+	"github.com/labstack/echo/v4"
+	"go.uber.org/zap"
+)
+
+type EnrollmentHandler struct {
+	enrollmentUseCase usecase.EnrollmentUseCase
+	logger            *zap.Logger
+}
+
+func NewEnrollmentHandler(enrollmentUseCase usecase.EnrollmentUseCase, logger *zap.Logger) *EnrollmentHandler {
+	return &EnrollmentHandler{
+		enrollmentUseCase: enrollmentUseCase,
+		logger:            logger,
+	}
+}
+
+type EnrollStudentRequest struct {
+	StudentID int `json:"student_id" validate:"required,min=1"`
+}
+
+type UpdateEnrollmentStatusRequest struct {
+	CompletionStatus string   `json:"completion_status" validate:"required"`
+	FinalScore       *float64 `json:"final_score,omitempty"`
+}
+
+type JoinCourseRequest struct {
+	InviteCode string `json:"invite_code" validate:"required"`
+}
+
+func (h *EnrollmentHandler) GetCoursesIdEnrollments(ctx echo.Context, id api.IdPath) error {
+	roster, err := h.enrollmentUseCase.ListRoster(ctx.Request().Context(), id)
+	if err != nil {
+		return h.handleError(ctx, err)
+	}
+
+	response := make([]api.EnrollmentResponse, len(roster))
+	for i, entry := range roster {
+		response[i] = toEnrollmentResponse(&entry.CourseEnrollment)
+		response[i].Email = &entry.Email
+		response[i].FirstName = &entry.FirstName
+		response[i].LastName = &entry.LastName
+	}
+
+	return ctx.JSON(http.StatusOK, response)
+}
+
+func (h *EnrollmentHandler) PostCoursesIdEnrollments(ctx echo.Context, id api.IdPath) error {
+	var req EnrollStudentRequest
+	if err := ctx.Bind(&req); err != nil || req.StudentID < 1 {
+		return invalidRequestBody(ctx)
+	}
+
+	enrollment, err := h.enrollmentUseCase.EnrollStudent(ctx.Request().Context(), id, req.StudentID)
+	if err != nil {
+		return h.handleError(ctx, err)
+	}
+
+	h.logger.Info("Student enrolled",
+		zap.Int("course_id", id),
+		zap.Int("student_id", req.StudentID),
+	)
+
+	return ctx.JSON(http.StatusCreated, toEnrollmentResponse(enrollment))
+}
+
+func (h *EnrollmentHandler) DeleteCoursesIdEnrollmentsStudentId(ctx echo.Context, id api.IdPath, studentId api.StudentIdPath) error {
+	if err := h.enrollmentUseCase.UnenrollStudent(ctx.Request().Context(), id, studentId); err != nil {
+		return h.handleError(ctx, err)
+	}
+
+	h.logger.Info("Student unenrolled",
+		zap.Int("course_id", id),
+		zap.Int("student_id", studentId),
+	)
+
+	return ctx.NoContent(http.StatusNoContent)
+}
+
+func (h *EnrollmentHandler) PatchCoursesIdEnrollmentsStudentId(ctx echo.Context, id api.IdPath, studentId api.StudentIdPath) error {
+	var req UpdateEnrollmentStatusRequest
+	if err := ctx.Bind(&req); err != nil {
+		return invalidRequestBody(ctx)
+	}
+
+	enrollment, err := h.enrollmentUseCase.UpdateStatus(ctx.Request().Context(), &usecase.UpdateEnrollmentRequest{
+		CourseID:         id,
+		StudentID:        studentId,
+		CompletionStatus: req.CompletionStatus,
+		FinalScore:       req.FinalScore,
+	})
+	if err != nil {
+		return h.handleError(ctx, err)
+	}
+
+	return ctx.JSON(http.StatusOK, toEnrollmentResponse(enrollment))
+}
+
+func (h *EnrollmentHandler) PostCoursesIdInviteCode(ctx echo.Context, id api.IdPath) error {
+	inviteCode, err := h.enrollmentUseCase.RotateInviteCode(ctx.Request().Context(), id)
+	if err != nil {
+		return h.handleError(ctx, err)
+	}
+
+	return ctx.JSON(http.StatusOK, api.InviteCodeResponse{
+		CourseId:   &id,
+		InviteCode: &inviteCode,
+	})
+}
+
+func (h *EnrollmentHandler) PostEnrollmentsJoin(ctx echo.Context) error {
+	var req JoinCourseRequest
+	if err := ctx.Bind(&req); err != nil || req.InviteCode == "" {
+		return invalidRequestBody(ctx)
+	}
+
+	enrollment, err := h.enrollmentUseCase.JoinByInviteCode(ctx.Request().Context(), req.InviteCode)
+	if err != nil {
+		return h.handleError(ctx, err)
+	}
+
+	return ctx.JSON(http.StatusOK, toEnrollmentResponse(enrollment))
+}
+
+func toEnrollmentResponse(enrollment *domain.CourseEnrollment) api.EnrollmentResponse {
+	status := string(enrollment.CompletionStatus)
+	response := api.EnrollmentResponse{
+		CourseId:         &enrollment.CourseID,
+		StudentId:        &enrollment.StudentID,
+		CompletionStatus: &status,
+		EnrolledAt:       &enrollment.EnrolledAt,
+	}
+
+	if enrollment.FinalScore != nil {
+		score := float32(*enrollment.FinalScore)
+		response.FinalScore = &score
+	}
+
+	return response
+}
+
+func (h *EnrollmentHandler) handleError(ctx echo.Context, err error) error {
+	var validationErr *usecase.ValidationError
+	if errors.As(err, &validationErr) {
+		return validationErrorResponse(ctx, http.StatusBadRequest, validationErr)
+	}
+
+	if errors.Is(err, usecase.ErrCourseNotFound) {
+		return ctx.JSON(http.StatusNotFound, api.NotFound{
+			Error: stringPtr("Course not found"),
+		})
+	}
+
+	if errors.Is(err, usecase.ErrUserNotFound) {
+		return ctx.JSON(http.StatusNotFound, api.NotFound{
+			Error: stringPtr("User not found"),
+		})
+	}
+
+	if errors.Is(err, usecase.ErrEnrollmentNotFound) {
+		return ctx.JSON(http.StatusNotFound, api.NotFound{
+			Error: stringPtr("Enrollment not found"),
+		})
+	}
+
+	if errors.Is(err, usecase.ErrInvalidInviteCode) {
+		return ctx.JSON(http.StatusNotFound, api.NotFound{
+			Error: stringPtr("Invalid invite code"),
+		})
+	}
+
+	if errors.Is(err, usecase.ErrInvalidStatusTransition) {
+		return ctx.JSON(http.StatusConflict, api.ApiError{
+			Error: stringPtr(err.Error()),
+		})
+	}
+
+	if errors.Is(err, usecase.ErrUnauthorized) {
+		return ctx.JSON(http.StatusForbidden, api.ApiError{
+			Error: stringPtr("Access denied"),
+		})
+	}
+
+	h.logger.Error("Enrollment request failed", zap.Error(err))
+	return ctx.JSON(http.StatusInternalServerError, map[string]string{
+		"error": "Internal server error",
+	})
+}
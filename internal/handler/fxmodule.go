@@ -12,6 +12,12 @@ func FxModule() fx.Option {
 			NewTaskHandler,
 			NewUserHandler,
 			NewCourseHandler,
+			NewReviewHandler,
+			NewAuthHandler,
+			NewEnrollmentHandler,
+			NewTeacherReviewHandler,
+			NewPromptTemplateHandler,
+			NewAIUsageHandler,
 		),
 	)
 }
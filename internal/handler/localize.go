@@ -0,0 +1,133 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/ilyin-ad/flutter-code-mentor/api"
+	"github.com/ilyin-ad/flutter-code-mentor/internal/domain"
+	"github.com/ilyin-ad/flutter-code-mentor/internal/usecase"
+	"github.com/labstack/echo/v4"
+)
+
+// russianMessages translates the validation messages the use cases return.
+// Messages missing here are sent in English.
+var russianMessages = map[string]string{
+	"Invalid request body": "Некорректное тело запроса",
+	"Validation failed":    "Ошибка валидации",
+
+	"Required":                              "Обязательное поле",
+	"Invalid email format":                  "Некорректный формат email",
+	"Must be at least %d characters":        "Должно содержать не менее %d символов",
+	"Must be at most %d characters":         "Должно содержать не более %d символов",
+	"Must be between %d and %d characters":  "Должно содержать от %d до %d символов",
+	"Must be between %d and %d":             "Должно быть от %d до %d",
+	"Must be between 0 and %d":              "Должно быть от 0 до %d",
+	"Must be greater than 0":                "Должно быть больше 0",
+	"Must be in the future":                 "Должно быть в будущем",
+	"Must be after start_date":              "Должно быть позже start_date",
+	"Must not be less than line_start":      "Должно быть не меньше line_start",
+	"Must not be negative":                  "Не должно быть отрицательным",
+	"Must be after from":                    "Должно быть позже from",
+	"Must be either 'student' or 'teacher'": "Должно быть 'student' или 'teacher'",
+	"Must be one of 'en', 'ru'":             "Должно быть одним из: 'en', 'ru'",
+
+	"Must be one of 'active', 'completed', 'dropped', 'failed'":                                                    "Должно быть одним из: 'active', 'completed', 'dropped', 'failed'",
+	"Must be one of 'code', 'github_link' or 'zip_archive'":                                                        "Должно быть одним из: 'code', 'github_link', 'zip_archive'",
+	"Must be one of 'teacher_reviewed', 'accepted'":                                                                "Должно быть одним из: 'teacher_reviewed', 'accepted'",
+	"Must be one of 'critical_error', 'logic_error', 'style_issue', 'performance', 'security_risk', 'improvement'": "Должно быть одним из: 'critical_error', 'logic_error', 'style_issue', 'performance', 'security_risk', 'improvement'",
+
+	"Must reference a user with the student role": "Должно ссылаться на пользователя с ролью student",
+	"Must reference a user with the teacher role": "Должно ссылаться на пользователя с ролью teacher",
+	"Code snippet is required":                    "Фрагмент кода обязателен",
+	"Description is required":                     "Описание обязательно",
+	"Score is required to accept a submission":    "Чтобы принять посылку, нужно указать оценку",
+
+	"Required when submission_type is 'code' and no files are given": "Обязательно, если submission_type равен 'code' и файлы не переданы",
+	"Required when submission_type is 'github_link'":                 "Обязательно, если submission_type равен 'github_link'",
+	"Required when submission_type is 'zip_archive'":                 "Обязательно, если submission_type равен 'zip_archive'",
+	"Should not be provided when submission_type is 'code'":          "Не должно передаваться, если submission_type равен 'code'",
+	"Should not be provided when submission_type is 'github_link'":   "Не должно передаваться, если submission_type равен 'github_link'",
+	"Should not be provided when submission_type is 'zip_archive'":   "Не должно передаваться, если submission_type равен 'zip_archive'",
+	"Should only be provided when submission_type is 'code'":         "Передаётся только если submission_type равен 'code'",
+	"Should only be provided when submission_type is 'zip_archive'":  "Передаётся только если submission_type равен 'zip_archive'",
+	"Provide either code or files, not both":                         "Передайте либо code, либо files, но не оба",
+
+	"Must contain at most %d files":                             "Должно содержать не более %d файлов",
+	"Total size must not exceed %d KB":                          "Общий размер не должен превышать %d КБ",
+	"Must be a relative path like lib/main.dart":                "Должен быть относительный путь вида lib/main.dart",
+	"Only .dart and .yaml files can be submitted":               "Можно отправлять только файлы .dart и .yaml",
+	"Must not be larger than %d MB":                             "Должно быть не больше %d МБ",
+	"Must be a .zip file with a name of at most 255 characters": "Должен быть файл .zip с именем не длиннее 255 символов",
+	"Archive must not be larger than %d MB":                     "Архив должен быть не больше %d МБ",
+	"task_id must be an integer":                                "task_id должен быть целым числом",
+
+	"Must be a zip archive of at most %d files and %d MB unpacked":   "Должен быть zip-архив не более чем из %d файлов и не больше %d МБ в распакованном виде",
+	"Must be a glob pattern relative to the repository root":         "Должен быть glob-шаблон относительно корня репозитория",
+	"Must be a valid template that includes the code with {{.Code}}": "Должен быть корректный шаблон, включающий код через {{.Code}}",
+
+	"Must be a repository URL on one of: %s":                         "Должен быть адрес репозитория на одном из хостингов: %s",
+	"Must be a valid branch, tag or commit name":                     "Должно быть корректным именем ветки, тега или коммита",
+	"Repository is not reachable. Make sure it exists and is public": "Репозиторий недоступен. Убедитесь, что он существует и открыт",
+	"Branch, tag or commit not found in the repository":              "Ветка, тег или коммит не найдены в репозитории",
+}
+
+// requestLanguage picks the language of API messages: the user's setting,
+// else the first supported Accept-Language tag, else English. A browser
+// sends its own locale with every request, so it must not override a
+// language the user has chosen.
+func requestLanguage(ctx echo.Context) domain.Language {
+	if language := domain.LanguageFromContext(ctx.Request().Context()); language.Valid() {
+		return language
+	}
+
+	for _, tag := range strings.Split(ctx.Request().Header.Get("Accept-Language"), ",") {
+		tag, _, _ = strings.Cut(strings.TrimSpace(tag), ";")
+		tag, _, _ = strings.Cut(tag, "-")
+		if language := domain.Language(strings.ToLower(tag)); language.Valid() {
+			return language
+		}
+	}
+
+	return domain.DefaultLanguage
+}
+
+// localize translates message and fills in args. Messages without args are
+// returned as is, so a literal % in them is kept.
+func localize(language domain.Language, message string, args ...any) string {
+	if language == domain.LanguageRussian {
+		if translated, ok := russianMessages[message]; ok {
+			message = translated
+		}
+	}
+	if len(args) == 0 {
+		return message
+	}
+	return fmt.Sprintf(message, args...)
+}
+
+func validationErrorResponse(ctx echo.Context, status int, validationErr *usecase.ValidationError) error {
+	language := requestLanguage(ctx)
+
+	details := make([]struct {
+		Field   *string `json:"field,omitempty"`
+		Message *string `json:"message,omitempty"`
+	}, len(validationErr.Details))
+
+	for i, detail := range validationErr.Details {
+		details[i].Field = stringPtr(detail.Field)
+		details[i].Message = stringPtr(localize(language, detail.Message, detail.Args...))
+	}
+
+	return ctx.JSON(status, api.ValidationError{
+		Error:   stringPtr(localize(language, validationErr.Message)),
+		Details: &details,
+	})
+}
+
+func invalidRequestBody(ctx echo.Context) error {
+	return ctx.JSON(http.StatusBadRequest, api.ValidationError{
+		Error: stringPtr(localize(requestLanguage(ctx), "Invalid request body")),
+	})
+}
@@ -0,0 +1,221 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/ilyin-ad/flutter-code-mentor/api"
+	"github.com/ilyin-ad/flutter-code-mentor/internal/domain"
+	"github.com/ilyin-ad/flutter-code-mentor/internal/usecase"
+	"github.com/labstack/echo/v4"
+	"go.uber.org/zap"
+)
+
+type PromptTemplateHandler struct {
+	promptTemplateUseCase usecase.PromptTemplateUseCase
+	reviewUseCase         usecase.ReviewUseCase
+	logger                *zap.Logger
+}
+
+func NewPromptTemplateHandler(
+	promptTemplateUseCase usecase.PromptTemplateUseCase,
+	reviewUseCase usecase.ReviewUseCase,
+	logger *zap.Logger,
+) *PromptTemplateHandler {
+	return &PromptTemplateHandler{
+		promptTemplateUseCase: promptTemplateUseCase,
+		reviewUseCase:         reviewUseCase,
+		logger:                logger,
+	}
+}
+
+type PromptTemplateRequest struct {
+	SystemPrompt *string `json:"system_prompt,omitempty"`
+	UserPrompt   string  `json:"user_prompt"`
+}
+
+func (h *PromptTemplateHandler) GetCoursesIdPromptTemplate(ctx echo.Context, id api.IdPath) error {
+	return h.getTemplate(ctx, domain.PromptScopeCourse, id)
+}
+
+func (h *PromptTemplateHandler) PutCoursesIdPromptTemplate(ctx echo.Context, id api.IdPath) error {
+	return h.saveTemplate(ctx, domain.PromptScopeCourse, id)
+}
+
+func (h *PromptTemplateHandler) DeleteCoursesIdPromptTemplate(ctx echo.Context, id api.IdPath) error {
+	return h.deleteTemplate(ctx, domain.PromptScopeCourse, id)
+}
+
+func (h *PromptTemplateHandler) GetCoursesIdPromptTemplateVersions(ctx echo.Context, id api.IdPath) error {
+	return h.listVersions(ctx, domain.PromptScopeCourse, id)
+}
+
+func (h *PromptTemplateHandler) GetTasksIdPromptTemplate(ctx echo.Context, id api.IdPath) error {
+	return h.getTemplate(ctx, domain.PromptScopeTask, id)
+}
+
+func (h *PromptTemplateHandler) PutTasksIdPromptTemplate(ctx echo.Context, id api.IdPath) error {
+	return h.saveTemplate(ctx, domain.PromptScopeTask, id)
+}
+
+func (h *PromptTemplateHandler) DeleteTasksIdPromptTemplate(ctx echo.Context, id api.IdPath) error {
+	return h.deleteTemplate(ctx, domain.PromptScopeTask, id)
+}
+
+func (h *PromptTemplateHandler) GetTasksIdPromptTemplateVersions(ctx echo.Context, id api.IdPath) error {
+	return h.listVersions(ctx, domain.PromptScopeTask, id)
+}
+
+func (h *PromptTemplateHandler) PostSubmissionsIdPromptPreview(ctx echo.Context, id api.IdPath) error {
+	var req PromptTemplateRequest
+	if err := ctx.Bind(&req); err != nil {
+		return invalidRequestBody(ctx)
+	}
+
+	var draft *usecase.PromptTemplateDraft
+	if req.UserPrompt != "" || req.SystemPrompt != nil {
+		draft = &usecase.PromptTemplateDraft{
+			SystemPrompt: req.SystemPrompt,
+			UserPrompt:   req.UserPrompt,
+		}
+	}
+
+	preview, err := h.reviewUseCase.PreviewPrompt(ctx.Request().Context(), id, draft)
+	if err != nil {
+		return h.handleError(ctx, err)
+	}
+
+	source := api.PromptPreviewResponseSource(preview.Source)
+	parts := make([]api.PromptPreviewPart, len(preview.Parts))
+	for i, part := range preview.Parts {
+		parts[i] = api.PromptPreviewPart{
+			SystemPrompt:    &part.SystemPrompt,
+			UserPrompt:      &part.UserPrompt,
+			EstimatedTokens: &part.EstimatedTokens,
+		}
+	}
+
+	response := api.PromptPreviewResponse{
+		SubmissionId: &preview.SubmissionID,
+		Source:       &source,
+		Parts:        &parts,
+	}
+	if preview.Template != nil {
+		tmpl := toPromptTemplateResponse(preview.Template)
+		response.Template = &tmpl
+	}
+
+	return ctx.JSON(http.StatusOK, response)
+}
+
+func (h *PromptTemplateHandler) getTemplate(ctx echo.Context, scope domain.PromptScope, id int) error {
+	tmpl, err := h.promptTemplateUseCase.GetTemplate(ctx.Request().Context(), scope, id)
+	if err != nil {
+		return h.handleError(ctx, err)
+	}
+
+	return ctx.JSON(http.StatusOK, toPromptTemplateResponse(tmpl))
+}
+
+func (h *PromptTemplateHandler) saveTemplate(ctx echo.Context, scope domain.PromptScope, id int) error {
+	var req PromptTemplateRequest
+	if err := ctx.Bind(&req); err != nil {
+		return invalidRequestBody(ctx)
+	}
+
+	tmpl, err := h.promptTemplateUseCase.SaveTemplate(ctx.Request().Context(), &usecase.SavePromptTemplateRequest{
+		Scope:        scope,
+		ScopeID:      id,
+		SystemPrompt: req.SystemPrompt,
+		UserPrompt:   req.UserPrompt,
+	})
+	if err != nil {
+		return h.handleError(ctx, err)
+	}
+
+	return ctx.JSON(http.StatusOK, toPromptTemplateResponse(tmpl))
+}
+
+func (h *PromptTemplateHandler) deleteTemplate(ctx echo.Context, scope domain.PromptScope, id int) error {
+	if err := h.promptTemplateUseCase.DeleteTemplate(ctx.Request().Context(), scope, id); err != nil {
+		return h.handleError(ctx, err)
+	}
+
+	return ctx.NoContent(http.StatusNoContent)
+}
+
+func (h *PromptTemplateHandler) listVersions(ctx echo.Context, scope domain.PromptScope, id int) error {
+	templates, err := h.promptTemplateUseCase.ListVersions(ctx.Request().Context(), scope, id)
+	if err != nil {
+		return h.handleError(ctx, err)
+	}
+
+	response := make([]api.PromptTemplateResponse, len(templates))
+	for i, tmpl := range templates {
+		response[i] = toPromptTemplateResponse(tmpl)
+	}
+
+	return ctx.JSON(http.StatusOK, response)
+}
+
+func toPromptTemplateResponse(tmpl *domain.PromptTemplate) api.PromptTemplateResponse {
+	return api.PromptTemplateResponse{
+		TemplateId:   &tmpl.ID,
+		CourseId:     tmpl.CourseID,
+		TaskId:       tmpl.TaskID,
+		Version:      &tmpl.Version,
+		SystemPrompt: tmpl.SystemPrompt,
+		UserPrompt:   &tmpl.UserPrompt,
+		IsActive:     &tmpl.IsActive,
+		CreatedBy:    tmpl.CreatedBy,
+		CreatedAt:    &tmpl.CreatedAt,
+	}
+}
+
+func (h *PromptTemplateHandler) handleError(ctx echo.Context, err error) error {
+	var validationErr *usecase.ValidationError
+	if errors.As(err, &validationErr) {
+		return validationErrorResponse(ctx, http.StatusBadRequest, validationErr)
+	}
+
+	if errors.Is(err, usecase.ErrPromptTemplateNotFound) {
+		return ctx.JSON(http.StatusNotFound, api.NotFound{
+			Error: stringPtr("Prompt template not found"),
+		})
+	}
+
+	if errors.Is(err, usecase.ErrCourseNotFound) {
+		return ctx.JSON(http.StatusNotFound, api.NotFound{
+			Error: stringPtr("Course not found"),
+		})
+	}
+
+	if errors.Is(err, usecase.ErrTaskNotFound) {
+		return ctx.JSON(http.StatusNotFound, api.NotFound{
+			Error: stringPtr("Task not found"),
+		})
+	}
+
+	if errors.Is(err, usecase.ErrSubmissionNotFound) {
+		return ctx.JSON(http.StatusNotFound, api.NotFound{
+			Error: stringPtr("Submission not found"),
+		})
+	}
+
+	if errors.Is(err, usecase.ErrSubmissionFilesNotStored) {
+		return ctx.JSON(http.StatusConflict, api.ApiError{
+			Error: stringPtr("Submission files are available for preview once the submission has been reviewed"),
+		})
+	}
+
+	if errors.Is(err, usecase.ErrUnauthorized) {
+		return ctx.JSON(http.StatusForbidden, api.ApiError{
+			Error: stringPtr("Access denied"),
+		})
+	}
+
+	h.logger.Error("Prompt template request failed", zap.Error(err))
+	return ctx.JSON(http.StatusInternalServerError, map[string]string{
+		"error": "Internal server error",
+	})
+}
@@ -0,0 +1,169 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/ilyin-ad/flutter-code-mentor/api"
+	"github.com/ilyin-ad/flutter-code-mentor/internal/domain"
+	"github.com/ilyin-ad/flutter-code-mentor/internal/usecase"
+	"github.com/labstack/echo/v4"
+	"go.uber.org/zap"
+)
+
+type ReviewHandler struct {
+	reviewUseCase usecase.ReviewUseCase
+	logger        *zap.Logger
+}
+
+func NewReviewHandler(reviewUseCase usecase.ReviewUseCase, logger *zap.Logger) *ReviewHandler {
+	return &ReviewHandler{
+		reviewUseCase: reviewUseCase,
+		logger:        logger,
+	}
+}
+
+func (h *ReviewHandler) GetReviewJobsFailed(ctx echo.Context, params api.GetReviewJobsFailedParams) error {
+	limit := 20
+	if params.Limit != nil {
+		limit = *params.Limit
+	}
+
+	offset := 0
+	if params.Offset != nil {
+		offset = *params.Offset
+	}
+
+	if limit < 1 || limit > 100 || offset < 0 {
+		return ctx.JSON(http.StatusBadRequest, api.ValidationError{
+			Error: stringPtr("limit must be between 1 and 100, offset must not be negative"),
+		})
+	}
+
+	jobs, err := h.reviewUseCase.ListFailedJobs(ctx.Request().Context(), limit, offset)
+	if err != nil {
+		h.logger.Error("Failed to list failed review jobs", zap.Error(err))
+		return ctx.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Internal server error",
+		})
+	}
+
+	response := make([]api.ReviewJobResponse, len(jobs))
+	for i, job := range jobs {
+		response[i] = toReviewJobResponse(job)
+	}
+
+	return ctx.JSON(http.StatusOK, response)
+}
+
+func (h *ReviewHandler) GetSubmissionsIdReview(ctx echo.Context, id api.IdPath) error {
+	result, err := h.reviewUseCase.GetSubmissionReview(ctx.Request().Context(), id)
+	if err != nil {
+		if errors.Is(err, usecase.ErrSubmissionNotFound) {
+			return ctx.JSON(http.StatusNotFound, api.NotFound{
+				Error: stringPtr("Submission not found"),
+			})
+		}
+
+		if errors.Is(err, usecase.ErrUnauthorized) {
+			return ctx.JSON(http.StatusForbidden, api.ApiError{
+				Error: stringPtr("Access denied"),
+			})
+		}
+
+		h.logger.Error("Failed to get submission review",
+			zap.Int("submission_id", id),
+			zap.Error(err),
+		)
+		return ctx.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Internal server error",
+		})
+	}
+
+	response := api.SubmissionReviewResponse{
+		SubmissionId: &result.SubmissionID,
+	}
+
+	if result.ReviewJob != nil {
+		reviewJob := toReviewJobResponse(result.ReviewJob)
+		response.ReviewJob = &reviewJob
+	}
+
+	if result.Review != nil {
+		response.Review = &api.CodeReviewResponse{
+			ReviewId:              &result.Review.ID,
+			AiModel:               &result.Review.AIModel,
+			OverallStatus:         &result.Review.OverallStatus,
+			AiConfidence:          result.Review.AIConfidence,
+			ExecutionTimeMs:       result.Review.ExecutionTimeMs,
+			SuggestedScore:        result.Review.SuggestedScore,
+			PromptTemplateId:      result.Review.PromptTemplateID,
+			PromptTemplateVersion: result.Review.PromptTemplateVersion,
+			CreatedAt:             &result.Review.CreatedAt,
+		}
+
+		files := make([]api.FileFeedback, len(result.Files))
+		for i, file := range result.Files {
+			feedbacks := make([]api.FeedbackItem, len(file.Feedbacks))
+			for j, fb := range file.Feedbacks {
+				feedbacks[j] = toFeedbackItem(fb)
+			}
+			files[i] = api.FileFeedback{
+				FilePath:  file.FilePath,
+				Feedbacks: &feedbacks,
+			}
+		}
+		response.Files = &files
+
+		criteria := make([]api.CriterionResultResponse, len(result.Criteria))
+		for i, report := range result.Criteria {
+			verdict := string(report.Verdict)
+			criteria[i] = api.CriterionResultResponse{
+				CriterionId:   &report.CriterionID,
+				CriterionName: &report.CriterionName,
+				IsMandatory:   &report.IsMandatory,
+				Weight:        &report.Weight,
+				Verdict:       &verdict,
+				Evidence:      report.Evidence,
+			}
+		}
+		response.Criteria = &criteria
+	}
+
+	return ctx.JSON(http.StatusOK, response)
+}
+
+func toReviewJobResponse(job *domain.ReviewJob) api.ReviewJobResponse {
+	status := api.ReviewJobResponseStatus(job.Status)
+	return api.ReviewJobResponse{
+		JobId:         &job.ID,
+		SubmissionId:  &job.SubmissionID,
+		Status:        &status,
+		Attempts:      &job.Attempts,
+		LastError:     job.LastError,
+		NextAttemptAt: &job.NextAttemptAt,
+		UpdatedAt:     &job.UpdatedAt,
+	}
+}
+
+func toFeedbackItem(fb *domain.ReviewFeedback) api.FeedbackItem {
+	origin := api.FeedbackItemOrigin(fb.Origin)
+	return api.FeedbackItem{
+		FeedbackId:      &fb.ID,
+		FeedbackType:    &fb.FeedbackType,
+		LineStart:       &fb.LineStart,
+		LineEnd:         fb.LineEnd,
+		CodeSnippet:     &fb.CodeSnippet,
+		SuggestedFix:    fb.SuggestedFix,
+		Description:     &fb.Description,
+		Severity:        &fb.Severity,
+		IsResolved:      &fb.IsResolved,
+		TeacherComment:  fb.TeacherComment,
+		TeacherApproved: fb.TeacherApproved,
+		FilePath:        fb.FilePath,
+		AuthorId:        fb.AuthorID,
+		Origin:          &origin,
+		LintRule:        fb.LintRule,
+		IsAnchored:      fb.IsAnchored,
+	}
+}
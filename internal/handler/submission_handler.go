@@ -2,32 +2,45 @@ package handler
 
 import (
 	"errors"
+	"fmt"
+	"io"
 	"net/http"
+	"strconv"
+	"strings"
 
 	"github.com/ilyin-ad/flutter-code-mentor/api"
+	"github.com/ilyin-ad/flutter-code-mentor/internal/config"
+	"github.com/ilyin-ad/flutter-code-mentor/internal/domain"
 	"github.com/ilyin-ad/flutter-code-mentor/internal/usecase"
 	"github.com/labstack/echo/v4"
 	"go.uber.org/zap"
 )
 
+// multipartOverhead leaves room for form fields and part headers on top of
+// the archive itself.
+const multipartOverhead = 1 << 20
+
 type SubmissionHandler struct {
 	submissionUseCase usecase.SubmissionUseCase
+	maxUploadBytes    int64
 	logger            *zap.Logger
 }
 
-func NewSubmissionHandler(submissionUseCase usecase.SubmissionUseCase, logger *zap.Logger) *SubmissionHandler {
+func NewSubmissionHandler(cfg *config.Config, submissionUseCase usecase.SubmissionUseCase, logger *zap.Logger) *SubmissionHandler {
 	return &SubmissionHandler{
 		submissionUseCase: submissionUseCase,
+		maxUploadBytes:    int64(cfg.Archive.MaxUploadMB) << 20,
 		logger:            logger,
 	}
 }
 
 type CreateSubmissionRequest struct {
-	TaskID         int     `json:"task_id" validate:"required,min=1"`
-	UserID         int     `json:"user_id" validate:"required,min=1"`
-	SubmissionType string  `json:"submission_type" validate:"required,oneof=code github_link"`
-	Code           *string `json:"code,omitempty"`
-	GithubURL      *string `json:"github_url,omitempty"`
+	TaskID         int               `json:"task_id" validate:"required,min=1"`
+	SubmissionType string            `json:"submission_type" validate:"required,oneof=code github_link"`
+	Code           *string           `json:"code,omitempty"`
+	GithubURL      *string           `json:"github_url,omitempty"`
+	Ref            *string           `json:"ref,omitempty"`
+	Files          map[string]string `json:"files,omitempty"`
 }
 
 func (h *SubmissionHandler) PostSubmission(ctx echo.Context) error {
@@ -36,28 +49,37 @@ func (h *SubmissionHandler) PostSubmission(ctx echo.Context) error {
 		zap.String("path", ctx.Request().URL.Path),
 	)
 
-	var req CreateSubmissionRequest
-	if err := ctx.Bind(&req); err != nil {
-		h.logger.Warn("Invalid request body", zap.Error(err))
-		return ctx.JSON(http.StatusBadRequest, api.ValidationError{
-			Error: stringPtr("Invalid request body"),
-		})
+	var usecaseReq *usecase.CreateSubmissionRequest
+	if strings.HasPrefix(ctx.Request().Header.Get(echo.HeaderContentType), echo.MIMEMultipartForm) {
+		req, httpErr := h.bindArchiveSubmission(ctx)
+		if httpErr != nil {
+			return ctx.JSON(httpErr.Code, api.ValidationError{
+				Error: stringPtr(localize(requestLanguage(ctx), fmt.Sprint(httpErr.Message))),
+			})
+		}
+		usecaseReq = req
+	} else {
+		var req CreateSubmissionRequest
+		if err := ctx.Bind(&req); err != nil {
+			h.logger.Warn("Invalid request body", zap.Error(err))
+			return invalidRequestBody(ctx)
+		}
+
+		usecaseReq = &usecase.CreateSubmissionRequest{
+			TaskID:         req.TaskID,
+			SubmissionType: req.SubmissionType,
+			Code:           req.Code,
+			GithubURL:      req.GithubURL,
+			GitRef:         req.Ref,
+			Files:          req.Files,
+		}
 	}
 
 	h.logger.Info("Creating submission",
-		zap.Int("task_id", req.TaskID),
-		zap.Int("user_id", req.UserID),
-		zap.String("submission_type", req.SubmissionType),
+		zap.Int("task_id", usecaseReq.TaskID),
+		zap.String("submission_type", usecaseReq.SubmissionType),
 	)
 
-	usecaseReq := &usecase.CreateSubmissionRequest{
-		TaskID:         req.TaskID,
-		UserID:         req.UserID,
-		SubmissionType: req.SubmissionType,
-		Code:           req.Code,
-		GithubURL:      req.GithubURL,
-	}
-
 	resp, err := h.submissionUseCase.CreateSubmission(ctx.Request().Context(), usecaseReq)
 	if err != nil {
 		return h.handleError(ctx, err)
@@ -71,29 +93,156 @@ func (h *SubmissionHandler) PostSubmission(ctx echo.Context) error {
 	response := api.SubmissionResponse{
 		SubmissionId: &resp.SubmissionID,
 		Status:       &status,
+		CommitSha:    resp.CommitSHA,
 		CreatedAt:    &resp.CreatedAt,
 	}
 
 	return ctx.JSON(http.StatusCreated, response)
 }
 
+// bindArchiveSubmission reads a multipart/form-data upload with task_id,
+// an optional submission_type and the zip file in the "archive" field.
+func (h *SubmissionHandler) bindArchiveSubmission(ctx echo.Context) (*usecase.CreateSubmissionRequest, *echo.HTTPError) {
+	r := ctx.Request()
+	r.Body = http.MaxBytesReader(ctx.Response(), r.Body, h.maxUploadBytes+multipartOverhead)
+
+	if err := r.ParseMultipartForm(multipartOverhead); err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			return nil, echo.NewHTTPError(http.StatusRequestEntityTooLarge,
+				localize(requestLanguage(ctx), "Archive must not be larger than %d MB", h.maxUploadBytes>>20))
+		}
+		h.logger.Warn("Invalid multipart body", zap.Error(err))
+		return nil, echo.NewHTTPError(http.StatusBadRequest, "Invalid request body")
+	}
+
+	taskID, err := strconv.Atoi(ctx.FormValue("task_id"))
+	if err != nil {
+		return nil, echo.NewHTTPError(http.StatusBadRequest, "task_id must be an integer")
+	}
+
+	submissionType := ctx.FormValue("submission_type")
+	if submissionType == "" {
+		submissionType = string(domain.SubmissionTypeZipArchive)
+	}
+
+	req := &usecase.CreateSubmissionRequest{
+		TaskID:         taskID,
+		SubmissionType: submissionType,
+	}
+
+	header, err := ctx.FormFile("archive")
+	if err != nil {
+		// A missing archive is reported by the use case validation.
+		return req, nil
+	}
+
+	file, err := header.Open()
+	if err != nil {
+		h.logger.Warn("Failed to open uploaded archive", zap.Error(err))
+		return nil, echo.NewHTTPError(http.StatusBadRequest, "Invalid request body")
+	}
+	defer file.Close()
+
+	content, err := io.ReadAll(io.LimitReader(file, h.maxUploadBytes+1))
+	if err != nil {
+		h.logger.Warn("Failed to read uploaded archive", zap.Error(err))
+		return nil, echo.NewHTTPError(http.StatusBadRequest, "Invalid request body")
+	}
+
+	req.Archive = &usecase.SubmissionArchive{
+		Name:    header.Filename,
+		Content: content,
+	}
+	return req, nil
+}
+
+func (h *SubmissionHandler) GetSubmissionsId(ctx echo.Context, id api.IdPath) error {
+	details, err := h.submissionUseCase.GetSubmission(ctx.Request().Context(), id)
+	if err != nil {
+		return h.handleError(ctx, err)
+	}
+
+	response := toSubmissionResponse(details.Submission, details.ReviewJob)
+	if details.Files != nil {
+		response.Files = &details.Files
+	}
+
+	return ctx.JSON(http.StatusOK, response)
+}
+
+func (h *SubmissionHandler) GetTasksIdSubmissions(ctx echo.Context, id api.IdPath) error {
+	submissions, err := h.submissionUseCase.ListTaskSubmissions(ctx.Request().Context(), id)
+	if err != nil {
+		return h.handleError(ctx, err)
+	}
+
+	response := make([]api.SubmissionResponse, len(submissions))
+	for i, submission := range submissions {
+		response[i] = toSubmissionResponse(submission, nil)
+	}
+
+	return ctx.JSON(http.StatusOK, response)
+}
+
+func (h *SubmissionHandler) GetSubmissionsIdDiff(ctx echo.Context, id api.IdPath) error {
+	result, err := h.submissionUseCase.GetSubmissionDiff(ctx.Request().Context(), id)
+	if err != nil {
+		return h.handleError(ctx, err)
+	}
+
+	files := make([]api.FileDiff, len(result.Files))
+	for i, diff := range result.Files {
+		status := string(diff.Status)
+		files[i] = api.FileDiff{
+			FilePath:     &diff.Path,
+			Status:       &status,
+			AddedLines:   &diff.AddedLines,
+			RemovedLines: &diff.RemovedLines,
+			Patch:        &diff.Patch,
+		}
+	}
+
+	return ctx.JSON(http.StatusOK, api.SubmissionDiffResponse{
+		SubmissionId:         &result.SubmissionID,
+		PreviousSubmissionId: result.PreviousSubmissionID,
+		Files:                &files,
+	})
+}
+
+func toSubmissionResponse(submission *domain.Submission, job *domain.ReviewJob) api.SubmissionResponse {
+	status := api.SubmissionResponseStatus(submission.Status)
+	submissionType := api.SubmissionResponseSubmissionType(submission.SubmissionType)
+
+	response := api.SubmissionResponse{
+		SubmissionId:   &submission.ID,
+		TaskId:         &submission.TaskID,
+		StudentId:      &submission.StudentID,
+		SubmissionType: &submissionType,
+		Code:           submission.Code,
+		GithubUrl:      submission.GithubURL,
+		Score:          submission.Score,
+		Status:         &status,
+		CreatedAt:      &submission.SubmittedAt,
+
+		PreviousSubmissionId: submission.PreviousSubmissionID,
+		Ref:                  submission.GitRef,
+		CommitSha:            submission.CommitSHA,
+		ArchiveName:          submission.ArchiveName,
+	}
+
+	if job != nil {
+		reviewJob := toReviewJobResponse(job)
+		response.ReviewJob = &reviewJob
+	}
+
+	return response
+}
+
 func (h *SubmissionHandler) handleError(ctx echo.Context, err error) error {
 	var validationErr *usecase.ValidationError
 	if errors.As(err, &validationErr) {
-		details := make([]struct {
-			Field   *string `json:"field,omitempty"`
-			Message *string `json:"message,omitempty"`
-		}, len(validationErr.Details))
-
-		for i, detail := range validationErr.Details {
-			details[i].Field = stringPtr(detail.Field)
-			details[i].Message = stringPtr(detail.Message)
-		}
-
-		return ctx.JSON(http.StatusUnprocessableEntity, api.ValidationError{
-			Error:   stringPtr(validationErr.Message),
-			Details: &details,
-		})
+		return validationErrorResponse(ctx, http.StatusUnprocessableEntity, validationErr)
 	}
 
 	if errors.Is(err, usecase.ErrTaskNotFound) {
@@ -108,6 +257,30 @@ func (h *SubmissionHandler) handleError(ctx echo.Context, err error) error {
 		})
 	}
 
+	if errors.Is(err, usecase.ErrSubmissionNotFound) {
+		return ctx.JSON(http.StatusNotFound, api.NotFound{
+			Error: stringPtr("Submission not found"),
+		})
+	}
+
+	if errors.Is(err, usecase.ErrTaskAlreadyAccepted) {
+		return ctx.JSON(http.StatusConflict, api.ApiError{
+			Error: stringPtr("A submission for this task has already been accepted"),
+		})
+	}
+
+	if errors.Is(err, usecase.ErrNotEnrolled) {
+		return ctx.JSON(http.StatusForbidden, api.ApiError{
+			Error: stringPtr("Student is not enrolled in the course"),
+		})
+	}
+
+	if errors.Is(err, usecase.ErrUnauthorized) {
+		return ctx.JSON(http.StatusForbidden, api.ApiError{
+			Error: stringPtr("Access denied"),
+		})
+	}
+
 	return ctx.JSON(http.StatusInternalServerError, map[string]string{
 		"error": "Internal server error",
 	})
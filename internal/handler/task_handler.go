@@ -6,6 +6,7 @@ import (
 	"time"
 
 	"github.com/ilyin-ad/flutter-code-mentor/api"
+	"github.com/ilyin-ad/flutter-code-mentor/internal/domain"
 	"github.com/ilyin-ad/flutter-code-mentor/internal/usecase"
 	"github.com/labstack/echo/v4"
 	"go.uber.org/zap"
@@ -30,6 +31,14 @@ type CreateTaskRequest struct {
 	Deadline    time.Time                   `json:"deadline" validate:"required"`
 	MaxScore    int                         `json:"max_score" validate:"required,min=1,max=100"`
 	Criteria    []CreateTaskCriteriaRequest `json:"criteria,omitempty"`
+	FileRules   *TaskFileRulesRequest       `json:"file_rules,omitempty"`
+}
+
+type TaskFileRulesRequest struct {
+	IncludePatterns []string `json:"include_patterns,omitempty"`
+	ExcludePatterns []string `json:"exclude_patterns,omitempty"`
+	MaxFileSizeKB   *int     `json:"max_file_size_kb,omitempty"`
+	MaxFiles        *int     `json:"max_files,omitempty"`
 }
 
 type CreateTaskCriteriaRequest struct {
@@ -48,9 +57,7 @@ func (h *TaskHandler) PostTask(ctx echo.Context) error {
 	var req CreateTaskRequest
 	if err := ctx.Bind(&req); err != nil {
 		h.logger.Warn("Invalid request body", zap.Error(err))
-		return ctx.JSON(http.StatusBadRequest, api.ValidationError{
-			Error: stringPtr("Invalid request body"),
-		})
+		return invalidRequestBody(ctx)
 	}
 
 	h.logger.Info("Creating task",
@@ -77,6 +84,9 @@ func (h *TaskHandler) PostTask(ctx echo.Context) error {
 		MaxScore:    req.MaxScore,
 		Criteria:    criteria,
 	}
+	if req.FileRules != nil {
+		usecaseReq.FileRules = req.FileRules.toDomain()
+	}
 
 	resp, err := h.taskUseCase.CreateTask(ctx.Request().Context(), usecaseReq)
 	if err != nil {
@@ -88,34 +98,68 @@ func (h *TaskHandler) PostTask(ctx echo.Context) error {
 	)
 
 	status := api.Active
+	fileRules := toTaskFileRulesResponse(&resp.FileRules)
 	response := api.TaskResponse{
 		TaskId:    &resp.TaskID,
 		CourseId:  &resp.CourseID,
 		Title:     &resp.Title,
 		Status:    &status,
+		FileRules: &fileRules,
 		CreatedAt: &resp.CreatedAt,
 	}
 
 	return ctx.JSON(http.StatusCreated, response)
 }
 
+func (h *TaskHandler) GetTasksIdFileRules(ctx echo.Context, id api.IdPath) error {
+	rules, err := h.taskUseCase.GetFileRules(ctx.Request().Context(), id)
+	if err != nil {
+		return h.handleError(ctx, err)
+	}
+
+	return ctx.JSON(http.StatusOK, toTaskFileRulesResponse(rules))
+}
+
+func (h *TaskHandler) PutTasksIdFileRules(ctx echo.Context, id api.IdPath) error {
+	var req TaskFileRulesRequest
+	if err := ctx.Bind(&req); err != nil {
+		return invalidRequestBody(ctx)
+	}
+
+	rules, err := h.taskUseCase.SetFileRules(ctx.Request().Context(), id, req.toDomain())
+	if err != nil {
+		return h.handleError(ctx, err)
+	}
+
+	h.logger.Info("Updated task file rules", zap.Int("task_id", id))
+
+	return ctx.JSON(http.StatusOK, toTaskFileRulesResponse(rules))
+}
+
+func (r *TaskFileRulesRequest) toDomain() domain.FileSelectionRules {
+	return domain.FileSelectionRules{
+		IncludePatterns: r.IncludePatterns,
+		ExcludePatterns: r.ExcludePatterns,
+		MaxFileSizeKB:   r.MaxFileSizeKB,
+		MaxFiles:        r.MaxFiles,
+	}
+}
+
+func toTaskFileRulesResponse(rules *domain.FileSelectionRules) api.TaskFileRules {
+	include := append([]string{}, rules.IncludePatterns...)
+	exclude := append([]string{}, rules.ExcludePatterns...)
+	return api.TaskFileRules{
+		IncludePatterns: &include,
+		ExcludePatterns: &exclude,
+		MaxFileSizeKb:   rules.MaxFileSizeKB,
+		MaxFiles:        rules.MaxFiles,
+	}
+}
+
 func (h *TaskHandler) handleError(ctx echo.Context, err error) error {
 	var validationErr *usecase.ValidationError
 	if errors.As(err, &validationErr) {
-		details := make([]struct {
-			Field   *string `json:"field,omitempty"`
-			Message *string `json:"message,omitempty"`
-		}, len(validationErr.Details))
-
-		for i, detail := range validationErr.Details {
-			details[i].Field = stringPtr(detail.Field)
-			details[i].Message = stringPtr(detail.Message)
-		}
-
-		return ctx.JSON(http.StatusBadRequest, api.ValidationError{
-			Error:   stringPtr(validationErr.Message),
-			Details: &details,
-		})
+		return validationErrorResponse(ctx, http.StatusBadRequest, validationErr)
 	}
 
 	if errors.Is(err, usecase.ErrCourseNotFound) {
@@ -124,12 +168,19 @@ func (h *TaskHandler) handleError(ctx echo.Context, err error) error {
 		})
 	}
 
+	if errors.Is(err, usecase.ErrTaskNotFound) {
+		return ctx.JSON(http.StatusNotFound, api.NotFound{
+			Error: stringPtr("Task not found"),
+		})
+	}
+
 	if errors.Is(err, usecase.ErrUnauthorized) {
 		return ctx.JSON(http.StatusForbidden, api.ApiError{
-			Error: stringPtr("Only teachers can create tasks"),
+			Error: stringPtr("Only the course teacher can manage its tasks"),
 		})
 	}
 
+	h.logger.Error("Task request failed", zap.Error(err))
 	return ctx.JSON(http.StatusInternalServerError, map[string]string{
 		"error": "Internal server error",
 	})
@@ -0,0 +1,151 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/ilyin-ad/flutter-code-mentor/api"
+	"github.com/ilyin-ad/flutter-code-mentor/internal/usecase"
+	"github.com/labstack/echo/v4"
+	"go.uber.org/zap"
+)
+
+type TeacherReviewHandler struct {
+	teacherReviewUseCase usecase.TeacherReviewUseCase
+	logger               *zap.Logger
+}
+
+func NewTeacherReviewHandler(teacherReviewUseCase usecase.TeacherReviewUseCase, logger *zap.Logger) *TeacherReviewHandler {
+	return &TeacherReviewHandler{
+		teacherReviewUseCase: teacherReviewUseCase,
+		logger:               logger,
+	}
+}
+
+type CreateFeedbackRequest struct {
+	FeedbackType   string  `json:"feedback_type" validate:"required"`
+	FilePath       *string `json:"file_path,omitempty"`
+	LineStart      int     `json:"line_start" validate:"required,min=1"`
+	LineEnd        *int    `json:"line_end,omitempty"`
+	CodeSnippet    string  `json:"code_snippet" validate:"required"`
+	SuggestedFix   *string `json:"suggested_fix,omitempty"`
+	Description    string  `json:"description" validate:"required"`
+	Severity       int     `json:"severity" validate:"required,min=1,max=5"`
+	TeacherComment *string `json:"teacher_comment,omitempty"`
+}
+
+type UpdateFeedbackRequest struct {
+	TeacherApproved *bool   `json:"teacher_approved,omitempty"`
+	TeacherComment  *string `json:"teacher_comment,omitempty"`
+	IsResolved      *bool   `json:"is_resolved,omitempty"`
+}
+
+type TeacherReviewRequest struct {
+	Status string   `json:"status" validate:"required,oneof=teacher_reviewed accepted"`
+	Score  *float64 `json:"score,omitempty"`
+}
+
+func (h *TeacherReviewHandler) PostSubmissionsIdFeedback(ctx echo.Context, id api.IdPath) error {
+	var req CreateFeedbackRequest
+	if err := ctx.Bind(&req); err != nil {
+		return invalidRequestBody(ctx)
+	}
+
+	feedback, err := h.teacherReviewUseCase.AddFeedback(ctx.Request().Context(), &usecase.AddFeedbackRequest{
+		SubmissionID:   id,
+		FeedbackType:   req.FeedbackType,
+		FilePath:       req.FilePath,
+		LineStart:      req.LineStart,
+		LineEnd:        req.LineEnd,
+		CodeSnippet:    req.CodeSnippet,
+		SuggestedFix:   req.SuggestedFix,
+		Description:    req.Description,
+		Severity:       req.Severity,
+		TeacherComment: req.TeacherComment,
+	})
+	if err != nil {
+		return h.handleError(ctx, err)
+	}
+
+	return ctx.JSON(http.StatusCreated, toFeedbackItem(feedback))
+}
+
+func (h *TeacherReviewHandler) PatchSubmissionsIdFeedbackFeedbackId(ctx echo.Context, id api.IdPath, feedbackId api.FeedbackIdPath) error {
+	var req UpdateFeedbackRequest
+	if err := ctx.Bind(&req); err != nil {
+		return invalidRequestBody(ctx)
+	}
+
+	feedback, err := h.teacherReviewUseCase.UpdateFeedback(ctx.Request().Context(), &usecase.UpdateFeedbackRequest{
+		SubmissionID:    id,
+		FeedbackID:      feedbackId,
+		TeacherApproved: req.TeacherApproved,
+		TeacherComment:  req.TeacherComment,
+		IsResolved:      req.IsResolved,
+	})
+	if err != nil {
+		return h.handleError(ctx, err)
+	}
+
+	return ctx.JSON(http.StatusOK, toFeedbackItem(feedback))
+}
+
+func (h *TeacherReviewHandler) PostSubmissionsIdTeacherReview(ctx echo.Context, id api.IdPath) error {
+	var req TeacherReviewRequest
+	if err := ctx.Bind(&req); err != nil {
+		return invalidRequestBody(ctx)
+	}
+
+	submission, err := h.teacherReviewUseCase.CompleteReview(ctx.Request().Context(), &usecase.CompleteReviewRequest{
+		SubmissionID: id,
+		Status:       req.Status,
+		Score:        req.Score,
+	})
+	if err != nil {
+		return h.handleError(ctx, err)
+	}
+
+	return ctx.JSON(http.StatusOK, toSubmissionResponse(submission, nil))
+}
+
+func (h *TeacherReviewHandler) handleError(ctx echo.Context, err error) error {
+	var validationErr *usecase.ValidationError
+	if errors.As(err, &validationErr) {
+		return validationErrorResponse(ctx, http.StatusBadRequest, validationErr)
+	}
+
+	if errors.Is(err, usecase.ErrSubmissionNotFound) {
+		return ctx.JSON(http.StatusNotFound, api.NotFound{
+			Error: stringPtr("Submission not found"),
+		})
+	}
+
+	if errors.Is(err, usecase.ErrReviewNotFound) {
+		return ctx.JSON(http.StatusNotFound, api.NotFound{
+			Error: stringPtr("Review not found"),
+		})
+	}
+
+	if errors.Is(err, usecase.ErrFeedbackNotFound) {
+		return ctx.JSON(http.StatusNotFound, api.NotFound{
+			Error: stringPtr("Feedback not found"),
+		})
+	}
+
+	if errors.Is(err, usecase.ErrReviewNotEditable) || errors.Is(err, usecase.ErrInvalidStatusTransition) {
+		return ctx.JSON(http.StatusConflict, api.ApiError{
+			Error: stringPtr(err.Error()),
+		})
+	}
+
+	if errors.Is(err, usecase.ErrUnauthorized) {
+		return ctx.JSON(http.StatusForbidden, api.ApiError{
+			Error: stringPtr("Access denied"),
+		})
+	}
+
+	h.logger.Error("Teacher review request failed", zap.Error(err))
+	return ctx.JSON(http.StatusInternalServerError, map[string]string{
+		"error": "Internal server error",
+	})
+}
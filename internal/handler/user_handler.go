@@ -23,11 +23,16 @@ func NewUserHandler(userUseCase usecase.UserUseCase, logger *zap.Logger) *UserHa
 }
 
 type CreateUserRequest struct {
-	Email     string `json:"email" validate:"required,email"`
-	Password  string `json:"password" validate:"required,min=12"`
-	Role      string `json:"role" validate:"required,oneof=student teacher"`
-	FirstName string `json:"first_name" validate:"required,min=2,max=50"`
-	LastName  string `json:"last_name" validate:"required,min=2,max=50"`
+	Email     string  `json:"email" validate:"required,email"`
+	Password  string  `json:"password" validate:"required,min=12"`
+	Role      string  `json:"role" validate:"required,oneof=student teacher"`
+	FirstName string  `json:"first_name" validate:"required,min=2,max=50"`
+	LastName  string  `json:"last_name" validate:"required,min=2,max=50"`
+	Language  *string `json:"language,omitempty"`
+}
+
+type LanguageRequest struct {
+	Language *string `json:"language,omitempty"`
 }
 
 func (h *UserHandler) PostUser(ctx echo.Context) error {
@@ -39,9 +44,7 @@ func (h *UserHandler) PostUser(ctx echo.Context) error {
 	var req CreateUserRequest
 	if err := ctx.Bind(&req); err != nil {
 		h.logger.Warn("Invalid request body", zap.Error(err))
-		return ctx.JSON(http.StatusBadRequest, api.ValidationError{
-			Error: stringPtr("Invalid request body"),
-		})
+		return invalidRequestBody(ctx)
 	}
 
 	usecaseReq := &usecase.CreateUserRequest{
@@ -50,6 +53,7 @@ func (h *UserHandler) PostUser(ctx echo.Context) error {
 		Role:      req.Role,
 		FirstName: req.FirstName,
 		LastName:  req.LastName,
+		Language:  req.Language,
 	}
 
 	resp, err := h.userUseCase.CreateUser(ctx.Request().Context(), usecaseReq)
@@ -66,29 +70,37 @@ func (h *UserHandler) PostUser(ctx echo.Context) error {
 		UserId:    &resp.UserID,
 		Email:     &resp.Email,
 		Role:      (*api.UserResponseRole)(&resp.Role),
+		Language:  (*api.Language)(resp.Language),
 		CreatedAt: &resp.CreatedAt,
 	}
 
 	return ctx.JSON(http.StatusCreated, response)
 }
 
+func (h *UserHandler) PutUserLanguage(ctx echo.Context) error {
+	var req LanguageRequest
+	if err := ctx.Bind(&req); err != nil {
+		return invalidRequestBody(ctx)
+	}
+
+	user, err := h.userUseCase.SetLanguage(ctx.Request().Context(), req.Language)
+	if err != nil {
+		return h.handleError(ctx, err)
+	}
+
+	return ctx.JSON(http.StatusOK, api.UserResponse{
+		UserId:    &user.ID,
+		Email:     &user.Email,
+		Role:      (*api.UserResponseRole)(&user.Role),
+		Language:  (*api.Language)(user.Language),
+		CreatedAt: &user.CreatedAt,
+	})
+}
+
 func (h *UserHandler) handleError(ctx echo.Context, err error) error {
 	var validationErr *usecase.ValidationError
 	if errors.As(err, &validationErr) {
-		details := make([]struct {
-			Field   *string `json:"field,omitempty"`
-			Message *string `json:"message,omitempty"`
-		}, len(validationErr.Details))
-
-		for i, detail := range validationErr.Details {
-			details[i].Field = stringPtr(detail.Field)
-			details[i].Message = stringPtr(detail.Message)
-		}
-
-		return ctx.JSON(http.StatusBadRequest, api.ValidationError{
-			Error:   stringPtr(validationErr.Message),
-			Details: &details,
-		})
+		return validationErrorResponse(ctx, http.StatusBadRequest, validationErr)
 	}
 
 	if errors.Is(err, usecase.ErrEmailAlreadyExists) {
@@ -97,6 +109,24 @@ func (h *UserHandler) handleError(ctx echo.Context, err error) error {
 		})
 	}
 
+	if errors.Is(err, usecase.ErrTeacherSignUp) {
+		return ctx.JSON(http.StatusForbidden, api.ApiError{
+			Error: stringPtr("Only admins can create teacher accounts"),
+		})
+	}
+
+	if errors.Is(err, usecase.ErrUserNotFound) {
+		return ctx.JSON(http.StatusNotFound, api.NotFound{
+			Error: stringPtr("User not found"),
+		})
+	}
+
+	if errors.Is(err, usecase.ErrUnauthorized) {
+		return ctx.JSON(http.StatusUnauthorized, api.ApiError{
+			Error: stringPtr("Unauthorized"),
+		})
+	}
+
 	return ctx.JSON(http.StatusInternalServerError, map[string]string{
 		"error": "Internal server error",
 	})
@@ -0,0 +1,198 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/ilyin-ad/flutter-code-mentor/internal/domain"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type AIUsageRepository interface {
+	RecordCall(ctx context.Context, call *domain.AIModelCall) error
+	GetCourseUsage(ctx context.Context, courseID int, from, to *time.Time) (*domain.AIUsage, error)
+	ListStudentUsage(ctx context.Context, courseID int, from, to *time.Time) ([]*domain.StudentAIUsage, error)
+	GetCourseSpend(ctx context.Context, courseID int) (*domain.AISpend, error)
+	GetBudget(ctx context.Context, courseID int) (*domain.AIBudget, error)
+	SetBudget(ctx context.Context, budget *domain.AIBudget) error
+}
+
+type aiUsageRepository struct {
+	pool *pgxpool.Pool
+}
+
+func NewAIUsageRepository(pool *pgxpool.Pool) AIUsageRepository {
+	return &aiUsageRepository{pool: pool}
+}
+
+// RecordCall adds a model call to the usage ledger.
+func (r *aiUsageRepository) RecordCall(ctx context.Context, call *domain.AIModelCall) error {
+	query := `
+		INSERT INTO ai_usage (submission_id, ai_model, prompt_tokens, completion_tokens, estimated_cost)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id, created_at
+	`
+
+	err := r.pool.QueryRow(
+		ctx,
+		query,
+		call.SubmissionID,
+		call.AIModel,
+		call.PromptTokens,
+		call.CompletionTokens,
+		call.EstimatedCost,
+	).Scan(&call.ID, &call.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to record AI model call: %w", err)
+	}
+
+	return nil
+}
+
+// courseCalls joins the model calls made for the course's submissions; the
+// condition keeps those made in the optional [$2, $3) window.
+const (
+	courseCalls = `
+		FROM ai_usage au
+		JOIN submissions s ON s.id = au.submission_id
+		JOIN tasks t ON t.id = s.task_id
+	`
+	courseCallsInWindow = `
+		WHERE t.course_id = $1
+		  AND ($2::timestamp IS NULL OR au.created_at >= $2)
+		  AND ($3::timestamp IS NULL OR au.created_at < $3)
+	`
+	usageSums = `COUNT(DISTINCT au.submission_id), COALESCE(SUM(au.prompt_tokens), 0),
+		COALESCE(SUM(au.completion_tokens), 0), COALESCE(SUM(au.estimated_cost), 0)`
+)
+
+func (r *aiUsageRepository) GetCourseUsage(ctx context.Context, courseID int, from, to *time.Time) (*domain.AIUsage, error) {
+	query := `SELECT ` + usageSums + courseCalls + courseCallsInWindow
+
+	usage := &domain.AIUsage{}
+	err := r.pool.QueryRow(ctx, query, courseID, from, to).Scan(
+		&usage.Reviews,
+		&usage.PromptTokens,
+		&usage.CompletionTokens,
+		&usage.EstimatedCost,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get course AI usage: %w", err)
+	}
+
+	return usage, nil
+}
+
+// ListStudentUsage returns the usage of every student with model calls in
+// the window, the most expensive first.
+func (r *aiUsageRepository) ListStudentUsage(ctx context.Context, courseID int, from, to *time.Time) ([]*domain.StudentAIUsage, error) {
+	query := `SELECT u.id, u.first_name, u.last_name, ` + usageSums + courseCalls + `
+		JOIN users u ON u.id = s.student_id
+	` + courseCallsInWindow + `
+		GROUP BY u.id, u.first_name, u.last_name
+		ORDER BY COALESCE(SUM(au.estimated_cost), 0) DESC, u.id ASC
+	`
+
+	rows, err := r.pool.Query(ctx, query, courseID, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query student AI usage: %w", err)
+	}
+	defer rows.Close()
+
+	var usages []*domain.StudentAIUsage
+	for rows.Next() {
+		usage := &domain.StudentAIUsage{}
+		err := rows.Scan(
+			&usage.StudentID,
+			&usage.FirstName,
+			&usage.LastName,
+			&usage.Reviews,
+			&usage.PromptTokens,
+			&usage.CompletionTokens,
+			&usage.EstimatedCost,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan student AI usage: %w", err)
+		}
+
+		usages = append(usages, usage)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating student AI usage: %w", err)
+	}
+
+	return usages, nil
+}
+
+// GetCourseSpend sums the estimated cost of the course's model calls since
+// the start of the current day and month of the database clock, and returns
+// the ends of both periods by that clock.
+func (r *aiUsageRepository) GetCourseSpend(ctx context.Context, courseID int) (*domain.AISpend, error) {
+	query := `
+		SELECT
+			COALESCE(SUM(au.estimated_cost) FILTER (WHERE au.created_at >= date_trunc('day', NOW())), 0),
+			COALESCE(SUM(au.estimated_cost), 0),
+			date_trunc('day', NOW()) + INTERVAL '1 day',
+			date_trunc('month', NOW()) + INTERVAL '1 month'
+		FROM ai_usage au
+		JOIN submissions s ON s.id = au.submission_id
+		JOIN tasks t ON t.id = s.task_id
+		WHERE t.course_id = $1
+		  AND au.created_at >= date_trunc('month', NOW())
+	`
+
+	spend := &domain.AISpend{}
+	err := r.pool.QueryRow(ctx, query, courseID).Scan(&spend.Today, &spend.ThisMonth, &spend.NextDay, &spend.NextMonth)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get course AI spend: %w", err)
+	}
+
+	return spend, nil
+}
+
+func (r *aiUsageRepository) GetBudget(ctx context.Context, courseID int) (*domain.AIBudget, error) {
+	query := `
+		SELECT course_id, daily_limit, monthly_limit, updated_at
+		FROM course_ai_budgets
+		WHERE course_id = $1
+	`
+
+	budget := &domain.AIBudget{}
+	err := r.pool.QueryRow(ctx, query, courseID).Scan(
+		&budget.CourseID,
+		&budget.DailyLimit,
+		&budget.MonthlyLimit,
+		&budget.UpdatedAt,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get course AI budget: %w", err)
+	}
+
+	return budget, nil
+}
+
+func (r *aiUsageRepository) SetBudget(ctx context.Context, budget *domain.AIBudget) error {
+	query := `
+		INSERT INTO course_ai_budgets (course_id, daily_limit, monthly_limit)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (course_id) DO UPDATE
+		SET daily_limit = EXCLUDED.daily_limit,
+			monthly_limit = EXCLUDED.monthly_limit,
+			updated_at = NOW()
+		RETURNING updated_at
+	`
+
+	err := r.pool.QueryRow(ctx, query, budget.CourseID, budget.DailyLimit, budget.MonthlyLimit).Scan(&budget.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to set course AI budget: %w", err)
+	}
+
+	return nil
+}
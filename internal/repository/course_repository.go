@@ -14,6 +14,9 @@ type CourseRepository interface {
 	Create(ctx context.Context, course *domain.Course) (int, error)
 	GetByID(ctx context.Context, id int) (*domain.Course, error)
 	GetByTeacherID(ctx context.Context, teacherID int) ([]*domain.Course, error)
+	GetByInviteCode(ctx context.Context, inviteCode string) (*domain.Course, error)
+	SetInviteCode(ctx context.Context, id int, inviteCode string) error
+	SetLanguage(ctx context.Context, id int, language *domain.Language) error
 }
 
 type courseRepository struct {
@@ -26,8 +29,8 @@ func NewCourseRepository(pool *pgxpool.Pool) CourseRepository {
 
 func (r *courseRepository) Create(ctx context.Context, course *domain.Course) (int, error) {
 	query := `
-		INSERT INTO courses (teacher_id, title, description, start_date, end_date, is_active)
-		VALUES ($1, $2, $3, $4, $5, $6)
+		INSERT INTO courses (teacher_id, title, description, start_date, end_date, is_active, language)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
 		RETURNING id, created_at
 	`
 
@@ -41,6 +44,7 @@ func (r *courseRepository) Create(ctx context.Context, course *domain.Course) (i
 		course.StartDate,
 		course.EndDate,
 		course.IsActive,
+		course.Language,
 	).Scan(&id, &course.CreatedAt)
 
 	if err != nil {
@@ -52,7 +56,7 @@ func (r *courseRepository) Create(ctx context.Context, course *domain.Course) (i
 
 func (r *courseRepository) GetByID(ctx context.Context, id int) (*domain.Course, error) {
 	query := `
-		SELECT id, teacher_id, title, description, start_date, end_date, is_active, created_at
+		SELECT id, teacher_id, title, description, start_date, end_date, is_active, invite_code, language, created_at
 		FROM courses
 		WHERE id = $1
 	`
@@ -66,6 +70,8 @@ func (r *courseRepository) GetByID(ctx context.Context, id int) (*domain.Course,
 		&course.StartDate,
 		&course.EndDate,
 		&course.IsActive,
+		&course.InviteCode,
+		&course.Language,
 		&course.CreatedAt,
 	)
 
@@ -82,7 +88,7 @@ func (r *courseRepository) GetByID(ctx context.Context, id int) (*domain.Course,
 
 func (r *courseRepository) GetByTeacherID(ctx context.Context, teacherID int) ([]*domain.Course, error) {
 	query := `
-		SELECT id, teacher_id, title, description, start_date, end_date, is_active, created_at
+		SELECT id, teacher_id, title, description, start_date, end_date, is_active, invite_code, language, created_at
 		FROM courses
 		WHERE teacher_id = $1
 		ORDER BY created_at DESC
@@ -105,6 +111,8 @@ func (r *courseRepository) GetByTeacherID(ctx context.Context, teacherID int) ([
 			&course.StartDate,
 			&course.EndDate,
 			&course.IsActive,
+			&course.InviteCode,
+			&course.Language,
 			&course.CreatedAt,
 		)
 		if err != nil {
@@ -120,3 +128,65 @@ func (r *courseRepository) GetByTeacherID(ctx context.Context, teacherID int) ([
 
 	return courses, nil
 }
+
+func (r *courseRepository) GetByInviteCode(ctx context.Context, inviteCode string) (*domain.Course, error) {
+	query := `
+		SELECT id, teacher_id, title, description, start_date, end_date, is_active, invite_code, language, created_at
+		FROM courses
+		WHERE invite_code = $1
+	`
+
+	course := &domain.Course{}
+	err := r.pool.QueryRow(ctx, query, inviteCode).Scan(
+		&course.ID,
+		&course.TeacherID,
+		&course.Title,
+		&course.Description,
+		&course.StartDate,
+		&course.EndDate,
+		&course.IsActive,
+		&course.InviteCode,
+		&course.Language,
+		&course.CreatedAt,
+	)
+
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+
+		return nil, fmt.Errorf("failed to get course by invite code: %w", err)
+	}
+
+	return course, nil
+}
+
+func (r *courseRepository) SetInviteCode(ctx context.Context, id int, inviteCode string) error {
+	query := `
+		UPDATE courses
+		SET invite_code = $1
+		WHERE id = $2
+	`
+
+	_, err := r.pool.Exec(ctx, query, inviteCode, id)
+	if err != nil {
+		return fmt.Errorf("failed to set invite code: %w", err)
+	}
+
+	return nil
+}
+
+func (r *courseRepository) SetLanguage(ctx context.Context, id int, language *domain.Language) error {
+	query := `
+		UPDATE courses
+		SET language = $1
+		WHERE id = $2
+	`
+
+	_, err := r.pool.Exec(ctx, query, language, id)
+	if err != nil {
+		return fmt.Errorf("failed to set course language: %w", err)
+	}
+
+	return nil
+}
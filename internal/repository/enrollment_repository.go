@@ -0,0 +1,160 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/ilyin-ad/flutter-code-mentor/internal/domain"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type EnrollmentRepository interface {
+	Enroll(ctx context.Context, courseID, studentID int) (*domain.CourseEnrollment, error)
+	Get(ctx context.Context, courseID, studentID int) (*domain.CourseEnrollment, error)
+	Delete(ctx context.Context, courseID, studentID int) (bool, error)
+	ListByCourse(ctx context.Context, courseID int) ([]*domain.RosterEntry, error)
+	UpdateStatus(ctx context.Context, courseID, studentID int, status domain.EnrollmentStatus, finalScore *float64) error
+}
+
+type enrollmentRepository struct {
+	pool *pgxpool.Pool
+}
+
+func NewEnrollmentRepository(pool *pgxpool.Pool) EnrollmentRepository {
+	return &enrollmentRepository{pool: pool}
+}
+
+// Enroll creates an active enrollment. A dropped student is re-activated,
+// other existing enrollments are returned unchanged.
+func (r *enrollmentRepository) Enroll(ctx context.Context, courseID, studentID int) (*domain.CourseEnrollment, error) {
+	query := `
+		INSERT INTO course_enrollments (student_id, course_id, completion_status)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (student_id, course_id) DO UPDATE
+		SET completion_status = CASE
+				WHEN course_enrollments.completion_status = $4 THEN EXCLUDED.completion_status
+				ELSE course_enrollments.completion_status
+			END
+		RETURNING student_id, course_id, enrolled_at, completion_status, final_score
+	`
+
+	enrollment := &domain.CourseEnrollment{}
+	err := r.pool.QueryRow(
+		ctx,
+		query,
+		studentID,
+		courseID,
+		domain.EnrollmentActive,
+		domain.EnrollmentDropped,
+	).Scan(
+		&enrollment.StudentID,
+		&enrollment.CourseID,
+		&enrollment.EnrolledAt,
+		&enrollment.CompletionStatus,
+		&enrollment.FinalScore,
+	)
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to enroll student: %w", err)
+	}
+
+	return enrollment, nil
+}
+
+func (r *enrollmentRepository) Get(ctx context.Context, courseID, studentID int) (*domain.CourseEnrollment, error) {
+	query := `
+		SELECT student_id, course_id, enrolled_at, completion_status, final_score
+		FROM course_enrollments
+		WHERE course_id = $1 AND student_id = $2
+	`
+
+	enrollment := &domain.CourseEnrollment{}
+	err := r.pool.QueryRow(ctx, query, courseID, studentID).Scan(
+		&enrollment.StudentID,
+		&enrollment.CourseID,
+		&enrollment.EnrolledAt,
+		&enrollment.CompletionStatus,
+		&enrollment.FinalScore,
+	)
+
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+
+		return nil, fmt.Errorf("failed to get enrollment: %w", err)
+	}
+
+	return enrollment, nil
+}
+
+func (r *enrollmentRepository) Delete(ctx context.Context, courseID, studentID int) (bool, error) {
+	query := `DELETE FROM course_enrollments WHERE course_id = $1 AND student_id = $2`
+
+	tag, err := r.pool.Exec(ctx, query, courseID, studentID)
+	if err != nil {
+		return false, fmt.Errorf("failed to delete enrollment: %w", err)
+	}
+
+	return tag.RowsAffected() > 0, nil
+}
+
+func (r *enrollmentRepository) ListByCourse(ctx context.Context, courseID int) ([]*domain.RosterEntry, error) {
+	query := `
+		SELECT e.student_id, e.course_id, e.enrolled_at, e.completion_status, e.final_score,
+			   u.email, u.first_name, u.last_name
+		FROM course_enrollments e
+		JOIN users u ON u.id = e.student_id
+		WHERE e.course_id = $1
+		ORDER BY u.last_name ASC, u.first_name ASC
+	`
+
+	rows, err := r.pool.Query(ctx, query, courseID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query enrollments: %w", err)
+	}
+	defer rows.Close()
+
+	var roster []*domain.RosterEntry
+	for rows.Next() {
+		entry := &domain.RosterEntry{}
+		err := rows.Scan(
+			&entry.StudentID,
+			&entry.CourseID,
+			&entry.EnrolledAt,
+			&entry.CompletionStatus,
+			&entry.FinalScore,
+			&entry.Email,
+			&entry.FirstName,
+			&entry.LastName,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan enrollment: %w", err)
+		}
+
+		roster = append(roster, entry)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating enrollments: %w", err)
+	}
+
+	return roster, nil
+}
+
+func (r *enrollmentRepository) UpdateStatus(ctx context.Context, courseID, studentID int, status domain.EnrollmentStatus, finalScore *float64) error {
+	query := `
+		UPDATE course_enrollments
+		SET completion_status = $1, final_score = COALESCE($2, final_score)
+		WHERE course_id = $3 AND student_id = $4
+	`
+
+	_, err := r.pool.Exec(ctx, query, status, finalScore, courseID, studentID)
+	if err != nil {
+		return fmt.Errorf("failed to update enrollment status: %w", err)
+	}
+
+	return nil
+}
@@ -13,6 +13,11 @@ func FxModule() fx.Option {
 			NewUserRepository,
 			NewCourseRepository,
 			NewReviewRepository,
+			NewReviewJobRepository,
+			NewEnrollmentRepository,
+			NewPromptTemplateRepository,
+			NewReviewCacheRepository,
+			NewAIUsageRepository,
 		),
 	)
 }
@@ -0,0 +1,193 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/ilyin-ad/flutter-code-mentor/internal/domain"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type PromptTemplateRepository interface {
+	CreateVersion(ctx context.Context, tmpl *domain.PromptTemplate) error
+	GetActive(ctx context.Context, scope domain.PromptScope, scopeID int) (*domain.PromptTemplate, error)
+	ListVersions(ctx context.Context, scope domain.PromptScope, scopeID int) ([]*domain.PromptTemplate, error)
+	Deactivate(ctx context.Context, scope domain.PromptScope, scopeID int) (bool, error)
+}
+
+type promptTemplateRepository struct {
+	pool *pgxpool.Pool
+}
+
+func NewPromptTemplateRepository(pool *pgxpool.Pool) PromptTemplateRepository {
+	return &promptTemplateRepository{pool: pool}
+}
+
+const promptTemplateColumns = `id, course_id, task_id, version, system_prompt, user_prompt,
+	is_active, created_by, created_at`
+
+func scanPromptTemplate(row rowScanner) (*domain.PromptTemplate, error) {
+	tmpl := &domain.PromptTemplate{}
+	err := row.Scan(
+		&tmpl.ID,
+		&tmpl.CourseID,
+		&tmpl.TaskID,
+		&tmpl.Version,
+		&tmpl.SystemPrompt,
+		&tmpl.UserPrompt,
+		&tmpl.IsActive,
+		&tmpl.CreatedBy,
+		&tmpl.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return tmpl, nil
+}
+
+// promptScopeTables maps a scope to the template column holding its id and
+// the table it refers to. Both are interpolated into queries, so only these
+// values may be used.
+var promptScopeTables = map[domain.PromptScope][2]string{
+	domain.PromptScopeCourse: {"course_id", "courses"},
+	domain.PromptScopeTask:   {"task_id", "tasks"},
+}
+
+func promptScopeColumn(scope domain.PromptScope) (string, string, error) {
+	names, ok := promptScopeTables[scope]
+	if !ok {
+		return "", "", fmt.Errorf("unknown prompt template scope %q", scope)
+	}
+	return names[0], names[1], nil
+}
+
+// CreateVersion stores the template as the next version of its scope and
+// makes it the active one. The course or task row is locked, so concurrent
+// saves get consecutive versions.
+func (r *promptTemplateRepository) CreateVersion(ctx context.Context, tmpl *domain.PromptTemplate) error {
+	var scope domain.PromptScope
+	var scopeID int
+	switch {
+	case tmpl.TaskID != nil:
+		scope, scopeID = domain.PromptScopeTask, *tmpl.TaskID
+	case tmpl.CourseID != nil:
+		scope, scopeID = domain.PromptScopeCourse, *tmpl.CourseID
+	default:
+		return fmt.Errorf("prompt template has neither course nor task")
+	}
+	column, parent, err := promptScopeColumn(scope)
+	if err != nil {
+		return err
+	}
+
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err := tx.Exec(ctx, `SELECT 1 FROM `+parent+` WHERE id = $1 FOR UPDATE`, scopeID); err != nil {
+		return fmt.Errorf("failed to lock %s: %w", parent, err)
+	}
+
+	_, err = tx.Exec(ctx, `UPDATE prompt_templates SET is_active = false WHERE `+column+` = $1 AND is_active`, scopeID)
+	if err != nil {
+		return fmt.Errorf("failed to deactivate prompt template: %w", err)
+	}
+
+	query := `
+		INSERT INTO prompt_templates (course_id, task_id, version, system_prompt, user_prompt, is_active, created_by)
+		SELECT $1, $2, COALESCE(MAX(version), 0) + 1, $3, $4, true, $5
+		FROM prompt_templates
+		WHERE ` + column + ` = $6
+		RETURNING id, version, is_active, created_at
+	`
+
+	err = tx.QueryRow(
+		ctx,
+		query,
+		tmpl.CourseID,
+		tmpl.TaskID,
+		tmpl.SystemPrompt,
+		tmpl.UserPrompt,
+		tmpl.CreatedBy,
+		scopeID,
+	).Scan(&tmpl.ID, &tmpl.Version, &tmpl.IsActive, &tmpl.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create prompt template: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit prompt template: %w", err)
+	}
+
+	return nil
+}
+
+func (r *promptTemplateRepository) GetActive(ctx context.Context, scope domain.PromptScope, scopeID int) (*domain.PromptTemplate, error) {
+	column, _, err := promptScopeColumn(scope)
+	if err != nil {
+		return nil, err
+	}
+
+	query := `SELECT ` + promptTemplateColumns + ` FROM prompt_templates WHERE ` + column + ` = $1 AND is_active`
+
+	tmpl, err := scanPromptTemplate(r.pool.QueryRow(ctx, query, scopeID))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get prompt template: %w", err)
+	}
+
+	return tmpl, nil
+}
+
+// ListVersions returns every version of the scope's template, newest first.
+func (r *promptTemplateRepository) ListVersions(ctx context.Context, scope domain.PromptScope, scopeID int) ([]*domain.PromptTemplate, error) {
+	column, _, err := promptScopeColumn(scope)
+	if err != nil {
+		return nil, err
+	}
+
+	query := `SELECT ` + promptTemplateColumns + ` FROM prompt_templates WHERE ` + column + ` = $1 ORDER BY version DESC`
+
+	rows, err := r.pool.Query(ctx, query, scopeID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query prompt templates: %w", err)
+	}
+	defer rows.Close()
+
+	var templates []*domain.PromptTemplate
+	for rows.Next() {
+		tmpl, err := scanPromptTemplate(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan prompt template: %w", err)
+		}
+		templates = append(templates, tmpl)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate prompt templates: %w", err)
+	}
+
+	return templates, nil
+}
+
+// Deactivate switches the scope back to the inherited prompt. The versions
+// are kept, since reviews refer to them.
+func (r *promptTemplateRepository) Deactivate(ctx context.Context, scope domain.PromptScope, scopeID int) (bool, error) {
+	column, _, err := promptScopeColumn(scope)
+	if err != nil {
+		return false, err
+	}
+
+	tag, err := r.pool.Exec(ctx, `UPDATE prompt_templates SET is_active = false WHERE `+column+` = $1 AND is_active`, scopeID)
+	if err != nil {
+		return false, fmt.Errorf("failed to deactivate prompt template: %w", err)
+	}
+
+	return tag.RowsAffected() > 0, nil
+}
@@ -0,0 +1,96 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/ilyin-ad/flutter-code-mentor/internal/domain"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type ReviewCacheRepository interface {
+	Get(ctx context.Context, cacheKey string) (*domain.ReviewCacheEntry, error)
+	Put(ctx context.Context, entry *domain.ReviewCacheEntry) error
+	DeleteExpired(ctx context.Context) (int, error)
+}
+
+type reviewCacheRepository struct {
+	pool *pgxpool.Pool
+}
+
+func NewReviewCacheRepository(pool *pgxpool.Pool) ReviewCacheRepository {
+	return &reviewCacheRepository{pool: pool}
+}
+
+// Get returns the entry for the key unless it has expired, counting the hit.
+func (r *reviewCacheRepository) Get(ctx context.Context, cacheKey string) (*domain.ReviewCacheEntry, error) {
+	query := `
+		UPDATE review_cache
+		SET hit_count = hit_count + 1
+		WHERE cache_key = $1 AND expires_at > NOW()
+		RETURNING cache_key, task_id, ai_model, result, hit_count, created_at, expires_at
+	`
+
+	entry := &domain.ReviewCacheEntry{}
+	err := r.pool.QueryRow(ctx, query, cacheKey).Scan(
+		&entry.CacheKey,
+		&entry.TaskID,
+		&entry.AIModel,
+		&entry.Result,
+		&entry.HitCount,
+		&entry.CreatedAt,
+		&entry.ExpiresAt,
+	)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get cached review: %w", err)
+	}
+
+	return entry, nil
+}
+
+// Put stores the entry, replacing an expired one with the same key.
+func (r *reviewCacheRepository) Put(ctx context.Context, entry *domain.ReviewCacheEntry) error {
+	query := `
+		INSERT INTO review_cache (cache_key, task_id, ai_model, result, expires_at)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (cache_key) DO UPDATE
+		SET task_id = EXCLUDED.task_id,
+			ai_model = EXCLUDED.ai_model,
+			result = EXCLUDED.result,
+			hit_count = 0,
+			created_at = NOW(),
+			expires_at = EXCLUDED.expires_at
+		RETURNING created_at
+	`
+
+	err := r.pool.QueryRow(
+		ctx,
+		query,
+		entry.CacheKey,
+		entry.TaskID,
+		entry.AIModel,
+		entry.Result,
+		entry.ExpiresAt,
+	).Scan(&entry.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to cache review: %w", err)
+	}
+
+	return nil
+}
+
+func (r *reviewCacheRepository) DeleteExpired(ctx context.Context) (int, error) {
+	query := `DELETE FROM review_cache WHERE expires_at <= NOW()`
+
+	tag, err := r.pool.Exec(ctx, query)
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete expired cached reviews: %w", err)
+	}
+
+	return int(tag.RowsAffected()), nil
+}
@@ -0,0 +1,298 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/ilyin-ad/flutter-code-mentor/internal/domain"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type ReviewJobRepository interface {
+	Enqueue(ctx context.Context, submissionID int) error
+	EnqueueMissing(ctx context.Context) (int, error)
+	ListDue(ctx context.Context, limit int) ([]int, error)
+	ClaimBySubmissionID(ctx context.Context, submissionID int, workerID string, lockTimeout time.Duration) (*domain.ReviewJob, error)
+	GetBySubmissionID(ctx context.Context, submissionID int) (*domain.ReviewJob, error)
+	MarkDone(ctx context.Context, job *domain.ReviewJob) error
+	Retry(ctx context.Context, job *domain.ReviewJob, lastError string, nextAttemptAt time.Time) error
+	MarkFailed(ctx context.Context, job *domain.ReviewJob, lastError string) error
+	Postpone(ctx context.Context, job *domain.ReviewJob, reason string, nextAttemptAt time.Time) error
+	ListFailed(ctx context.Context, limit, offset int) ([]*domain.ReviewJob, error)
+}
+
+// ErrReviewJobLockLost is returned when a worker finishes a job whose lock
+// has expired and been claimed again, by another worker or another
+// goroutine of the same one; the job's state is left to the new claim.
+var ErrReviewJobLockLost = errors.New("review job lock lost")
+
+type reviewJobRepository struct {
+	pool *pgxpool.Pool
+}
+
+func NewReviewJobRepository(pool *pgxpool.Pool) ReviewJobRepository {
+	return &reviewJobRepository{pool: pool}
+}
+
+const reviewJobColumns = `id, submission_id, status, attempts, last_error, next_attempt_at,
+	locked_by, locked_until, claim_seq, created_at, updated_at`
+
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanReviewJob(row rowScanner) (*domain.ReviewJob, error) {
+	job := &domain.ReviewJob{}
+	err := row.Scan(
+		&job.ID,
+		&job.SubmissionID,
+		&job.Status,
+		&job.Attempts,
+		&job.LastError,
+		&job.NextAttemptAt,
+		&job.LockedBy,
+		&job.LockedUntil,
+		&job.ClaimSeq,
+		&job.CreatedAt,
+		&job.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return job, nil
+}
+
+func (r *reviewJobRepository) Enqueue(ctx context.Context, submissionID int) error {
+	query := `
+		INSERT INTO review_jobs (submission_id)
+		VALUES ($1)
+		ON CONFLICT (submission_id) DO NOTHING
+	`
+
+	_, err := r.pool.Exec(ctx, query, submissionID)
+	if err != nil {
+		return fmt.Errorf("failed to enqueue review job: %w", err)
+	}
+
+	return nil
+}
+
+func (r *reviewJobRepository) EnqueueMissing(ctx context.Context) (int, error) {
+	query := `
+		INSERT INTO review_jobs (submission_id)
+		SELECT s.id
+		FROM submissions s
+		WHERE s.status = $1
+		  AND NOT EXISTS (SELECT 1 FROM review_jobs j WHERE j.submission_id = s.id)
+		ON CONFLICT (submission_id) DO NOTHING
+	`
+
+	tag, err := r.pool.Exec(ctx, query, domain.StatusPending)
+	if err != nil {
+		return 0, fmt.Errorf("failed to enqueue missing review jobs: %w", err)
+	}
+
+	return int(tag.RowsAffected()), nil
+}
+
+// ListDue returns submissions whose job is ready to run, including jobs
+// whose lock expired because a worker died mid-review.
+func (r *reviewJobRepository) ListDue(ctx context.Context, limit int) ([]int, error) {
+	query := `
+		SELECT submission_id
+		FROM review_jobs
+		WHERE (status = $1 AND next_attempt_at <= NOW())
+		   OR (status = $2 AND locked_until < NOW())
+		ORDER BY next_attempt_at ASC
+		LIMIT $3
+	`
+
+	rows, err := r.pool.Query(ctx, query, domain.ReviewJobQueued, domain.ReviewJobRunning, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query due review jobs: %w", err)
+	}
+	defer rows.Close()
+
+	var submissionIDs []int
+	for rows.Next() {
+		var submissionID int
+		if err := rows.Scan(&submissionID); err != nil {
+			return nil, fmt.Errorf("failed to scan review job: %w", err)
+		}
+
+		submissionIDs = append(submissionIDs, submissionID)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating review jobs: %w", err)
+	}
+
+	return submissionIDs, nil
+}
+
+// ClaimBySubmissionID locks the submission's job for workerID if it is due.
+// It returns nil when the job is not due or another worker holds it. Every
+// claim gets a new ClaimSeq, which the returned job carries into the updates
+// that finish it.
+func (r *reviewJobRepository) ClaimBySubmissionID(ctx context.Context, submissionID int, workerID string, lockTimeout time.Duration) (*domain.ReviewJob, error) {
+	query := `
+		UPDATE review_jobs
+		SET status = $1,
+			attempts = attempts + 1,
+			claim_seq = claim_seq + 1,
+			locked_by = $2,
+			locked_until = NOW() + make_interval(secs => $3),
+			updated_at = NOW()
+		WHERE id = (
+			SELECT id
+			FROM review_jobs
+			WHERE submission_id = $4
+			  AND ((status = $5 AND next_attempt_at <= NOW())
+			    OR (status = $1 AND locked_until < NOW()))
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING ` + reviewJobColumns
+
+	job, err := scanReviewJob(r.pool.QueryRow(
+		ctx,
+		query,
+		domain.ReviewJobRunning,
+		workerID,
+		lockTimeout.Seconds(),
+		submissionID,
+		domain.ReviewJobQueued,
+	))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to claim review job: %w", err)
+	}
+
+	return job, nil
+}
+
+func (r *reviewJobRepository) GetBySubmissionID(ctx context.Context, submissionID int) (*domain.ReviewJob, error) {
+	query := `SELECT ` + reviewJobColumns + ` FROM review_jobs WHERE submission_id = $1`
+
+	job, err := scanReviewJob(r.pool.QueryRow(ctx, query, submissionID))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get review job: %w", err)
+	}
+
+	return job, nil
+}
+
+func (r *reviewJobRepository) MarkDone(ctx context.Context, job *domain.ReviewJob) error {
+	query := `
+		UPDATE review_jobs
+		SET status = $1, last_error = NULL, locked_by = NULL, locked_until = NULL, updated_at = NOW()
+		WHERE id = $2 AND claim_seq = $3
+	`
+
+	tag, err := r.pool.Exec(ctx, query, domain.ReviewJobDone, job.ID, job.ClaimSeq)
+	if err != nil {
+		return fmt.Errorf("failed to mark review job done: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrReviewJobLockLost
+	}
+
+	return nil
+}
+
+func (r *reviewJobRepository) Retry(ctx context.Context, job *domain.ReviewJob, lastError string, nextAttemptAt time.Time) error {
+	query := `
+		UPDATE review_jobs
+		SET status = $1, last_error = $2, next_attempt_at = $3,
+			locked_by = NULL, locked_until = NULL, updated_at = NOW()
+		WHERE id = $4 AND claim_seq = $5
+	`
+
+	tag, err := r.pool.Exec(ctx, query, domain.ReviewJobQueued, lastError, nextAttemptAt, job.ID, job.ClaimSeq)
+	if err != nil {
+		return fmt.Errorf("failed to reschedule review job: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrReviewJobLockLost
+	}
+
+	return nil
+}
+
+func (r *reviewJobRepository) MarkFailed(ctx context.Context, job *domain.ReviewJob, lastError string) error {
+	query := `
+		UPDATE review_jobs
+		SET status = $1, last_error = $2, locked_by = NULL, locked_until = NULL, updated_at = NOW()
+		WHERE id = $3 AND claim_seq = $4
+	`
+
+	tag, err := r.pool.Exec(ctx, query, domain.ReviewJobFailed, lastError, job.ID, job.ClaimSeq)
+	if err != nil {
+		return fmt.Errorf("failed to mark review job failed: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrReviewJobLockLost
+	}
+
+	return nil
+}
+
+// Postpone puts a claimed job back in the queue until nextAttemptAt without
+// counting the attempt, for reviews that are not due to fail but to wait.
+func (r *reviewJobRepository) Postpone(ctx context.Context, job *domain.ReviewJob, reason string, nextAttemptAt time.Time) error {
+	query := `
+		UPDATE review_jobs
+		SET status = $1, attempts = GREATEST(attempts - 1, 0), last_error = $2, next_attempt_at = $3,
+			locked_by = NULL, locked_until = NULL, updated_at = NOW()
+		WHERE id = $4 AND claim_seq = $5
+	`
+
+	tag, err := r.pool.Exec(ctx, query, domain.ReviewJobQueued, reason, nextAttemptAt, job.ID, job.ClaimSeq)
+	if err != nil {
+		return fmt.Errorf("failed to postpone review job: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrReviewJobLockLost
+	}
+
+	return nil
+}
+
+func (r *reviewJobRepository) ListFailed(ctx context.Context, limit, offset int) ([]*domain.ReviewJob, error) {
+	query := `
+		SELECT ` + reviewJobColumns + `
+		FROM review_jobs
+		WHERE status = $1
+		ORDER BY updated_at DESC
+		LIMIT $2 OFFSET $3
+	`
+
+	rows, err := r.pool.Query(ctx, query, domain.ReviewJobFailed, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query failed review jobs: %w", err)
+	}
+	defer rows.Close()
+
+	var jobs []*domain.ReviewJob
+	for rows.Next() {
+		job, err := scanReviewJob(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan review job: %w", err)
+		}
+
+		jobs = append(jobs, job)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating review jobs: %w", err)
+	}
+
+	return jobs, nil
+}
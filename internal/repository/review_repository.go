@@ -11,10 +11,14 @@ import (
 )
 
 type ReviewRepository interface {
-	CreateCodeReview(ctx context.Context, review *domain.CodeReview) (int, error)
+	SaveReview(ctx context.Context, review *domain.CodeReview, feedbacks []*domain.ReviewFeedback, results []*domain.CriterionResult) (int, error)
 	CreateReviewFeedback(ctx context.Context, feedback *domain.ReviewFeedback) error
 	GetCodeReviewBySubmissionID(ctx context.Context, submissionID int) (*domain.CodeReview, error)
 	GetReviewFeedbackByReviewID(ctx context.Context, reviewID int) ([]*domain.ReviewFeedback, error)
+	GetReviewFeedbackByID(ctx context.Context, id int) (*domain.ReviewFeedback, error)
+	UpdateReviewFeedback(ctx context.Context, feedback *domain.ReviewFeedback) error
+	MarkFeedbackResolved(ctx context.Context, reviewID int, feedbackIDs []int) (int, error)
+	GetCriterionReportsByReviewID(ctx context.Context, reviewID int) ([]*domain.CriterionReport, error)
 }
 
 type reviewRepository struct {
@@ -25,18 +29,105 @@ func NewReviewRepository(pool *pgxpool.Pool) ReviewRepository {
 	return &reviewRepository{pool: pool}
 }
 
-func (r *reviewRepository) CreateCodeReview(ctx context.Context, review *domain.CodeReview) (int, error) {
+const reviewFeedbackColumns = `id, review_id, feedback_type, file_path, line_start, line_end,
+	code_snippet, suggested_fix, description, severity,
+	is_resolved, teacher_comment, teacher_approved, author_id, created_at,
+	origin, lint_rule, is_anchored`
+
+func scanReviewFeedback(row rowScanner) (*domain.ReviewFeedback, error) {
+	feedback := &domain.ReviewFeedback{}
+	err := row.Scan(
+		&feedback.ID,
+		&feedback.ReviewID,
+		&feedback.FeedbackType,
+		&feedback.FilePath,
+		&feedback.LineStart,
+		&feedback.LineEnd,
+		&feedback.CodeSnippet,
+		&feedback.SuggestedFix,
+		&feedback.Description,
+		&feedback.Severity,
+		&feedback.IsResolved,
+		&feedback.TeacherComment,
+		&feedback.TeacherApproved,
+		&feedback.AuthorID,
+		&feedback.CreatedAt,
+		&feedback.Origin,
+		&feedback.LintRule,
+		&feedback.IsAnchored,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return feedback, nil
+}
+
+// queryRower is implemented by both the pool and a transaction.
+type queryRower interface {
+	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+}
+
+// SaveReview stores an AI review with its feedback and criterion results and
+// moves the submission from pending to ai_reviewed in one transaction. A
+// failed step leaves nothing behind, so a retried job never finds a review
+// whose submission is still pending. A submission resubmitted while the
+// review ran keeps its status.
+func (r *reviewRepository) SaveReview(ctx context.Context, review *domain.CodeReview, feedbacks []*domain.ReviewFeedback, results []*domain.CriterionResult) (int, error) {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	reviewID, err := createCodeReview(ctx, tx, review)
+	if err != nil {
+		return 0, err
+	}
+
+	for _, feedback := range feedbacks {
+		feedback.ReviewID = reviewID
+		if err := createReviewFeedback(ctx, tx, feedback); err != nil {
+			return 0, err
+		}
+	}
+
+	for _, result := range results {
+		result.ReviewID = reviewID
+		if err := createCriterionResult(ctx, tx, result); err != nil {
+			return 0, err
+		}
+	}
+
+	query := `
+		UPDATE submissions
+		SET status = $1
+		WHERE id = $2 AND status = $3
+	`
+	if _, err := tx.Exec(ctx, query, domain.StatusAIReviewed, review.SubmissionID, domain.StatusPending); err != nil {
+		return 0, fmt.Errorf("failed to update submission status: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return 0, fmt.Errorf("failed to commit code review: %w", err)
+	}
+
+	return reviewID, nil
+}
+
+func createCodeReview(ctx context.Context, q queryRower, review *domain.CodeReview) (int, error) {
 	query := `
 		INSERT INTO code_reviews (
 			submission_id, ai_model, overall_status,
-			ai_confidence, execution_time_ms
+			ai_confidence, execution_time_ms, suggested_score,
+			prompt_template_id, prompt_template_version,
+			prompt_tokens, completion_tokens, estimated_cost
 		)
-		VALUES ($1, $2, $3, $4, $5)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
 		RETURNING id, created_at
 	`
 
 	var id int
-	err := r.pool.QueryRow(
+	err := q.QueryRow(
 		ctx,
 		query,
 		review.SubmissionID,
@@ -44,6 +135,12 @@ func (r *reviewRepository) CreateCodeReview(ctx context.Context, review *domain.
 		review.OverallStatus,
 		review.AIConfidence,
 		review.ExecutionTimeMs,
+		review.SuggestedScore,
+		review.PromptTemplateID,
+		review.PromptTemplateVersion,
+		review.PromptTokens,
+		review.CompletionTokens,
+		review.EstimatedCost,
 	).Scan(&id, &review.CreatedAt)
 
 	if err != nil {
@@ -54,17 +151,26 @@ func (r *reviewRepository) CreateCodeReview(ctx context.Context, review *domain.
 }
 
 func (r *reviewRepository) CreateReviewFeedback(ctx context.Context, feedback *domain.ReviewFeedback) error {
+	return createReviewFeedback(ctx, r.pool, feedback)
+}
+
+func createReviewFeedback(ctx context.Context, q queryRower, feedback *domain.ReviewFeedback) error {
 	query := `
 		INSERT INTO review_feedback (
 			review_id, feedback_type, file_path, line_start, line_end,
 			code_snippet, suggested_fix, description, severity,
-			is_resolved, teacher_comment, teacher_approved
+			is_resolved, teacher_comment, teacher_approved, author_id,
+			origin, lint_rule, is_anchored
 		)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16)
 		RETURNING id, created_at
 	`
 
-	err := r.pool.QueryRow(
+	if feedback.Origin == "" {
+		feedback.Origin = domain.FeedbackOriginAI
+	}
+
+	err := q.QueryRow(
 		ctx,
 		query,
 		feedback.ReviewID,
@@ -79,6 +185,10 @@ func (r *reviewRepository) CreateReviewFeedback(ctx context.Context, feedback *d
 		feedback.IsResolved,
 		feedback.TeacherComment,
 		feedback.TeacherApproved,
+		feedback.AuthorID,
+		feedback.Origin,
+		feedback.LintRule,
+		feedback.IsAnchored,
 	).Scan(&feedback.ID, &feedback.CreatedAt)
 
 	if err != nil {
@@ -91,7 +201,9 @@ func (r *reviewRepository) CreateReviewFeedback(ctx context.Context, feedback *d
 func (r *reviewRepository) GetCodeReviewBySubmissionID(ctx context.Context, submissionID int) (*domain.CodeReview, error) {
 	query := `
 		SELECT id, submission_id, ai_model, overall_status,
-			   ai_confidence, execution_time_ms, created_at
+			   ai_confidence, execution_time_ms, suggested_score, created_at,
+			   prompt_template_id, prompt_template_version,
+			   prompt_tokens, completion_tokens, estimated_cost
 		FROM code_reviews
 		WHERE submission_id = $1
 	`
@@ -104,7 +216,13 @@ func (r *reviewRepository) GetCodeReviewBySubmissionID(ctx context.Context, subm
 		&review.OverallStatus,
 		&review.AIConfidence,
 		&review.ExecutionTimeMs,
+		&review.SuggestedScore,
 		&review.CreatedAt,
+		&review.PromptTemplateID,
+		&review.PromptTemplateVersion,
+		&review.PromptTokens,
+		&review.CompletionTokens,
+		&review.EstimatedCost,
 	)
 
 	if err != nil {
@@ -119,9 +237,7 @@ func (r *reviewRepository) GetCodeReviewBySubmissionID(ctx context.Context, subm
 
 func (r *reviewRepository) GetReviewFeedbackByReviewID(ctx context.Context, reviewID int) ([]*domain.ReviewFeedback, error) {
 	query := `
-		SELECT id, review_id, feedback_type, file_path, line_start, line_end,
-			   code_snippet, suggested_fix, description, severity,
-			   is_resolved, teacher_comment, teacher_approved, created_at
+		SELECT ` + reviewFeedbackColumns + `
 		FROM review_feedback
 		WHERE review_id = $1
 		ORDER BY severity DESC, line_start ASC
@@ -135,23 +251,7 @@ func (r *reviewRepository) GetReviewFeedbackByReviewID(ctx context.Context, revi
 
 	var feedbacks []*domain.ReviewFeedback
 	for rows.Next() {
-		feedback := &domain.ReviewFeedback{}
-		err := rows.Scan(
-			&feedback.ID,
-			&feedback.ReviewID,
-			&feedback.FeedbackType,
-			&feedback.FilePath,
-			&feedback.LineStart,
-			&feedback.LineEnd,
-			&feedback.CodeSnippet,
-			&feedback.SuggestedFix,
-			&feedback.Description,
-			&feedback.Severity,
-			&feedback.IsResolved,
-			&feedback.TeacherComment,
-			&feedback.TeacherApproved,
-			&feedback.CreatedAt,
-		)
+		feedback, err := scanReviewFeedback(rows)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan review feedback: %w", err)
 		}
@@ -165,3 +265,121 @@ func (r *reviewRepository) GetReviewFeedbackByReviewID(ctx context.Context, revi
 
 	return feedbacks, nil
 }
+
+func (r *reviewRepository) GetReviewFeedbackByID(ctx context.Context, id int) (*domain.ReviewFeedback, error) {
+	query := `SELECT ` + reviewFeedbackColumns + ` FROM review_feedback WHERE id = $1`
+
+	feedback, err := scanReviewFeedback(r.pool.QueryRow(ctx, query, id))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get review feedback: %w", err)
+	}
+
+	return feedback, nil
+}
+
+func (r *reviewRepository) UpdateReviewFeedback(ctx context.Context, feedback *domain.ReviewFeedback) error {
+	query := `
+		UPDATE review_feedback
+		SET teacher_approved = $1, teacher_comment = $2, is_resolved = $3
+		WHERE id = $4
+	`
+
+	_, err := r.pool.Exec(
+		ctx,
+		query,
+		feedback.TeacherApproved,
+		feedback.TeacherComment,
+		feedback.IsResolved,
+		feedback.ID,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update review feedback: %w", err)
+	}
+
+	return nil
+}
+
+func createCriterionResult(ctx context.Context, q queryRower, result *domain.CriterionResult) error {
+	query := `
+		INSERT INTO review_criteria_results (review_id, criterion_id, verdict, evidence)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id, created_at
+	`
+
+	err := q.QueryRow(
+		ctx,
+		query,
+		result.ReviewID,
+		result.CriterionID,
+		result.Verdict,
+		result.Evidence,
+	).Scan(&result.ID, &result.CreatedAt)
+
+	if err != nil {
+		return fmt.Errorf("failed to create criterion result: %w", err)
+	}
+
+	return nil
+}
+
+func (r *reviewRepository) GetCriterionReportsByReviewID(ctx context.Context, reviewID int) ([]*domain.CriterionReport, error) {
+	query := `
+		SELECT r.id, r.review_id, r.criterion_id, r.verdict, r.evidence, r.created_at,
+			   c.criterion_name, c.is_mandatory, c.weight
+		FROM review_criteria_results r
+		JOIN task_criteria c ON c.id = r.criterion_id
+		WHERE r.review_id = $1
+		ORDER BY c.id ASC
+	`
+
+	rows, err := r.pool.Query(ctx, query, reviewID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query criterion results: %w", err)
+	}
+	defer rows.Close()
+
+	var reports []*domain.CriterionReport
+	for rows.Next() {
+		report := &domain.CriterionReport{}
+		err := rows.Scan(
+			&report.ID,
+			&report.ReviewID,
+			&report.CriterionID,
+			&report.Verdict,
+			&report.Evidence,
+			&report.CreatedAt,
+			&report.CriterionName,
+			&report.IsMandatory,
+			&report.Weight,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan criterion result: %w", err)
+		}
+
+		reports = append(reports, report)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating criterion results: %w", err)
+	}
+
+	return reports, nil
+}
+
+func (r *reviewRepository) MarkFeedbackResolved(ctx context.Context, reviewID int, feedbackIDs []int) (int, error) {
+	query := `
+		UPDATE review_feedback
+		SET is_resolved = true
+		WHERE review_id = $1 AND id = ANY($2) AND is_resolved IS NOT TRUE
+	`
+
+	tag, err := r.pool.Exec(ctx, query, reviewID, feedbackIDs)
+	if err != nil {
+		return 0, fmt.Errorf("failed to mark review feedback resolved: %w", err)
+	}
+
+	return int(tag.RowsAffected()), nil
+}
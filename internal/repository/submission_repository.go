@@ -13,10 +13,16 @@ import (
 
 type SubmissionRepository interface {
 	Create(ctx context.Context, submission *domain.Submission) (int, error)
+	CreateWithFiles(ctx context.Context, submission *domain.Submission, files map[string]string) (int, error)
 	GetByID(ctx context.Context, id int) (*domain.Submission, error)
 	GetByTaskAndStudent(ctx context.Context, taskID, studentID int) ([]*domain.Submission, error)
-	GetPendingSubmissions(ctx context.Context) ([]*domain.Submission, error)
+	GetByTaskID(ctx context.Context, taskID int) ([]*domain.Submission, error)
 	UpdateStatus(ctx context.Context, id int, status domain.SubmissionStatus) error
+	CompleteReview(ctx context.Context, id int, from, to domain.SubmissionStatus, score *float64) (bool, error)
+	SaveFiles(ctx context.Context, submissionID int, files map[string]string) error
+	GetFiles(ctx context.Context, submissionID int) (map[string]string, error)
+	SaveArchive(ctx context.Context, sha256 string, content []byte) error
+	GetArchive(ctx context.Context, sha256 string) ([]byte, error)
 }
 
 type submissionRepository struct {
@@ -28,35 +34,80 @@ func NewSubmissionRepository(pool *pgxpool.Pool, logger *zap.Logger) SubmissionR
 	return &submissionRepository{pool: pool, logger: logger}
 }
 
-func (r *submissionRepository) Create(ctx context.Context, submission *domain.Submission) (int, error) {
-	query := `
-		INSERT INTO submissions (student_id, task_id, code, github_url, status, submission_type)
-		VALUES ($1, $2, $3, $4, $5, $6)
-		RETURNING id, submitted_at
-	`
+const insertSubmissionQuery = `
+	INSERT INTO submissions (
+		student_id, task_id, code, github_url, status, submission_type, previous_submission_id,
+		git_ref, commit_sha, archive_name, archive_sha256, multi_file
+	)
+	VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
+	RETURNING id, submitted_at
+`
 
-	var id int
-	err := r.pool.QueryRow(
-		ctx,
-		query,
+func insertSubmissionArgs(submission *domain.Submission) []any {
+	return []any{
 		submission.StudentID,
 		submission.TaskID,
 		submission.Code,
 		submission.GithubURL,
 		submission.Status,
 		submission.SubmissionType,
-	).Scan(&id, &submission.SubmittedAt)
+		submission.PreviousSubmissionID,
+		submission.GitRef,
+		submission.CommitSHA,
+		submission.ArchiveName,
+		submission.ArchiveSHA256,
+		submission.MultiFile,
+	}
+}
+
+func (r *submissionRepository) Create(ctx context.Context, submission *domain.Submission) (int, error) {
+	var id int
+	err := r.pool.QueryRow(ctx, insertSubmissionQuery, insertSubmissionArgs(submission)...).
+		Scan(&id, &submission.SubmittedAt)
+
+	if err != nil {
+		return 0, fmt.Errorf("failed to create submission: %w", err)
+	}
+
+	return id, nil
+}
+
+// CreateWithFiles stores a multi-file code submission together with its
+// files, so a submission never exists without its content.
+func (r *submissionRepository) CreateWithFiles(ctx context.Context, submission *domain.Submission, files map[string]string) (int, error) {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
 
+	var id int
+	err = tx.QueryRow(ctx, insertSubmissionQuery, insertSubmissionArgs(submission)...).
+		Scan(&id, &submission.SubmittedAt)
 	if err != nil {
 		return 0, fmt.Errorf("failed to create submission: %w", err)
 	}
 
+	batch := &pgx.Batch{}
+	for path, content := range files {
+		batch.Queue(`INSERT INTO submission_files (submission_id, file_path, content) VALUES ($1, $2, $3)`,
+			id, path, content)
+	}
+	if err := tx.SendBatch(ctx, batch).Close(); err != nil {
+		return 0, fmt.Errorf("failed to save submission files: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return 0, fmt.Errorf("failed to commit submission: %w", err)
+	}
+
 	return id, nil
 }
 
 func (r *submissionRepository) GetByID(ctx context.Context, id int) (*domain.Submission, error) {
 	query := `
-		SELECT id, student_id, task_id, code, github_url, submitted_at, score, status, submission_type
+		SELECT id, student_id, task_id, code, github_url, submitted_at, score, status, submission_type,
+		       previous_submission_id, git_ref, commit_sha, archive_name, archive_sha256, multi_file
 		FROM submissions
 		WHERE id = $1
 	`
@@ -72,6 +123,12 @@ func (r *submissionRepository) GetByID(ctx context.Context, id int) (*domain.Sub
 		&submission.Score,
 		&submission.Status,
 		&submission.SubmissionType,
+		&submission.PreviousSubmissionID,
+		&submission.GitRef,
+		&submission.CommitSHA,
+		&submission.ArchiveName,
+		&submission.ArchiveSHA256,
+		&submission.MultiFile,
 	)
 
 	if err != nil {
@@ -87,7 +144,8 @@ func (r *submissionRepository) GetByID(ctx context.Context, id int) (*domain.Sub
 
 func (r *submissionRepository) GetByTaskAndStudent(ctx context.Context, taskID, studentID int) ([]*domain.Submission, error) {
 	query := `
-		SELECT id, student_id, task_id, code, github_url, submitted_at, score, status, submission_type
+		SELECT id, student_id, task_id, code, github_url, submitted_at, score, status, submission_type,
+		       previous_submission_id, git_ref, commit_sha, archive_name, archive_sha256, multi_file
 		FROM submissions
 		WHERE task_id = $1 AND student_id = $2
 		ORDER BY submitted_at DESC
@@ -112,6 +170,12 @@ func (r *submissionRepository) GetByTaskAndStudent(ctx context.Context, taskID,
 			&submission.Score,
 			&submission.Status,
 			&submission.SubmissionType,
+			&submission.PreviousSubmissionID,
+			&submission.GitRef,
+			&submission.CommitSHA,
+			&submission.ArchiveName,
+			&submission.ArchiveSHA256,
+			&submission.MultiFile,
 		)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan submission: %w", err)
@@ -127,18 +191,18 @@ func (r *submissionRepository) GetByTaskAndStudent(ctx context.Context, taskID,
 	return submissions, nil
 }
 
-func (r *submissionRepository) GetPendingSubmissions(ctx context.Context) ([]*domain.Submission, error) {
+func (r *submissionRepository) GetByTaskID(ctx context.Context, taskID int) ([]*domain.Submission, error) {
 	query := `
-		SELECT id, student_id, task_id, code, github_url, submitted_at, score, status, submission_type
+		SELECT id, student_id, task_id, code, github_url, submitted_at, score, status, submission_type,
+		       previous_submission_id, git_ref, commit_sha, archive_name, archive_sha256, multi_file
 		FROM submissions
-		WHERE status = $1
-		ORDER BY submitted_at ASC
-		LIMIT 10
+		WHERE task_id = $1
+		ORDER BY submitted_at DESC
 	`
 
-	rows, err := r.pool.Query(ctx, query, domain.StatusPending)
+	rows, err := r.pool.Query(ctx, query, taskID)
 	if err != nil {
-		return nil, fmt.Errorf("failed to query pending submissions: %w", err)
+		return nil, fmt.Errorf("failed to query submissions: %w", err)
 	}
 	defer rows.Close()
 
@@ -155,6 +219,12 @@ func (r *submissionRepository) GetPendingSubmissions(ctx context.Context) ([]*do
 			&submission.Score,
 			&submission.Status,
 			&submission.SubmissionType,
+			&submission.PreviousSubmissionID,
+			&submission.GitRef,
+			&submission.CommitSHA,
+			&submission.ArchiveName,
+			&submission.ArchiveSHA256,
+			&submission.MultiFile,
 		)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan submission: %w", err)
@@ -184,3 +254,98 @@ func (r *submissionRepository) UpdateStatus(ctx context.Context, id int, status
 
 	return nil
 }
+
+// CompleteReview moves the submission from one status to another and sets
+// the score if given. It returns false when the submission is no longer in
+// the from status.
+func (r *submissionRepository) CompleteReview(ctx context.Context, id int, from, to domain.SubmissionStatus, score *float64) (bool, error) {
+	query := `
+		UPDATE submissions
+		SET status = $1, score = COALESCE($2, score)
+		WHERE id = $3 AND status = $4
+	`
+
+	tag, err := r.pool.Exec(ctx, query, to, score, id, from)
+	if err != nil {
+		return false, fmt.Errorf("failed to complete submission review: %w", err)
+	}
+
+	return tag.RowsAffected() > 0, nil
+}
+
+func (r *submissionRepository) SaveFiles(ctx context.Context, submissionID int, files map[string]string) error {
+	query := `
+		INSERT INTO submission_files (submission_id, file_path, content)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (submission_id, file_path) DO UPDATE SET content = EXCLUDED.content
+	`
+
+	batch := &pgx.Batch{}
+	for path, content := range files {
+		batch.Queue(query, submissionID, path, content)
+	}
+
+	if err := r.pool.SendBatch(ctx, batch).Close(); err != nil {
+		return fmt.Errorf("failed to save submission files: %w", err)
+	}
+
+	return nil
+}
+
+func (r *submissionRepository) GetFiles(ctx context.Context, submissionID int) (map[string]string, error) {
+	query := `SELECT file_path, content FROM submission_files WHERE submission_id = $1`
+
+	rows, err := r.pool.Query(ctx, query, submissionID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query submission files: %w", err)
+	}
+	defer rows.Close()
+
+	files := make(map[string]string)
+	for rows.Next() {
+		var path, content string
+		if err := rows.Scan(&path, &content); err != nil {
+			return nil, fmt.Errorf("failed to scan submission file: %w", err)
+		}
+		files[path] = content
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating submission files: %w", err)
+	}
+
+	return files, nil
+}
+
+// SaveArchive stores an uploaded archive once per content hash; uploading
+// the same archive again reuses the stored copy.
+func (r *submissionRepository) SaveArchive(ctx context.Context, sha256 string, content []byte) error {
+	query := `
+		INSERT INTO submission_archives (sha256, size_bytes, content)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (sha256) DO NOTHING
+	`
+
+	_, err := r.pool.Exec(ctx, query, sha256, len(content), content)
+	if err != nil {
+		return fmt.Errorf("failed to save submission archive: %w", err)
+	}
+
+	return nil
+}
+
+func (r *submissionRepository) GetArchive(ctx context.Context, sha256 string) ([]byte, error) {
+	query := `SELECT content FROM submission_archives WHERE sha256 = $1`
+
+	var content []byte
+	err := r.pool.QueryRow(ctx, query, sha256).Scan(&content)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+
+		return nil, fmt.Errorf("failed to get submission archive: %w", err)
+	}
+
+	return content, nil
+}
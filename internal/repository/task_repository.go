@@ -14,6 +14,7 @@ type TaskRepository interface {
 	Create(ctx context.Context, task *domain.Task) (int, error)
 	GetByID(ctx context.Context, id int) (*domain.Task, error)
 	GetByCourseID(ctx context.Context, courseID int) ([]*domain.Task, error)
+	UpdateFileRules(ctx context.Context, taskID int, rules domain.FileSelectionRules) error
 	CreateCriteria(ctx context.Context, criteria *domain.TaskCriteria) (int, error)
 	GetCriteriaByTaskID(ctx context.Context, taskID int) ([]*domain.TaskCriteria, error)
 	DeleteCriteriaByTaskID(ctx context.Context, taskID int) error
@@ -29,8 +30,9 @@ func NewTaskRepository(pool *pgxpool.Pool) TaskRepository {
 
 func (r *taskRepository) Create(ctx context.Context, task *domain.Task) (int, error) {
 	query := `
-		INSERT INTO tasks (course_id, title, description, deadline, max_score)
-		VALUES ($1, $2, $3, $4, $5)
+		INSERT INTO tasks (course_id, title, description, deadline, max_score,
+			include_patterns, exclude_patterns, max_file_size_kb, max_files)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
 		RETURNING id, created_at
 	`
 
@@ -43,6 +45,10 @@ func (r *taskRepository) Create(ctx context.Context, task *domain.Task) (int, er
 		task.Description,
 		task.Deadline,
 		task.MaxScore,
+		nonNilStrings(task.FileRules.IncludePatterns),
+		nonNilStrings(task.FileRules.ExcludePatterns),
+		task.FileRules.MaxFileSizeKB,
+		task.FileRules.MaxFiles,
 	).Scan(&id, &task.CreatedAt)
 
 	if err != nil {
@@ -54,7 +60,8 @@ func (r *taskRepository) Create(ctx context.Context, task *domain.Task) (int, er
 
 func (r *taskRepository) GetByID(ctx context.Context, id int) (*domain.Task, error) {
 	query := `
-		SELECT id, course_id, title, description, deadline, max_score, created_at, updated_at
+		SELECT id, course_id, title, description, deadline, max_score, created_at, updated_at,
+			include_patterns, exclude_patterns, max_file_size_kb, max_files
 		FROM tasks
 		WHERE id = $1
 	`
@@ -69,6 +76,10 @@ func (r *taskRepository) GetByID(ctx context.Context, id int) (*domain.Task, err
 		&task.MaxScore,
 		&task.CreatedAt,
 		&task.UpdatedAt,
+		&task.FileRules.IncludePatterns,
+		&task.FileRules.ExcludePatterns,
+		&task.FileRules.MaxFileSizeKB,
+		&task.FileRules.MaxFiles,
 	)
 
 	if err != nil {
@@ -82,9 +93,34 @@ func (r *taskRepository) GetByID(ctx context.Context, id int) (*domain.Task, err
 	return task, nil
 }
 
+func (r *taskRepository) UpdateFileRules(ctx context.Context, taskID int, rules domain.FileSelectionRules) error {
+	query := `
+		UPDATE tasks
+		SET include_patterns = $1, exclude_patterns = $2, max_file_size_kb = $3, max_files = $4,
+			updated_at = NOW()
+		WHERE id = $5
+	`
+
+	_, err := r.pool.Exec(
+		ctx,
+		query,
+		nonNilStrings(rules.IncludePatterns),
+		nonNilStrings(rules.ExcludePatterns),
+		rules.MaxFileSizeKB,
+		rules.MaxFiles,
+		taskID,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update task file rules: %w", err)
+	}
+
+	return nil
+}
+
 func (r *taskRepository) GetByCourseID(ctx context.Context, courseID int) ([]*domain.Task, error) {
 	query := `
-		SELECT id, course_id, title, description, deadline, max_score, created_at, updated_at
+		SELECT id, course_id, title, description, deadline, max_score, created_at, updated_at,
+			include_patterns, exclude_patterns, max_file_size_kb, max_files
 		FROM tasks
 		WHERE course_id = $1
 		ORDER BY deadline ASC
@@ -108,6 +144,10 @@ func (r *taskRepository) GetByCourseID(ctx context.Context, courseID int) ([]*do
 			&task.MaxScore,
 			&task.CreatedAt,
 			&task.UpdatedAt,
+			&task.FileRules.IncludePatterns,
+			&task.FileRules.ExcludePatterns,
+			&task.FileRules.MaxFileSizeKB,
+			&task.FileRules.MaxFiles,
 		)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan task: %w", err)
@@ -123,8 +163,13 @@ func (r *taskRepository) GetByCourseID(ctx context.Context, courseID int) ([]*do
 	return tasks, nil
 }
 
+// CreateCriteria and DeleteCriteriaByTaskID also drop the task's cached
+// reviews, whose criterion verdicts no longer match.
 func (r *taskRepository) CreateCriteria(ctx context.Context, criteria *domain.TaskCriteria) (int, error) {
 	query := `
+		WITH invalidated AS (
+			DELETE FROM review_cache WHERE task_id = $1
+		)
 		INSERT INTO task_criteria (task_id, criterion_name, criterion_description, is_mandatory, weight)
 		VALUES ($1, $2, $3, $4, $5)
 		RETURNING id, created_at
@@ -189,7 +234,12 @@ func (r *taskRepository) GetCriteriaByTaskID(ctx context.Context, taskID int) ([
 }
 
 func (r *taskRepository) DeleteCriteriaByTaskID(ctx context.Context, taskID int) error {
-	query := `DELETE FROM task_criteria WHERE task_id = $1`
+	query := `
+		WITH invalidated AS (
+			DELETE FROM review_cache WHERE task_id = $1
+		)
+		DELETE FROM task_criteria WHERE task_id = $1
+	`
 
 	_, err := r.pool.Exec(ctx, query, taskID)
 	if err != nil {
@@ -198,3 +248,11 @@ func (r *taskRepository) DeleteCriteriaByTaskID(ctx context.Context, taskID int)
 
 	return nil
 }
+
+// nonNilStrings keeps NOT NULL text[] columns from receiving NULL.
+func nonNilStrings(values []string) []string {
+	if values == nil {
+		return []string{}
+	}
+	return values
+}
@@ -14,6 +14,9 @@ type UserRepository interface {
 	Create(ctx context.Context, user *domain.User) (int, error)
 	GetByID(ctx context.Context, id int) (*domain.User, error)
 	GetByEmail(ctx context.Context, email string) (*domain.User, error)
+	UpdateLastLogin(ctx context.Context, id int) error
+	SetLanguage(ctx context.Context, id int, language *domain.Language) error
+	GetLanguage(ctx context.Context, id int) (*domain.Language, error)
 }
 
 type userRepository struct {
@@ -26,8 +29,8 @@ func NewUserRepository(pool *pgxpool.Pool) UserRepository {
 
 func (r *userRepository) Create(ctx context.Context, user *domain.User) (int, error) {
 	query := `
-		INSERT INTO users (email, password_hash, role, first_name, last_name)
-		VALUES ($1, $2, $3, $4, $5)
+		INSERT INTO users (email, password_hash, role, first_name, last_name, language)
+		VALUES ($1, $2, $3, $4, $5, $6)
 		RETURNING id, created_at
 	`
 
@@ -40,6 +43,7 @@ func (r *userRepository) Create(ctx context.Context, user *domain.User) (int, er
 		user.Role,
 		user.FirstName,
 		user.LastName,
+		user.Language,
 	).Scan(&id, &user.CreatedAt)
 
 	if err != nil {
@@ -51,7 +55,7 @@ func (r *userRepository) Create(ctx context.Context, user *domain.User) (int, er
 
 func (r *userRepository) GetByID(ctx context.Context, id int) (*domain.User, error) {
 	query := `
-		SELECT id, email, password_hash, role, first_name, last_name, created_at, last_login
+		SELECT id, email, password_hash, role, first_name, last_name, created_at, last_login, language
 		FROM users
 		WHERE id = $1
 	`
@@ -66,6 +70,7 @@ func (r *userRepository) GetByID(ctx context.Context, id int) (*domain.User, err
 		&user.LastName,
 		&user.CreatedAt,
 		&user.LastLogin,
+		&user.Language,
 	)
 
 	if err != nil {
@@ -81,7 +86,7 @@ func (r *userRepository) GetByID(ctx context.Context, id int) (*domain.User, err
 
 func (r *userRepository) GetByEmail(ctx context.Context, email string) (*domain.User, error) {
 	query := `
-		SELECT id, email, password_hash, role, first_name, last_name, created_at, last_login
+		SELECT id, email, password_hash, role, first_name, last_name, created_at, last_login, language
 		FROM users
 		WHERE email = $1
 	`
@@ -96,11 +101,66 @@ func (r *userRepository) GetByEmail(ctx context.Context, email string) (*domain.
 		&user.LastName,
 		&user.CreatedAt,
 		&user.LastLogin,
+		&user.Language,
 	)
 
 	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+
 		return nil, fmt.Errorf("failed to get user: %w", err)
 	}
 
 	return user, nil
 }
+
+func (r *userRepository) UpdateLastLogin(ctx context.Context, id int) error {
+	query := `
+		UPDATE users
+		SET last_login = NOW()
+		WHERE id = $1
+	`
+
+	_, err := r.pool.Exec(ctx, query, id)
+	if err != nil {
+		return fmt.Errorf("failed to update last login: %w", err)
+	}
+
+	return nil
+}
+
+func (r *userRepository) SetLanguage(ctx context.Context, id int, language *domain.Language) error {
+	query := `
+		UPDATE users
+		SET language = $1
+		WHERE id = $2
+	`
+
+	_, err := r.pool.Exec(ctx, query, language, id)
+	if err != nil {
+		return fmt.Errorf("failed to set user language: %w", err)
+	}
+
+	return nil
+}
+
+func (r *userRepository) GetLanguage(ctx context.Context, id int) (*domain.Language, error) {
+	query := `
+		SELECT language
+		FROM users
+		WHERE id = $1
+	`
+
+	var language *domain.Language
+	err := r.pool.QueryRow(ctx, query, id).Scan(&language)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+
+		return nil, fmt.Errorf("failed to get user language: %w", err)
+	}
+
+	return language, nil
+}
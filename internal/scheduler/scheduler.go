@@ -5,28 +5,36 @@ import (
 	"time"
 
 	"github.com/go-co-op/gocron/v2"
+	"github.com/ilyin-ad/flutter-code-mentor/internal/config"
 	"github.com/ilyin-ad/flutter-code-mentor/internal/usecase"
 	"go.uber.org/zap"
 )
 
 type Scheduler struct {
-	scheduler gocron.Scheduler
-	reviewUC  usecase.ReviewUseCase
-	logger    *zap.Logger
+	scheduler     gocron.Scheduler
+	reviewUC      usecase.ReviewUseCase
+	dispatcher    usecase.ReviewDispatcher
+	sweepInterval time.Duration
+	logger        *zap.Logger
 }
 
-const kSchedulerDurationJob = 5 * time.Minute
-
-func NewScheduler(reviewUC usecase.ReviewUseCase, logger *zap.Logger) (*Scheduler, error) {
+func NewScheduler(
+	cfg *config.Config,
+	reviewUC usecase.ReviewUseCase,
+	dispatcher usecase.ReviewDispatcher,
+	logger *zap.Logger,
+) (*Scheduler, error) {
 	s, err := gocron.NewScheduler()
 	if err != nil {
 		return nil, err
 	}
 
 	return &Scheduler{
-		scheduler: s,
-		reviewUC:  reviewUC,
-		logger:    logger,
+		scheduler:     s,
+		reviewUC:      reviewUC,
+		dispatcher:    dispatcher,
+		sweepInterval: cfg.ReviewQueue.SweepInterval,
+		logger:        logger,
 	}, nil
 }
 
@@ -34,13 +42,19 @@ func (s *Scheduler) Start(ctx context.Context) error {
 	s.logger.Info("Starting scheduler")
 
 	_, err := s.scheduler.NewJob(
-		gocron.DurationJob(kSchedulerDurationJob),
+		gocron.DurationJob(s.sweepInterval),
 		gocron.NewTask(func() {
-			s.logger.Info("Running scheduled code review task")
-			if err := s.reviewUC.ProcessPendingSubmissions(context.Background()); err != nil {
-				s.logger.Error("Failed to process pending submissions", zap.Error(err))
+			s.logger.Info("Running scheduled review sweep")
+			submissionIDs, err := s.reviewUC.SweepReviewJobs(context.Background())
+			if err != nil {
+				s.logger.Error("Failed to sweep review jobs", zap.Error(err))
+				return
+			}
+			for _, submissionID := range submissionIDs {
+				s.dispatcher.Dispatch(submissionID)
 			}
 		}),
+		gocron.WithStartAt(gocron.WithStartImmediately()),
 	)
 
 	if err != nil {
@@ -0,0 +1,126 @@
+package server
+
+import (
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/ilyin-ad/flutter-code-mentor/api"
+	"github.com/ilyin-ad/flutter-code-mentor/internal/domain"
+	"github.com/ilyin-ad/flutter-code-mentor/internal/service"
+	"github.com/ilyin-ad/flutter-code-mentor/internal/usecase"
+	"github.com/labstack/echo/v4"
+	"go.uber.org/zap"
+)
+
+// publicOperations do not require a bearer token. A valid token sent with
+// them still identifies the caller, e.g. an admin creating a teacher.
+var publicOperations = map[string]bool{
+	"GET /health":        true,
+	"POST /auth/login":   true,
+	"POST /auth/refresh": true,
+	"POST /user":         true,
+}
+
+// operationRoles lists the roles allowed to call each protected operation.
+// Operations missing here are open to any authenticated user; ownership is
+// checked in the use cases.
+var operationRoles = map[string][]string{
+	"POST /courses":                                {domain.RoleTeacher, domain.RoleAdmin},
+	"GET /courses/:id/enrollments":                 {domain.RoleTeacher, domain.RoleAdmin},
+	"POST /courses/:id/enrollments":                {domain.RoleTeacher, domain.RoleAdmin},
+	"DELETE /courses/:id/enrollments/:student_id":  {domain.RoleTeacher, domain.RoleAdmin},
+	"PATCH /courses/:id/enrollments/:student_id":   {domain.RoleTeacher, domain.RoleAdmin},
+	"POST /courses/:id/invite-code":                {domain.RoleTeacher, domain.RoleAdmin},
+	"PUT /courses/:id/language":                    {domain.RoleTeacher, domain.RoleAdmin},
+	"POST /enrollments/join":                       {domain.RoleStudent},
+	"POST /task":                                   {domain.RoleTeacher, domain.RoleAdmin},
+	"POST /submissions/:id/feedback":               {domain.RoleTeacher, domain.RoleAdmin},
+	"PATCH /submissions/:id/feedback/:feedback_id": {domain.RoleTeacher, domain.RoleAdmin},
+	"POST /submissions/:id/teacher-review":         {domain.RoleTeacher, domain.RoleAdmin},
+	"POST /submission":                             {domain.RoleStudent},
+	"GET /tasks/:id/submissions":                   {domain.RoleTeacher, domain.RoleAdmin},
+	"GET /review-jobs/failed":                      {domain.RoleAdmin},
+	"GET /courses/:id/prompt-template":             {domain.RoleTeacher, domain.RoleAdmin},
+	"PUT /courses/:id/prompt-template":             {domain.RoleTeacher, domain.RoleAdmin},
+	"DELETE /courses/:id/prompt-template":          {domain.RoleTeacher, domain.RoleAdmin},
+	"GET /courses/:id/prompt-template/versions":    {domain.RoleTeacher, domain.RoleAdmin},
+	"GET /tasks/:id/file-rules":                    {domain.RoleTeacher, domain.RoleAdmin},
+	"PUT /tasks/:id/file-rules":                    {domain.RoleTeacher, domain.RoleAdmin},
+	"GET /tasks/:id/prompt-template":               {domain.RoleTeacher, domain.RoleAdmin},
+	"PUT /tasks/:id/prompt-template":               {domain.RoleTeacher, domain.RoleAdmin},
+	"DELETE /tasks/:id/prompt-template":            {domain.RoleTeacher, domain.RoleAdmin},
+	"GET /tasks/:id/prompt-template/versions":      {domain.RoleTeacher, domain.RoleAdmin},
+	"POST /submissions/:id/prompt-preview":         {domain.RoleTeacher, domain.RoleAdmin},
+	"GET /courses/:id/ai-usage":                    {domain.RoleTeacher, domain.RoleAdmin},
+	"PUT /courses/:id/ai-budget":                   {domain.RoleTeacher, domain.RoleAdmin},
+}
+
+func operationKey(c echo.Context) string {
+	return c.Request().Method + " " + c.Path()
+}
+
+func authMiddleware(tokenService service.TokenService, userUseCase usecase.UserUseCase, logger *zap.Logger) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			operation := operationKey(c)
+			header := c.Request().Header.Get(echo.HeaderAuthorization)
+			token, found := strings.CutPrefix(header, "Bearer ")
+
+			if publicOperations[operation] {
+				if found && token != "" {
+					if principal, err := tokenService.ParseAccessToken(token); err == nil {
+						setPrincipal(c, principal, userUseCase, logger)
+					}
+				}
+				return next(c)
+			}
+
+			if !found || token == "" {
+				return c.JSON(http.StatusUnauthorized, api.ApiError{
+					Error: stringPtr("Missing bearer token"),
+				})
+			}
+
+			principal, err := tokenService.ParseAccessToken(token)
+			if err != nil {
+				logger.Debug("Rejected access token", zap.Error(err))
+				return c.JSON(http.StatusUnauthorized, api.ApiError{
+					Error: stringPtr("Invalid or expired token"),
+				})
+			}
+
+			if roles, ok := operationRoles[operation]; ok && !principal.HasRole(roles...) {
+				return c.JSON(http.StatusForbidden, api.ApiError{
+					Error: stringPtr("Insufficient role for this operation"),
+				})
+			}
+
+			setPrincipal(c, principal, userUseCase, logger)
+
+			return next(c)
+		}
+	}
+}
+
+// setPrincipal attaches the principal to the request together with a lookup
+// of the user's current language. The language is not part of the token, so
+// that a change applies at once, and it is only read when a localized
+// response is written. A failed lookup only falls back to Accept-Language.
+func setPrincipal(c echo.Context, principal *domain.Principal, userUseCase usecase.UserUseCase, logger *zap.Logger) {
+	ctx := c.Request().Context()
+
+	lookup := sync.OnceValue(func() domain.Language {
+		language, err := userUseCase.Language(ctx, principal.UserID)
+		if err != nil {
+			logger.Warn("Failed to get user language", zap.Int("user_id", principal.UserID), zap.Error(err))
+		}
+		return language
+	})
+
+	c.SetRequest(c.Request().WithContext(domain.WithLanguageLookup(domain.WithPrincipal(ctx, principal), lookup)))
+}
+
+func stringPtr(s string) *string {
+	return &s
+}
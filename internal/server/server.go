@@ -7,6 +7,8 @@ import (
 	"github.com/ilyin-ad/flutter-code-mentor/api"
 	"github.com/ilyin-ad/flutter-code-mentor/internal/config"
 	"github.com/ilyin-ad/flutter-code-mentor/internal/handler"
+	"github.com/ilyin-ad/flutter-code-mentor/internal/service"
+	"github.com/ilyin-ad/flutter-code-mentor/internal/usecase"
 	"github.com/labstack/echo/v4"
 	"github.com/labstack/echo/v4/middleware"
 	"go.uber.org/fx"
@@ -24,6 +26,12 @@ type Handlers struct {
 	*handler.TaskHandler
 	*handler.UserHandler
 	*handler.CourseHandler
+	*handler.ReviewHandler
+	*handler.AuthHandler
+	*handler.EnrollmentHandler
+	*handler.TeacherReviewHandler
+	*handler.PromptTemplateHandler
+	*handler.AIUsageHandler
 }
 
 func NewServer(
@@ -32,6 +40,14 @@ func NewServer(
 	taskHandler *handler.TaskHandler,
 	userHandler *handler.UserHandler,
 	courseHandler *handler.CourseHandler,
+	reviewHandler *handler.ReviewHandler,
+	authHandler *handler.AuthHandler,
+	enrollmentHandler *handler.EnrollmentHandler,
+	teacherReviewHandler *handler.TeacherReviewHandler,
+	promptTemplateHandler *handler.PromptTemplateHandler,
+	aiUsageHandler *handler.AIUsageHandler,
+	tokenService service.TokenService,
+	userUseCase usecase.UserUseCase,
 	logger *zap.Logger,
 ) *Server {
 	e := echo.New()
@@ -39,12 +55,19 @@ func NewServer(
 	e.Use(middleware.Logger())
 	e.Use(middleware.Recover())
 	e.Use(middleware.CORS())
+	e.Use(authMiddleware(tokenService, userUseCase, logger))
 
 	handlers := &Handlers{
-		SubmissionHandler: submissionHandler,
-		TaskHandler:       taskHandler,
-		UserHandler:       userHandler,
-		CourseHandler:     courseHandler,
+		SubmissionHandler:     submissionHandler,
+		TaskHandler:           taskHandler,
+		UserHandler:           userHandler,
+		CourseHandler:         courseHandler,
+		ReviewHandler:         reviewHandler,
+		AuthHandler:           authHandler,
+		EnrollmentHandler:     enrollmentHandler,
+		TeacherReviewHandler:  teacherReviewHandler,
+		PromptTemplateHandler: promptTemplateHandler,
+		AIUsageHandler:        aiUsageHandler,
 	}
 
 	api.RegisterHandlers(e, handlers)
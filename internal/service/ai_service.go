@@ -1,47 +1,70 @@
 package service
 
 import (
-	"bytes"
 	"context"
-	"encoding/json"
 	"fmt"
-	"io"
-	"net/http"
-	"strings"
 	"time"
 
+	"github.com/ilyin-ad/flutter-code-mentor/internal/config"
 	"github.com/ilyin-ad/flutter-code-mentor/internal/domain"
 	"go.uber.org/zap"
 )
 
 type AIService interface {
-	ReviewCode(ctx context.Context, code *string, task *domain.Task, criteria []*domain.TaskCriteria) (*CodeReviewResult, error)
-	ReviewGitHubProject(ctx context.Context, files map[string]string, task *domain.Task, criteria []*domain.TaskCriteria) (*CodeReviewResult, error)
+	Review(ctx context.Context, prompt ReviewPrompt, task *domain.Task, criteria []*domain.TaskCriteria) (*CodeReviewResult, error)
+	RenderPrompt(prompt ReviewPrompt, task *domain.Task, criteria []*domain.TaskCriteria) []RenderedPrompt
+	CheckPromptTemplate(prompt ReviewPrompt, task *domain.Task, criteria []*domain.TaskCriteria) error
+	Model() string
 }
 
 type aiService struct {
-	apiKey string
-	apiURL string
-	client *http.Client
-	logger *zap.Logger
+	provider           LLMProvider
+	repairEnabled      bool
+	maxPromptTokens    int
+	inputPricePerMTok  float64
+	outputPricePerMTok float64
+	logger             *zap.Logger
 }
 
-func NewAIService(apiKey, apiURL string, logger *zap.Logger) AIService {
+func NewAIService(provider LLMProvider, cfg *config.Config, logger *zap.Logger) AIService {
 	return &aiService{
-		apiKey: apiKey,
-		apiURL: apiURL,
-		client: &http.Client{
-			Timeout: 60 * time.Second,
-		},
-		logger: logger,
+		provider:           provider,
+		repairEnabled:      cfg.AI.RepairEnabled,
+		maxPromptTokens:    cfg.AI.MaxPromptTokens,
+		inputPricePerMTok:  cfg.AI.InputPricePerMTok,
+		outputPricePerMTok: cfg.AI.OutputPricePerMTok,
+		logger:             logger,
 	}
 }
 
 type CodeReviewResult struct {
+	AIModel         string
 	OverallStatus   string
 	AIConfidence    float64
 	ExecutionTimeMs int
 	Feedbacks       []FeedbackItem
+	Criteria        []CriterionVerdictItem
+
+	ResolvedFeedbackIDs []int
+
+	// PromptTokens and CompletionTokens count all model calls of the review,
+	// repairs included; EstimatedCost prices them with the configured rates.
+	PromptTokens     int
+	CompletionTokens int
+	EstimatedCost    float64
+}
+
+// RenderedPrompt is one model call of a review as it would be sent.
+type RenderedPrompt struct {
+	SystemPrompt    string
+	UserPrompt      string
+	EstimatedTokens int
+}
+
+type CriterionVerdictItem struct {
+	CriterionID int
+	Verdict     domain.CriterionVerdict
+	Evidence    string
 }
 
 type FeedbackItem struct {
@@ -53,325 +76,245 @@ type FeedbackItem struct {
 	SuggestedFix string
 	Description  string
 	Severity     int
-}
 
-type deepseekRequest struct {
-	Model    string    `json:"model"`
-	Messages []message `json:"messages"`
-	Stream   bool      `json:"stream"`
+	// Anchored is set by AnchorFeedback when the snippet was found in the
+	// reviewed files.
+	Anchored bool
 }
 
-type message struct {
-	Role    string `json:"role"`
-	Content string `json:"content"`
-}
+type aiReviewResponse struct {
+	OverallStatus string          `json:"overall_status"`
+	Confidence    flexFloat       `json:"confidence"`
+	Feedbacks     []feedbackJSON  `json:"feedbacks"`
+	Criteria      []criterionJSON `json:"criteria"`
 
-type deepseekResponse struct {
-	Choices []struct {
-		Message struct {
-			Content string `json:"content"`
-		} `json:"message"`
-	} `json:"choices"`
+	ResolvedFeedbackIDs []flexInt `json:"resolved_feedback_ids"`
 }
 
-type aiReviewResponse struct {
-	OverallStatus string         `json:"overall_status"`
-	Confidence    float64        `json:"confidence"`
-	Feedbacks     []feedbackJSON `json:"feedbacks"`
+type criterionJSON struct {
+	CriterionID flexInt `json:"criterion_id"`
+	Verdict     string  `json:"verdict"`
+	Evidence    string  `json:"evidence"`
 }
 
 type feedbackJSON struct {
-	Type         string `json:"type"`
-	FilePath     string `json:"file_path"`
-	LineStart    int    `json:"line_start"`
-	LineEnd      int    `json:"line_end"`
-	CodeSnippet  string `json:"code_snippet"`
-	SuggestedFix string `json:"suggested_fix"`
-	Description  string `json:"description"`
-	Severity     int    `json:"severity"`
+	Type         string  `json:"type"`
+	FilePath     string  `json:"file_path"`
+	LineStart    flexInt `json:"line_start"`
+	LineEnd      flexInt `json:"line_end"`
+	CodeSnippet  string  `json:"code_snippet"`
+	SuggestedFix string  `json:"suggested_fix"`
+	Description  string  `json:"description"`
+	Severity     flexInt `json:"severity"`
 }
 
-func (s *aiService) ReviewCode(ctx context.Context, code *string, task *domain.Task, criteria []*domain.TaskCriteria) (*CodeReviewResult, error) {
-	startTime := time.Now()
-
-	s.logger.Info("Starting AI code review",
-		zap.Int("code_length", len(*code)),
-		zap.Int("criteria_count", len(criteria)),
-	)
-
-	prompt := s.buildPrompt(code, task, criteria)
-
-	reqBody := deepseekRequest{
-		Model: "deepseek-chat",
-		Messages: []message{
-			{
-				Role:    "system",
-				Content: "You are an expert Flutter/Dart code reviewer. Analyze code and provide structured feedback in JSON format.",
-			},
-			{
-				Role:    "user",
-				Content: prompt,
-			},
-		},
-		Stream: false,
-	}
-
-	jsonData, err := json.Marshal(reqBody)
-	if err != nil {
-		return nil, fmt.Errorf("failed to marshal request: %w", err)
-	}
-
-	s.logger.Info("Sending request to AI API",
-		zap.String("url", s.apiURL),
-		zap.String("model", "deepseek-chat"),
-	)
-
-	req, err := http.NewRequestWithContext(ctx, "POST", s.apiURL, bytes.NewBuffer(jsonData))
-	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
-	}
-
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Authorization", "Bearer "+s.apiKey)
+// Model is the model reviews are made with.
+func (s *aiService) Model() string {
+	return s.provider.Model()
+}
 
-	resp, err := s.client.Do(req)
-	if err != nil {
-		return nil, fmt.Errorf("failed to send request: %w", err)
+// RenderPrompt returns the prompts Review would send, one per part.
+func (s *aiService) RenderPrompt(prompt ReviewPrompt, task *domain.Task, criteria []*domain.TaskCriteria) []RenderedPrompt {
+	parts := s.split(prompt, task, criteria)
+	rendered := make([]RenderedPrompt, len(parts))
+	for i, part := range parts {
+		system, user := part.SystemPrompt(), part.UserPrompt(task, criteria)
+		rendered[i] = RenderedPrompt{
+			SystemPrompt:    system,
+			UserPrompt:      user,
+			EstimatedTokens: estimateTokens(system) + estimateTokens(user),
+		}
 	}
-	defer resp.Body.Close()
-
-	s.logger.Info("Received response from AI API",
-		zap.Int("status_code", resp.StatusCode),
-	)
+	return rendered
+}
 
-	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("API request failed with status %d: %s", resp.StatusCode, string(body))
+// CheckPromptTemplate renders the template of prompt for every part Review
+// would send and returns the first error, so a template that fails on this
+// submission is caught before the review rather than replaced silently.
+func (s *aiService) CheckPromptTemplate(prompt ReviewPrompt, task *domain.Task, criteria []*domain.TaskCriteria) error {
+	parts := s.split(prompt, task, criteria)
+	for i, part := range parts {
+		if err := templateError(part, task, criteria); err != nil {
+			if len(parts) > 1 {
+				return fmt.Errorf("part %d of %d: %w", i+1, len(parts), err)
+			}
+			return err
+		}
 	}
+	return nil
+}
 
-	var deepseekResp deepseekResponse
-	if err := json.NewDecoder(resp.Body).Decode(&deepseekResp); err != nil {
-		return nil, fmt.Errorf("failed to decode response: %w", err)
+// split returns the prompts to review, one per model call.
+func (s *aiService) split(prompt ReviewPrompt, task *domain.Task, criteria []*domain.TaskCriteria) []ReviewPrompt {
+	splittable, ok := prompt.(splittablePrompt)
+	if !ok {
+		return []ReviewPrompt{prompt}
 	}
 
-	if len(deepseekResp.Choices) == 0 {
-		return nil, fmt.Errorf("no response from AI")
+	parts := splittable.Split(task, criteria, s.maxPromptTokens)
+	if len(parts) == 0 {
+		return []ReviewPrompt{prompt}
 	}
+	return parts
+}
 
-	content := deepseekResp.Choices[0].Message.Content
-
-	content = strings.TrimSpace(content)
-	content = strings.TrimPrefix(content, "```json")
-	content = strings.TrimPrefix(content, "```")
-	content = strings.TrimSuffix(content, "```")
-	content = strings.TrimSpace(content)
-
-	var aiReview aiReviewResponse
-	if err := json.Unmarshal([]byte(content), &aiReview); err != nil {
-		return nil, fmt.Errorf("failed to parse AI response: %w", err)
+func (s *aiService) Review(ctx context.Context, prompt ReviewPrompt, task *domain.Task, criteria []*domain.TaskCriteria) (*CodeReviewResult, error) {
+	parts := s.split(prompt, task, criteria)
+	if len(parts) == 1 {
+		result, err := s.reviewPrompt(ctx, parts[0], task, criteria)
+		if err != nil {
+			return nil, err
+		}
+		return withOversizedFiles(result, parts), nil
 	}
 
-	executionTime := int(time.Since(startTime).Milliseconds())
+	s.logger.Info("Prompt exceeds token budget, reviewing in parts",
+		zap.String("kind", prompt.Kind()),
+		zap.Int("max_prompt_tokens", s.maxPromptTokens),
+		zap.Int("parts", len(parts)),
+	)
 
-	result := &CodeReviewResult{
-		OverallStatus:   aiReview.OverallStatus,
-		AIConfidence:    aiReview.Confidence,
-		ExecutionTimeMs: executionTime,
-		Feedbacks:       make([]FeedbackItem, 0, len(aiReview.Feedbacks)),
+	results := make([]*CodeReviewResult, 0, len(parts))
+	for i, part := range parts {
+		result, err := s.reviewPrompt(ctx, part, task, criteria)
+		if err != nil {
+			return nil, fmt.Errorf("failed to review part %d of %d: %w", i+1, len(parts), err)
+		}
+		results = append(results, result)
 	}
 
-	for _, fb := range aiReview.Feedbacks {
-		result.Feedbacks = append(result.Feedbacks, FeedbackItem{
-			FeedbackType: fb.Type,
-			FilePath:     fb.FilePath,
-			LineStart:    fb.LineStart,
-			LineEnd:      fb.LineEnd,
-			CodeSnippet:  fb.CodeSnippet,
-			SuggestedFix: fb.SuggestedFix,
-			Description:  fb.Description,
-			Severity:     fb.Severity,
-		})
-	}
+	merged := withOversizedFiles(mergeReviewResults(results, criteria), parts)
 
-	s.logger.Info("AI code review completed successfully",
-		zap.String("overall_status", result.OverallStatus),
-		zap.Float64("confidence", result.AIConfidence),
-		zap.Int("execution_time_ms", executionTime),
-		zap.Int("feedbacks_count", len(result.Feedbacks)),
+	s.logger.Info("Merged AI review parts",
+		zap.String("kind", prompt.Kind()),
+		zap.Int("parts", len(parts)),
+		zap.String("overall_status", merged.OverallStatus),
+		zap.Float64("confidence", merged.AIConfidence),
+		zap.Int("feedbacks_count", len(merged.Feedbacks)),
 	)
 
-	return result, nil
+	return merged, nil
 }
 
-func (s *aiService) buildPrompt(code *string, task *domain.Task, criteria []*domain.TaskCriteria) string {
-	criteriaSection := ""
-	if len(criteria) > 0 {
-		criteriaSection = "\n\nTask-specific criteria to check:\n"
-		for i, c := range criteria {
-			mandatory := "Optional"
-			if c.IsMandatory {
-				mandatory = "Mandatory"
-			}
-			criteriaSection += fmt.Sprintf("%d. [%s, Weight: %d] %s: %s\n",
-				i+1, mandatory, c.Weight, c.CriterionName, c.CriterionDescription)
+// withOversizedFiles tells the student about files that were too large to
+// be sent to the model, instead of reviewing the project without them
+// silently.
+func withOversizedFiles(result *CodeReviewResult, parts []ReviewPrompt) *CodeReviewResult {
+	for _, part := range parts {
+		for _, path := range oversizedFiles(part) {
+			result.Feedbacks = append(result.Feedbacks, FeedbackItem{
+				FeedbackType: "improvement",
+				FilePath:     path,
+				LineStart:    1,
+				LineEnd:      1,
+				Description:  "This file is too large for the AI review and was not reviewed. Consider splitting it into smaller files.",
+				Severity:     1,
+			})
 		}
 	}
-
-	taskDescription := ""
-	if task != nil {
-		taskDescription = fmt.Sprintf("\n\nTask description:\n%s\n", task.Description)
-	}
-
-	return fmt.Sprintf(`Analyze the following Flutter/Dart code and provide a detailed code review.
-%s%s
-Code to review:
-%s
-
-Provide your response in the following JSON format:
-{
-  "overall_status": "passed|failed|needs_improvement",
-  "confidence": 0.95,
-  "feedbacks": [
-    {
-      "type": "critical_error|logic_error|style_issue|performance|security_risk|improvement",
-      "line_start": 10,
-      "line_end": 15,
-      "code_snippet": "problematic code here",
-      "suggested_fix": "corrected code here",
-      "description": "detailed explanation of the issue",
-      "severity": 1-5
-    }
-  ]
-}
-
-Review criteria:
-1. **Critical Errors**: Syntax errors, null safety violations, type mismatches
-2. **Logic Errors**: Incorrect business logic, potential runtime errors
-3. **Style Issues**: Code formatting, naming conventions, Flutter best practices
-4. **Performance**: Inefficient algorithms, unnecessary rebuilds, memory leaks
-5. **Security**: Exposed sensitive data, insecure API calls
-6. **Improvements**: Better patterns, code organization, widget composition
-
-Severity levels:
-- 5: Critical (blocks functionality)
-- 4: Major (significant impact)
-- 3: Moderate (noticeable issue)
-- 2: Minor (cosmetic or style)
-- 1: Suggestion (optional improvement)
-
-Overall status:
-- "passed": Code is production-ready with minor or no issues
-- "needs_improvement": Code works but has moderate issues
-- "failed": Code has critical errors or major problems
-
-Provide confidence as a decimal between 0 and 1.
-
-IMPORTANT: Pay special attention to the task-specific criteria listed above. Check if the code meets these requirements and include them in your feedback if they are not satisfied.`, taskDescription, criteriaSection, *code)
+	return result
 }
 
-func (s *aiService) ReviewGitHubProject(ctx context.Context, files map[string]string, task *domain.Task, criteria []*domain.TaskCriteria) (*CodeReviewResult, error) {
+func (s *aiService) reviewPrompt(ctx context.Context, prompt ReviewPrompt, task *domain.Task, criteria []*domain.TaskCriteria) (*CodeReviewResult, error) {
 	startTime := time.Now()
 
-	s.logger.Info("Starting AI GitHub project review",
-		zap.Int("files_count", len(files)),
-		zap.Int("criteria_count", len(criteria)),
+	s.logger.Info("Starting AI review",
+		append([]zap.Field{
+			zap.String("kind", prompt.Kind()),
+			zap.Int("criteria_count", len(criteria)),
+		}, prompt.LogFields()...)...,
 	)
 
-	prompt := s.buildGitHubProjectPrompt(files, task, criteria)
-
-	reqBody := deepseekRequest{
-		Model: "deepseek-chat",
-		Messages: []message{
-			{
-				Role:    "system",
-				Content: "You are an expert Flutter/Dart code reviewer. Analyze Flutter/Dart projects and provide structured feedback in JSON format.",
-			},
-			{
-				Role:    "user",
-				Content: prompt,
-			},
-		},
-		Stream: false,
-	}
-
-	jsonData, err := json.Marshal(reqBody)
-	if err != nil {
-		return nil, fmt.Errorf("failed to marshal request: %w", err)
-	}
-
-	s.logger.Info("Sending request to AI API for GitHub project review",
-		zap.String("url", s.apiURL),
-		zap.String("model", "deepseek-chat"),
+	s.logger.Info("Sending request to AI API",
+		zap.String("kind", prompt.Kind()),
+		zap.String("provider", s.provider.Name()),
+		zap.String("model", s.provider.Model()),
 	)
 
-	req, err := http.NewRequestWithContext(ctx, "POST", s.apiURL, bytes.NewBuffer(jsonData))
-	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
-	}
-
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Authorization", "Bearer "+s.apiKey)
-
-	resp, err := s.client.Do(req)
+	systemPrompt, userPrompt := prompt.SystemPrompt(), prompt.UserPrompt(task, criteria)
+	completion, usage, err := s.complete(ctx, systemPrompt, userPrompt)
 	if err != nil {
-		return nil, fmt.Errorf("failed to send request: %w", err)
+		return nil, err
 	}
-	defer resp.Body.Close()
 
 	s.logger.Info("Received response from AI API",
-		zap.Int("status_code", resp.StatusCode),
+		zap.String("kind", prompt.Kind()),
+		zap.Int("prompt_tokens", usage.PromptTokens),
+		zap.Int("completion_tokens", usage.CompletionTokens),
 	)
 
-	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("API request failed with status %d: %s", resp.StatusCode, string(body))
-	}
+	aiReview, notes, err := parseReviewResponse(completion.Content)
+	if err != nil && s.repairEnabled {
+		s.logger.Warn("AI response violates review schema, requesting repair",
+			zap.String("kind", prompt.Kind()),
+			zap.Error(err),
+		)
+
+		repairPrompt := buildRepairPrompt(completion.Content, err, prompt.ResponseFormat(criteria))
+		var repairUsage TokenUsage
+		completion, repairUsage, err = s.complete(ctx, systemPrompt, repairPrompt)
+		if err != nil {
+			return nil, fmt.Errorf("failed to repair AI response: %w", err)
+		}
+		usage = usage.Add(repairUsage)
 
-	var deepseekResp deepseekResponse
-	if err := json.NewDecoder(resp.Body).Decode(&deepseekResp); err != nil {
-		return nil, fmt.Errorf("failed to decode response: %w", err)
+		aiReview, notes, err = parseReviewResponse(completion.Content)
 	}
-
-	if len(deepseekResp.Choices) == 0 {
-		return nil, fmt.Errorf("no response from AI")
+	if err != nil {
+		return nil, err
 	}
 
-	content := deepseekResp.Choices[0].Message.Content
-
-	content = strings.TrimSpace(content)
-	content = strings.TrimPrefix(content, "```json")
-	content = strings.TrimPrefix(content, "```")
-	content = strings.TrimSuffix(content, "```")
-	content = strings.TrimSpace(content)
-
-	var aiReview aiReviewResponse
-	if err := json.Unmarshal([]byte(content), &aiReview); err != nil {
-		return nil, fmt.Errorf("failed to parse AI response: %w", err)
+	if len(notes) > 0 {
+		s.logger.Warn("AI response normalised",
+			zap.String("kind", prompt.Kind()),
+			zap.Strings("corrections", notes),
+		)
 	}
 
-	executionTime := int(time.Since(startTime).Milliseconds())
+	executionTime := max(int(time.Since(startTime).Milliseconds()), 1)
 
 	result := &CodeReviewResult{
+		AIModel:         s.provider.Model(),
 		OverallStatus:   aiReview.OverallStatus,
-		AIConfidence:    aiReview.Confidence,
+		AIConfidence:    float64(aiReview.Confidence),
 		ExecutionTimeMs: executionTime,
 		Feedbacks:       make([]FeedbackItem, 0, len(aiReview.Feedbacks)),
+
+		PromptTokens:     usage.PromptTokens,
+		CompletionTokens: usage.CompletionTokens,
+		EstimatedCost:    s.estimateCost(usage),
 	}
 
 	for _, fb := range aiReview.Feedbacks {
 		result.Feedbacks = append(result.Feedbacks, FeedbackItem{
 			FeedbackType: fb.Type,
 			FilePath:     fb.FilePath,
-			LineStart:    fb.LineStart,
-			LineEnd:      fb.LineEnd,
+			LineStart:    int(fb.LineStart),
+			LineEnd:      int(fb.LineEnd),
 			CodeSnippet:  fb.CodeSnippet,
 			SuggestedFix: fb.SuggestedFix,
 			Description:  fb.Description,
-			Severity:     fb.Severity,
+			Severity:     int(fb.Severity),
 		})
 	}
 
-	s.logger.Info("AI GitHub project review completed successfully",
+	for _, id := range aiReview.ResolvedFeedbackIDs {
+		if id > 0 {
+			result.ResolvedFeedbackIDs = append(result.ResolvedFeedbackIDs, int(id))
+		}
+	}
+
+	var missing []int
+	result.Criteria, missing = matchCriteria(aiReview.Criteria, criteria)
+	if len(missing) > 0 {
+		s.logger.Warn("AI response has no verdict for some criteria",
+			zap.String("kind", prompt.Kind()),
+			zap.Ints("criterion_ids", missing),
+		)
+	}
+
+	s.logger.Info("AI review completed successfully",
+		zap.String("kind", prompt.Kind()),
 		zap.String("overall_status", result.OverallStatus),
 		zap.Float64("confidence", result.AIConfidence),
 		zap.Int("execution_time_ms", executionTime),
@@ -381,78 +324,86 @@ func (s *aiService) ReviewGitHubProject(ctx context.Context, files map[string]st
 	return result, nil
 }
 
-func (s *aiService) buildGitHubProjectPrompt(files map[string]string, task *domain.Task, criteria []*domain.TaskCriteria) string {
-	var filesContent strings.Builder
-	filesContent.WriteString("Flutter/Dart project files:\n\n")
+// ModelCall is the usage of one request to the model.
+type ModelCall struct {
+	Model         string
+	Usage         TokenUsage
+	EstimatedCost float64
+}
 
-	for filePath, content := range files {
-		filesContent.WriteString(fmt.Sprintf("=== File: %s ===\n", filePath))
-		filesContent.WriteString(content)
-		filesContent.WriteString("\n\n")
-	}
+type modelCallRecorderKey struct{}
 
-	criteriaSection := ""
-	if len(criteria) > 0 {
-		criteriaSection = "\n\nTask-specific criteria to check:\n"
-		for i, c := range criteria {
-			mandatory := "Optional"
-			if c.IsMandatory {
-				mandatory = "Mandatory"
-			}
-			criteriaSection += fmt.Sprintf("%d. [%s, Weight: %d] %s: %s\n",
-				i+1, mandatory, c.Weight, c.CriterionName, c.CriterionDescription)
-		}
+// WithModelCallRecorder makes reviews run with the returned context report
+// every model call to record as soon as it returns, so the calls of a review
+// that fails later are accounted for too.
+func WithModelCallRecorder(ctx context.Context, record func(ModelCall)) context.Context {
+	return context.WithValue(ctx, modelCallRecorderKey{}, record)
+}
+
+// complete sends one request to the model and reports its usage to the
+// recorder of ctx, if there is one.
+func (s *aiService) complete(ctx context.Context, systemPrompt, userPrompt string) (*Completion, TokenUsage, error) {
+	completion, err := s.provider.Complete(ctx, systemPrompt, userPrompt)
+	if err != nil {
+		return nil, TokenUsage{}, err
 	}
 
-	taskDescription := ""
-	if task != nil {
-		taskDescription = fmt.Sprintf("\n\nTask description:\n%s\n", task.Description)
+	usage := completionUsage(completion, systemPrompt, userPrompt)
+	if record, ok := ctx.Value(modelCallRecorderKey{}).(func(ModelCall)); ok {
+		record(ModelCall{Model: s.provider.Model(), Usage: usage, EstimatedCost: s.estimateCost(usage)})
 	}
+	return completion, usage, nil
+}
 
-	return fmt.Sprintf(`Analyze the following Flutter/Dart project and provide a detailed code review.
-%s%s
-%s
-
-Provide your response in the following JSON format:
-{
-  "overall_status": "passed|failed|needs_improvement",
-  "confidence": 0.95,
-  "feedbacks": [
-    {
-      "type": "critical_error|logic_error|style_issue|performance|security_risk|improvement",
-      "file_path": "lib/main.dart",
-      "line_start": 10,
-      "line_end": 15,
-      "code_snippet": "problematic code here",
-      "suggested_fix": "corrected code here",
-      "description": "detailed explanation of the issue",
-      "severity": 1-5
-    }
-  ]
+// estimateCost prices the usage with the configured per-million rates.
+func (s *aiService) estimateCost(usage TokenUsage) float64 {
+	return (float64(usage.PromptTokens)*s.inputPricePerMTok +
+		float64(usage.CompletionTokens)*s.outputPricePerMTok) / 1e6
 }
 
-Review criteria:
-1. **Critical Errors**: Syntax errors, null safety violations, type mismatches
-2. **Logic Errors**: Incorrect business logic, potential runtime errors
-3. **Style Issues**: Code formatting, naming conventions, Flutter best practices
-4. **Performance**: Inefficient algorithms, unnecessary rebuilds, memory leaks
-5. **Security**: Exposed sensitive data, insecure API calls
-6. **Improvements**: Better patterns, code organization, widget composition
-7. **Project Structure**: Proper file organization, separation of concerns
-
-Severity levels:
-- 5: Critical (blocks functionality)
-- 4: Major (significant impact)
-- 3: Moderate (noticeable issue)
-- 2: Minor (cosmetic or style)
-- 1: Suggestion (optional improvement)
-
-Overall status:
-- "passed": Code is production-ready with minor or no issues
-- "needs_improvement": Code works but has moderate issues
-- "failed": Code has critical errors or major problems
-
-Provide confidence as a decimal between 0 and 1.
-IMPORTANT: Always include "file_path" field in each feedback item to indicate which file the issue is in.
-IMPORTANT: Pay special attention to the task-specific criteria listed above. Check if the project meets these requirements and include them in your feedback if they are not satisfied.`, taskDescription, criteriaSection, filesContent.String())
+// completionUsage returns the token counts the API reported, estimating
+// them from the text for APIs that report none.
+func completionUsage(completion *Completion, systemPrompt, userPrompt string) TokenUsage {
+	if completion.Usage.PromptTokens > 0 || completion.Usage.CompletionTokens > 0 {
+		return completion.Usage
+	}
+	return TokenUsage{
+		PromptTokens:     estimateTokens(systemPrompt) + estimateTokens(userPrompt),
+		CompletionTokens: estimateTokens(completion.Content),
+	}
+}
+
+// matchCriteria keeps one verdict per task criterion in task order. Verdicts
+// for unknown criteria are dropped and criteria the model skipped are
+// reported as not met, so the score never rewards a missing answer.
+func matchCriteria(verdicts []criterionJSON, criteria []*domain.TaskCriteria) ([]CriterionVerdictItem, []int) {
+	byID := make(map[int]criterionJSON, len(verdicts))
+	for _, v := range verdicts {
+		if _, ok := byID[int(v.CriterionID)]; !ok {
+			byID[int(v.CriterionID)] = v
+		}
+	}
+
+	items := make([]CriterionVerdictItem, 0, len(criteria))
+	var missing []int
+	for _, c := range criteria {
+		v, ok := byID[c.ID]
+		if !ok {
+			missing = append(missing, c.ID)
+			items = append(items, CriterionVerdictItem{
+				CriterionID: c.ID,
+				Verdict:     domain.VerdictNotMet,
+				Evidence:    "No verdict returned by the reviewer",
+			})
+			continue
+		}
+
+		items = append(items, CriterionVerdictItem{
+			CriterionID: c.ID,
+			Verdict:     domain.CriterionVerdict(v.Verdict),
+			Evidence:    v.Evidence,
+		})
+	}
+
+	return items, missing
 }
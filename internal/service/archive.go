@@ -0,0 +1,217 @@
+package service
+
+import (
+	"archive/zip"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"go.uber.org/zap"
+)
+
+var ErrInvalidArchive = errors.New("invalid zip archive")
+
+// ignoredArchiveEntries are added by archivers and never part of a project.
+var ignoredArchiveEntries = map[string]bool{
+	"__MACOSX":  true,
+	".DS_Store": true,
+	"Thumbs.db": true,
+}
+
+// ValidateArchive checks that content is a readable zip archive within the
+// configured entry and size limits without extracting it.
+func ValidateArchive(content []byte, maxEntries int, maxUncompressed int64) error {
+	reader, err := zip.NewReader(bytes.NewReader(content), int64(len(content)))
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidArchive, err)
+	}
+
+	if maxEntries > 0 && len(reader.File) > maxEntries {
+		return fmt.Errorf("%w: more than %d entries", ErrInvalidArchive, maxEntries)
+	}
+
+	var total uint64
+	for _, f := range reader.File {
+		if _, err := archiveEntryPath(f.Name); err != nil {
+			return err
+		}
+		total += f.UncompressedSize64
+	}
+	if maxUncompressed > 0 && total > uint64(maxUncompressed) {
+		return fmt.Errorf("%w: uncompressed size exceeds %d MB", ErrInvalidArchive, maxUncompressed>>20)
+	}
+
+	return nil
+}
+
+// ExtractArchive unpacks a zip archive into a new workspace and returns the
+// project root: the single top-level directory when the archive wraps the
+// project in one, the workspace itself otherwise. Sizes are enforced on the
+// bytes actually written, not on the sizes the archive declares.
+func (s *githubService) ExtractArchive(content []byte, name string) (string, string, error) {
+	reader, err := zip.NewReader(bytes.NewReader(content), int64(len(content)))
+	if err != nil {
+		return "", "", fmt.Errorf("%w: %v", ErrInvalidArchive, err)
+	}
+
+	if s.archive.MaxEntries > 0 && len(reader.File) > s.archive.MaxEntries {
+		return "", "", fmt.Errorf("%w: more than %d entries", ErrInvalidArchive, s.archive.MaxEntries)
+	}
+
+	workspace, err := s.newWorkspace(archiveWorkspaceName(name), int64(s.archive.MaxUncompressedMB)<<20)
+	if err != nil {
+		return "", "", err
+	}
+
+	err = s.extractEntries(reader, workspace)
+	s.releaseWorkspace(workspace)
+	if err != nil {
+		os.RemoveAll(workspace)
+		return "", "", err
+	}
+
+	root, err := archiveProjectRoot(workspace)
+	if err != nil {
+		os.RemoveAll(workspace)
+		return "", "", err
+	}
+
+	s.logger.Info("Archive extracted",
+		zap.String("archive", name),
+		zap.Int("entries", len(reader.File)),
+		zap.String("path", root),
+	)
+
+	return workspace, root, nil
+}
+
+func (s *githubService) extractEntries(reader *zip.Reader, dest string) error {
+	remaining := int64(math.MaxInt64)
+	if s.archive.MaxUncompressedMB > 0 {
+		remaining = int64(s.archive.MaxUncompressedMB) << 20
+	}
+
+	for _, f := range reader.File {
+		rel, err := archiveEntryPath(f.Name)
+		if err != nil {
+			return err
+		}
+		if rel == "" || isIgnoredArchiveEntry(rel) {
+			continue
+		}
+
+		target := filepath.Join(dest, filepath.FromSlash(rel))
+
+		mode := f.Mode()
+		switch {
+		case mode.IsDir():
+			if err := os.MkdirAll(target, 0700); err != nil {
+				return fmt.Errorf("failed to create directory: %w", err)
+			}
+			continue
+		case !mode.IsRegular():
+			// Symlinks and devices could point outside the workspace.
+			continue
+		}
+
+		if err := os.MkdirAll(filepath.Dir(target), 0700); err != nil {
+			return fmt.Errorf("failed to create directory: %w", err)
+		}
+
+		written, err := s.extractFile(f, target, remaining)
+		if err != nil {
+			return err
+		}
+		remaining -= written
+	}
+
+	return nil
+}
+
+func (s *githubService) extractFile(f *zip.File, target string, remaining int64) (int64, error) {
+	// The per-entry cap follows from the compression ratio; the archive-wide
+	// cap from the uncompressed size limit. Either one stops a zip bomb.
+	limit := remaining
+	if ratio := int64(s.archive.MaxCompressionRatio); ratio > 0 {
+		limit = min(limit, max(int64(f.CompressedSize64), 1)*ratio)
+	}
+
+	src, err := f.Open()
+	if err != nil {
+		return 0, fmt.Errorf("%w: %v", ErrInvalidArchive, err)
+	}
+	defer src.Close()
+
+	dst, err := os.OpenFile(target, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0600)
+	if err != nil {
+		return 0, fmt.Errorf("failed to create %s: %w", f.Name, err)
+	}
+	defer dst.Close()
+
+	written, err := io.Copy(dst, io.LimitReader(src, limit+1))
+	if err != nil {
+		return written, fmt.Errorf("%w: %v", ErrInvalidArchive, err)
+	}
+	if written > limit {
+		return written, fmt.Errorf("%w: %s expands beyond the size limit", ErrInvalidArchive, f.Name)
+	}
+
+	return written, nil
+}
+
+// archiveEntryPath cleans an entry name and rejects names that would land
+// outside the extraction directory (zip-slip).
+func archiveEntryPath(name string) (string, error) {
+	name = strings.ReplaceAll(name, "\\", "/")
+	if strings.HasPrefix(name, "/") || (len(name) > 1 && name[1] == ':') {
+		return "", fmt.Errorf("%w: absolute path %q", ErrInvalidArchive, name)
+	}
+
+	clean := path.Clean(name)
+	if clean == ".." || strings.HasPrefix(clean, "../") {
+		return "", fmt.Errorf("%w: path %q escapes the archive", ErrInvalidArchive, name)
+	}
+	if clean == "." {
+		return "", nil
+	}
+	return clean, nil
+}
+
+func isIgnoredArchiveEntry(rel string) bool {
+	for _, part := range strings.Split(rel, "/") {
+		if ignoredArchiveEntries[part] {
+			return true
+		}
+	}
+	return false
+}
+
+// archiveProjectRoot descends into the top-level directory when it is the
+// only entry, as archivers do when zipping a project folder.
+func archiveProjectRoot(workspace string) (string, error) {
+	entries, err := os.ReadDir(workspace)
+	if err != nil {
+		return "", fmt.Errorf("failed to read extracted archive: %w", err)
+	}
+	if len(entries) == 0 {
+		return "", fmt.Errorf("%w: archive is empty", ErrInvalidArchive)
+	}
+	if len(entries) == 1 && entries[0].IsDir() {
+		return filepath.Join(workspace, entries[0].Name()), nil
+	}
+	return workspace, nil
+}
+
+func archiveWorkspaceName(name string) string {
+	base := strings.TrimSuffix(path.Base(strings.ReplaceAll(name, "\\", "/")), ".zip")
+	if !repositorySegmentPattern.MatchString(base) {
+		return "archive"
+	}
+	return base
+}
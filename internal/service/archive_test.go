@@ -0,0 +1,237 @@
+package service
+
+import (
+	"archive/zip"
+	"bytes"
+	"compress/flate"
+	"errors"
+	"hash/crc32"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ilyin-ad/flutter-code-mentor/internal/config"
+)
+
+// zipEntry is one entry of a test archive. Raw entries are written with
+// their declared size, whatever the data expands to.
+type zipEntry struct {
+	name     string
+	content  string
+	mode     os.FileMode
+	method   uint16
+	declared uint64
+}
+
+func buildZip(t *testing.T, entries ...zipEntry) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+	w := zip.NewWriter(&buf)
+	for _, e := range entries {
+		header := &zip.FileHeader{Name: e.name, Method: e.method}
+		if e.mode != 0 {
+			header.SetMode(e.mode)
+		}
+
+		if e.declared > 0 {
+			var compressed bytes.Buffer
+			fw, err := flate.NewWriter(&compressed, flate.BestCompression)
+			if err != nil {
+				t.Fatal(err)
+			}
+			fw.Write([]byte(e.content))
+			fw.Close()
+
+			header.Method = zip.Deflate
+			header.CRC32 = crc32.ChecksumIEEE([]byte(e.content))
+			header.CompressedSize64 = uint64(compressed.Len())
+			header.UncompressedSize64 = e.declared
+			raw, err := w.CreateRaw(header)
+			if err != nil {
+				t.Fatal(err)
+			}
+			raw.Write(compressed.Bytes())
+			continue
+		}
+
+		f, err := w.CreateHeader(header)
+		if err != nil {
+			t.Fatal(err)
+		}
+		f.Write([]byte(e.content))
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func newTestArchiveService(t *testing.T) *githubService {
+	t.Helper()
+
+	s := newTestGitHubService(t)
+	s.archive = config.ArchiveConfig{
+		MaxUncompressedMB:   1,
+		MaxEntries:          10,
+		MaxCompressionRatio: 100,
+	}
+	return s
+}
+
+func TestExtractArchive(t *testing.T) {
+	s := newTestArchiveService(t)
+
+	content := buildZip(t,
+		zipEntry{name: "app/", mode: os.ModeDir | 0o755},
+		zipEntry{name: "app/lib/main.dart", content: "void main() {}", method: zip.Deflate},
+		zipEntry{name: "app/pubspec.yaml", content: "name: app"},
+		zipEntry{name: "__MACOSX/app/._main.dart", content: "junk"},
+	)
+
+	workspace, root, err := s.ExtractArchive(content, "app.zip")
+	if err != nil {
+		t.Fatalf("ExtractArchive: %v", err)
+	}
+	defer s.Cleanup(workspace)
+
+	if root != filepath.Join(workspace, "app") {
+		t.Errorf("root = %s, want the single top-level directory", root)
+	}
+	got, err := os.ReadFile(filepath.Join(root, "lib", "main.dart"))
+	if err != nil || string(got) != "void main() {}" {
+		t.Errorf("lib/main.dart = %q, %v", got, err)
+	}
+	if _, err := os.Stat(filepath.Join(workspace, "__MACOSX")); !os.IsNotExist(err) {
+		t.Error("archiver metadata was extracted")
+	}
+}
+
+func TestExtractArchiveSkipsSymlinks(t *testing.T) {
+	s := newTestArchiveService(t)
+	outside := t.TempDir()
+
+	content := buildZip(t,
+		zipEntry{name: "lib/link", content: outside, mode: os.ModeSymlink | 0o777},
+		zipEntry{name: "lib/link/evil.dart", content: "// written through the link"},
+		zipEntry{name: "lib/main.dart", content: "void main() {}"},
+	)
+
+	workspace, _, err := s.ExtractArchive(content, "app.zip")
+	if err != nil {
+		t.Fatalf("ExtractArchive: %v", err)
+	}
+	defer s.Cleanup(workspace)
+
+	info, err := os.Lstat(filepath.Join(workspace, "lib", "link"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode()&os.ModeSymlink != 0 {
+		t.Error("symlink entry was extracted")
+	}
+	if _, err := os.Stat(filepath.Join(outside, "evil.dart")); !os.IsNotExist(err) {
+		t.Error("a file was written outside the workspace")
+	}
+}
+
+func TestExtractArchiveRejects(t *testing.T) {
+	// Within the size limit, but far beyond the compression ratio.
+	bomb := strings.Repeat("\x00", 800<<10)
+	zeros := strings.Repeat("\x00", 5<<20)
+	half := strings.Repeat("a", 600<<10)
+
+	tests := []struct {
+		name    string
+		entries []zipEntry
+		noRatio bool
+	}{
+		{name: "parent directory", entries: []zipEntry{{name: "../evil.dart", content: "x"}}},
+		{name: "parent directory inside the path", entries: []zipEntry{{name: "lib/../../evil.dart", content: "x"}}},
+		{name: "backslash parent directory", entries: []zipEntry{{name: "..\\evil.dart", content: "x"}}},
+		{name: "absolute path", entries: []zipEntry{{name: "/tmp/evil.dart", content: "x"}}},
+		{name: "drive letter", entries: []zipEntry{{name: "C:\\evil.dart", content: "x"}}},
+		{name: "too many entries", entries: func() []zipEntry {
+			var entries []zipEntry
+			for i := 0; i < 11; i++ {
+				entries = append(entries, zipEntry{name: "lib/" + strings.Repeat("f", i+1) + ".dart"})
+			}
+			return entries
+		}()},
+		{name: "entry over the size limit", entries: []zipEntry{{name: "big.dart", content: half + half}}},
+		{name: "entries together over the size limit", entries: []zipEntry{
+			{name: "a.dart", content: half},
+			{name: "b.dart", content: half},
+		}},
+		{name: "compression bomb", entries: []zipEntry{{name: "bomb.dart", content: bomb, method: zip.Deflate}}},
+		{
+			name:    "size understated in the header",
+			entries: []zipEntry{{name: "liar.dart", content: zeros, declared: 10}},
+			noRatio: true,
+		},
+		{name: "duplicate entry", entries: []zipEntry{
+			{name: "lib/main.dart", content: "void main() {}"},
+			{name: "lib/main.dart", content: "// overwritten"},
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newTestArchiveService(t)
+			if tt.noRatio {
+				s.archive.MaxCompressionRatio = 0
+			}
+
+			workspace, _, err := s.ExtractArchive(buildZip(t, tt.entries...), "app.zip")
+			if err == nil {
+				s.Cleanup(workspace)
+				t.Fatal("ExtractArchive accepted the archive")
+			}
+			if tt.name != "duplicate entry" && !errors.Is(err, ErrInvalidArchive) {
+				t.Errorf("ExtractArchive error = %v, want ErrInvalidArchive", err)
+			}
+
+			entries, err := os.ReadDir(s.tempDir)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(entries) != 0 {
+				t.Errorf("rejected archive left %d workspace(s) behind", len(entries))
+			}
+			if len(s.reserved) != 0 {
+				t.Errorf("rejected archive left %d reservation(s)", len(s.reserved))
+			}
+		})
+	}
+}
+
+func TestValidateArchive(t *testing.T) {
+	half := strings.Repeat("a", 600<<10)
+
+	tests := []struct {
+		name    string
+		content []byte
+		wantErr bool
+	}{
+		{name: "valid", content: buildZip(t, zipEntry{name: "lib/main.dart", content: "void main() {}"})},
+		{name: "not a zip", content: []byte("PK but not really"), wantErr: true},
+		{name: "zip-slip", content: buildZip(t, zipEntry{name: "../evil.dart", content: "x"}), wantErr: true},
+		{name: "declared size over the limit", content: buildZip(t,
+			zipEntry{name: "a.dart", content: half},
+			zipEntry{name: "b.dart", content: half},
+		), wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateArchive(tt.content, 10, 1<<20)
+			if tt.wantErr != (err != nil) {
+				t.Fatalf("ValidateArchive() error = %v, want error: %t", err, tt.wantErr)
+			}
+			if err != nil && !errors.Is(err, ErrInvalidArchive) {
+				t.Errorf("ValidateArchive() error = %v, want ErrInvalidArchive", err)
+			}
+		})
+	}
+}
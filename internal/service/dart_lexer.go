@@ -0,0 +1,244 @@
+package service
+
+import (
+	"strings"
+	"unicode"
+)
+
+type dartTokenKind int
+
+const (
+	dartIdent dartTokenKind = iota
+	dartNumber
+	dartString
+	dartPunct
+	dartComment
+)
+
+// dartToken is a lexical token of Dart source. Keywords are identifiers;
+// string tokens cover adjacent quotes, prefixes and interpolations.
+type dartToken struct {
+	kind dartTokenKind
+	text string
+	line int
+
+	// interpolated is set for strings containing $name or ${...}.
+	interpolated bool
+}
+
+// dartMultiCharPuncts are the operators the lint rules need as one token.
+// Longer ones come first.
+var dartMultiCharPuncts = []string{"...", "?..", "?.", "..", "=>"}
+
+// tokenizeDart splits Dart source into tokens. It never fails: malformed
+// input such as an unterminated string ends the token at the end of input,
+// which is all the lint rules need from code that may not compile.
+func tokenizeDart(src string) []dartToken {
+	l := &dartLexer{src: src, line: 1}
+	var tokens []dartToken
+	for {
+		t, ok := l.next()
+		if !ok {
+			return tokens
+		}
+		tokens = append(tokens, t)
+	}
+}
+
+type dartLexer struct {
+	src  string
+	pos  int
+	line int
+}
+
+func (l *dartLexer) next() (dartToken, bool) {
+	l.skipSpace()
+	if l.pos >= len(l.src) {
+		return dartToken{}, false
+	}
+
+	start, line := l.pos, l.line
+	c := l.src[l.pos]
+
+	switch {
+	case strings.HasPrefix(l.src[l.pos:], "//"):
+		for l.pos < len(l.src) && l.src[l.pos] != '\n' {
+			l.pos++
+		}
+		return dartToken{kind: dartComment, text: l.src[start:l.pos], line: line}, true
+
+	case strings.HasPrefix(l.src[l.pos:], "/*"):
+		l.skipBlockComment()
+		return dartToken{kind: dartComment, text: l.src[start:l.pos], line: line}, true
+
+	case c == '\'' || c == '"' || ((c == 'r' || c == 'R') && l.pos+1 < len(l.src) && (l.src[l.pos+1] == '\'' || l.src[l.pos+1] == '"')):
+		interpolated := l.skipString()
+		return dartToken{kind: dartString, text: l.src[start:l.pos], line: line, interpolated: interpolated}, true
+
+	case isDartIdentStart(c):
+		for l.pos < len(l.src) && isDartIdentPart(l.src[l.pos]) {
+			l.pos++
+		}
+		return dartToken{kind: dartIdent, text: l.src[start:l.pos], line: line}, true
+
+	case c >= '0' && c <= '9' || (c == '.' && l.pos+1 < len(l.src) && l.src[l.pos+1] >= '0' && l.src[l.pos+1] <= '9'):
+		l.skipNumber()
+		return dartToken{kind: dartNumber, text: l.src[start:l.pos], line: line}, true
+	}
+
+	for _, p := range dartMultiCharPuncts {
+		if strings.HasPrefix(l.src[l.pos:], p) {
+			l.pos += len(p)
+			return dartToken{kind: dartPunct, text: p, line: line}, true
+		}
+	}
+
+	l.pos++
+	return dartToken{kind: dartPunct, text: l.src[start:l.pos], line: line}, true
+}
+
+func (l *dartLexer) skipSpace() {
+	for l.pos < len(l.src) {
+		switch l.src[l.pos] {
+		case '\n':
+			l.line++
+		case ' ', '\t', '\r', '\f':
+		default:
+			return
+		}
+		l.pos++
+	}
+}
+
+// skipBlockComment skips a /* */ comment; Dart block comments nest.
+func (l *dartLexer) skipBlockComment() {
+	depth := 0
+	for l.pos < len(l.src) {
+		switch {
+		case strings.HasPrefix(l.src[l.pos:], "/*"):
+			depth++
+			l.pos += 2
+		case strings.HasPrefix(l.src[l.pos:], "*/"):
+			depth--
+			l.pos += 2
+			if depth == 0 {
+				return
+			}
+		default:
+			l.advance()
+		}
+	}
+}
+
+// skipString skips one string literal, including raw and triple-quoted
+// ones, and reports whether it contains interpolation.
+func (l *dartLexer) skipString() bool {
+	raw := false
+	if l.src[l.pos] == 'r' || l.src[l.pos] == 'R' {
+		raw = true
+		l.pos++
+	}
+
+	quote := l.src[l.pos : l.pos+1]
+	if strings.HasPrefix(l.src[l.pos:], quote+quote+quote) {
+		quote = quote + quote + quote
+	}
+	l.pos += len(quote)
+	multiline := len(quote) == 3
+
+	interpolated := false
+	for l.pos < len(l.src) {
+		switch {
+		case strings.HasPrefix(l.src[l.pos:], quote):
+			l.pos += len(quote)
+			return interpolated
+		case l.src[l.pos] == '\n' && !multiline:
+			// Unterminated single-line string.
+			return interpolated
+		case l.src[l.pos] == '\\' && !raw:
+			l.pos++
+			if l.pos < len(l.src) {
+				l.advance()
+			}
+		case l.src[l.pos] == '$' && !raw:
+			l.pos++
+			if l.pos < len(l.src) && l.src[l.pos] == '{' {
+				interpolated = true
+				l.pos++
+				l.skipInterpolation()
+			} else if l.pos < len(l.src) && isDartIdentStart(l.src[l.pos]) {
+				interpolated = true
+			}
+		default:
+			l.advance()
+		}
+	}
+	return interpolated
+}
+
+// skipInterpolation skips the expression of ${...} up to its closing brace.
+// The expression may contain braces and strings of its own.
+func (l *dartLexer) skipInterpolation() {
+	depth := 1
+	for l.pos < len(l.src) {
+		c := l.src[l.pos]
+		switch {
+		case c == '{':
+			depth++
+		case c == '}':
+			depth--
+			if depth == 0 {
+				l.pos++
+				return
+			}
+		case c == '\'' || c == '"':
+			l.skipString()
+			continue
+		}
+		l.advance()
+	}
+}
+
+func (l *dartLexer) skipNumber() {
+	if strings.HasPrefix(l.src[l.pos:], "0x") || strings.HasPrefix(l.src[l.pos:], "0X") {
+		l.pos += 2
+		for l.pos < len(l.src) && (isHexDigit(l.src[l.pos]) || l.src[l.pos] == '_') {
+			l.pos++
+		}
+		return
+	}
+
+	for l.pos < len(l.src) {
+		c := l.src[l.pos]
+		switch {
+		case c >= '0' && c <= '9', c == '_':
+		case c == '.' && l.pos+1 < len(l.src) && l.src[l.pos+1] >= '0' && l.src[l.pos+1] <= '9':
+		case (c == 'e' || c == 'E') && l.pos+1 < len(l.src):
+			if n := l.src[l.pos+1]; n == '+' || n == '-' {
+				l.pos++
+			}
+		default:
+			return
+		}
+		l.pos++
+	}
+}
+
+func (l *dartLexer) advance() {
+	if l.src[l.pos] == '\n' {
+		l.line++
+	}
+	l.pos++
+}
+
+func isDartIdentStart(c byte) bool {
+	return c == '_' || c == '$' || c >= 0x80 || unicode.IsLetter(rune(c))
+}
+
+func isDartIdentPart(c byte) bool {
+	return isDartIdentStart(c) || (c >= '0' && c <= '9')
+}
+
+func isHexDigit(c byte) bool {
+	return (c >= '0' && c <= '9') || (c >= 'a' && c <= 'f') || (c >= 'A' && c <= 'F')
+}
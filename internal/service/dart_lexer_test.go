@@ -0,0 +1,117 @@
+package service
+
+import (
+	"testing"
+)
+
+func TestTokenizeDart(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+		want []dartToken
+	}{
+		{
+			name: "identifiers, numbers and operators",
+			src:  "a?.b..c(1.5e-3, 0xFF, .5) => x;",
+			want: []dartToken{
+				{kind: dartIdent, text: "a"}, {kind: dartPunct, text: "?."}, {kind: dartIdent, text: "b"},
+				{kind: dartPunct, text: ".."}, {kind: dartIdent, text: "c"}, {kind: dartPunct, text: "("},
+				{kind: dartNumber, text: "1.5e-3"}, {kind: dartPunct, text: ","}, {kind: dartNumber, text: "0xFF"},
+				{kind: dartPunct, text: ","}, {kind: dartNumber, text: ".5"}, {kind: dartPunct, text: ")"},
+				{kind: dartPunct, text: "=>"}, {kind: dartIdent, text: "x"}, {kind: dartPunct, text: ";"},
+			},
+		},
+		{
+			name: "raw string does not interpolate or escape",
+			src:  `r'$name \' x`,
+			want: []dartToken{
+				{kind: dartString, text: `r'$name \'`},
+				{kind: dartIdent, text: "x"},
+			},
+		},
+		{
+			name: "escaped dollar is not interpolation",
+			src:  `'costs \$5'`,
+			want: []dartToken{{kind: dartString, text: `'costs \$5'`}},
+		},
+		{
+			name: "simple interpolation",
+			src:  `"Hello $name"`,
+			want: []dartToken{{kind: dartString, text: `"Hello $name"`, interpolated: true}},
+		},
+		{
+			name: "interpolation with nested braces and quotes",
+			src:  `'${map['key'] ?? {'a': 1}} done' x`,
+			want: []dartToken{
+				{kind: dartString, text: `'${map['key'] ?? {'a': 1}} done'`, interpolated: true},
+				{kind: dartIdent, text: "x"},
+			},
+		},
+		{
+			name: "triple-quoted string spans lines and holds quotes",
+			src:  "'''it's \"quoted\"\n'' still'''\nx",
+			want: []dartToken{
+				{kind: dartString, text: "'''it's \"quoted\"\n'' still'''"},
+				{kind: dartIdent, text: "x", line: 3},
+			},
+		},
+		{
+			name: "raw triple-quoted string",
+			src:  `r"""\d+ $x""" y`,
+			want: []dartToken{
+				{kind: dartString, text: `r"""\d+ $x"""`},
+				{kind: dartIdent, text: "y"},
+			},
+		},
+		{
+			name: "unterminated string ends at the line",
+			src:  "'open\nx",
+			want: []dartToken{
+				{kind: dartString, text: "'open"},
+				{kind: dartIdent, text: "x", line: 2},
+			},
+		},
+		{
+			name: "nested block comments",
+			src:  "/* a /* b */ still comment */ x",
+			want: []dartToken{
+				{kind: dartComment, text: "/* a /* b */ still comment */"},
+				{kind: dartIdent, text: "x"},
+			},
+		},
+		{
+			name: "line comment and line numbers",
+			src:  "a // print('x')\n\n/*\n*/ b",
+			want: []dartToken{
+				{kind: dartIdent, text: "a"},
+				{kind: dartComment, text: "// print('x')"},
+				{kind: dartComment, text: "/*\n*/", line: 3},
+				{kind: dartIdent, text: "b", line: 4},
+			},
+		},
+		{
+			name: "identifier r is not a raw string prefix",
+			src:  "r + R",
+			want: []dartToken{
+				{kind: dartIdent, text: "r"}, {kind: dartPunct, text: "+"}, {kind: dartIdent, text: "R"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tokenizeDart(tt.src)
+			if len(got) != len(tt.want) {
+				t.Fatalf("tokenizeDart(%q) = %d tokens %+v, want %d", tt.src, len(got), got, len(tt.want))
+			}
+			for i, want := range tt.want {
+				if want.line == 0 {
+					want.line = 1
+				}
+				if got[i] != want {
+					t.Errorf("token %d = %+v, want %+v", i, got[i], want)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,289 @@
+package service
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/ilyin-ad/flutter-code-mentor/internal/config"
+)
+
+// LintIssue is a finding of the static analysis pre-pass. It is stored with
+// the review like AI feedback and listed in the prompt so the model does not
+// spend its answer on the same trivial issues.
+type LintIssue struct {
+	Rule         string
+	FilePath     string
+	Line         int
+	CodeSnippet  string
+	Message      string
+	FeedbackType string
+	Severity     int
+}
+
+// DartLinter runs a fixed set of token-based lint rules on Dart sources. It
+// needs no Dart SDK, so it only catches issues visible without resolving
+// types: it errs on the side of missing an issue rather than inventing one.
+type DartLinter interface {
+	Lint(files map[string]string) []LintIssue
+	Rules() []string
+}
+
+// dartLintRule mirrors a rule of the Dart analyzer with the same name.
+type dartLintRule struct {
+	feedbackType string
+	severity     int
+	check        func(f *dartFile) []lintHit
+}
+
+type lintHit struct {
+	line    int
+	message string
+}
+
+var dartLintRules = map[string]dartLintRule{
+	"avoid_print":               {feedbackType: "style_issue", severity: 2, check: checkAvoidPrint},
+	"empty_catches":             {feedbackType: "logic_error", severity: 3, check: checkEmptyCatches},
+	"prefer_const_constructors": {feedbackType: "performance", severity: 1, check: checkPreferConstConstructors},
+	"unnecessary_new":           {feedbackType: "style_issue", severity: 1, check: checkUnnecessaryNew},
+	"unused_import":             {feedbackType: "style_issue", severity: 1, check: checkUnusedImports},
+}
+
+type dartLinter struct {
+	rules     []string
+	maxIssues int
+}
+
+// NewDartLinter enables the configured rules. An unknown rule name is a
+// configuration error rather than a silently skipped rule.
+func NewDartLinter(cfg config.LintConfig) (DartLinter, error) {
+	if !cfg.Enabled {
+		return &dartLinter{}, nil
+	}
+
+	seen := make(map[string]bool)
+	var rules []string
+	for _, name := range cfg.Rules {
+		name = strings.TrimSpace(name)
+		if name == "" || seen[name] {
+			continue
+		}
+		if _, ok := dartLintRules[name]; !ok {
+			return nil, fmt.Errorf("unknown lint rule %q", name)
+		}
+		seen[name] = true
+		rules = append(rules, name)
+	}
+	sort.Strings(rules)
+
+	return &dartLinter{rules: rules, maxIssues: cfg.MaxIssues}, nil
+}
+
+func (l *dartLinter) Rules() []string {
+	return l.rules
+}
+
+// Lint checks the Dart files of a submission. A pasted snippet is stored
+// under the empty path and is linted as Dart as well. Issues are ordered by
+// file and line and capped at the configured maximum.
+func (l *dartLinter) Lint(files map[string]string) []LintIssue {
+	if len(l.rules) == 0 {
+		return nil
+	}
+
+	paths := make([]string, 0, len(files))
+	for path := range files {
+		if path == "" || (strings.HasSuffix(path, ".dart") && !isGeneratedDartFile(path)) {
+			paths = append(paths, path)
+		}
+	}
+	sort.Strings(paths)
+
+	var issues []LintIssue
+	for _, path := range paths {
+		f := newDartFile(files[path])
+		seen := make(map[string]bool)
+
+		var fileIssues []LintIssue
+		for _, name := range l.rules {
+			rule := dartLintRules[name]
+			for _, hit := range rule.check(f) {
+				key := fmt.Sprintf("%s:%d", name, hit.line)
+				if seen[key] {
+					continue
+				}
+				seen[key] = true
+
+				fileIssues = append(fileIssues, LintIssue{
+					Rule:         name,
+					FilePath:     path,
+					Line:         hit.line,
+					CodeSnippet:  f.lineText(hit.line),
+					Message:      hit.message,
+					FeedbackType: rule.feedbackType,
+					Severity:     rule.severity,
+				})
+			}
+		}
+
+		sort.SliceStable(fileIssues, func(i, j int) bool {
+			return fileIssues[i].Line < fileIssues[j].Line
+		})
+		issues = append(issues, fileIssues...)
+	}
+
+	if l.maxIssues > 0 && len(issues) > l.maxIssues {
+		issues = issues[:l.maxIssues]
+	}
+	return issues
+}
+
+// dartFile is a tokenized source file. code holds the tokens without
+// comments, which most rules work on.
+type dartFile struct {
+	lines  []string
+	tokens []dartToken
+	code   []dartToken
+}
+
+func newDartFile(src string) *dartFile {
+	f := &dartFile{
+		lines:  strings.Split(src, "\n"),
+		tokens: tokenizeDart(src),
+	}
+	for _, t := range f.tokens {
+		if t.kind != dartComment {
+			f.code = append(f.code, t)
+		}
+	}
+	return f
+}
+
+func (f *dartFile) lineText(line int) string {
+	if line < 1 || line > len(f.lines) {
+		return ""
+	}
+	return strings.TrimSpace(f.lines[line-1])
+}
+
+// matchingClose returns the index of the bracket closing the one at open,
+// or len(tokens)-1 when the source is cut off.
+func matchingClose(tokens []dartToken, open int) int {
+	depth := 0
+	for i := open; i < len(tokens); i++ {
+		if tokens[i].kind != dartPunct {
+			continue
+		}
+		switch tokens[i].text {
+		case "(", "[", "{":
+			depth++
+		case ")", "]", "}":
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return len(tokens) - 1
+}
+
+func isPunct(t dartToken, text string) bool {
+	return t.kind == dartPunct && t.text == text
+}
+
+func isIdent(t dartToken, text string) bool {
+	return t.kind == dartIdent && t.text == text
+}
+
+// expressionKeywords may precede a call; any other identifier before a name
+// makes it a declaration such as "void print(...)".
+var expressionKeywords = map[string]bool{
+	"return": true, "await": true, "else": true, "yield": true, "throw": true, "in": true, "case": true,
+}
+
+func checkAvoidPrint(f *dartFile) []lintHit {
+	var hits []lintHit
+	for i, t := range f.code {
+		if !isIdent(t, "print") || i+1 >= len(f.code) || !isPunct(f.code[i+1], "(") {
+			continue
+		}
+		if i > 0 {
+			prev := f.code[i-1]
+			if prev.kind == dartPunct && (prev.text == "." || prev.text == "?." || prev.text == "..") {
+				continue
+			}
+			if prev.kind == dartIdent && !expressionKeywords[prev.text] {
+				continue
+			}
+		}
+		hits = append(hits, lintHit{
+			line:    t.line,
+			message: "Avoid print in production code: it is not stripped from release builds and clutters the device log. Use debugPrint or a logging package instead.",
+		})
+	}
+	return hits
+}
+
+func checkUnnecessaryNew(f *dartFile) []lintHit {
+	var hits []lintHit
+	for i, t := range f.code {
+		if !isIdent(t, "new") || i+1 >= len(f.code) || f.code[i+1].kind != dartIdent {
+			continue
+		}
+		// Foo.new is a constructor tear-off, not the keyword.
+		if i > 0 && isPunct(f.code[i-1], ".") {
+			continue
+		}
+		hits = append(hits, lintHit{
+			line:    t.line,
+			message: "The new keyword is unnecessary since Dart 2 and should be removed.",
+		})
+	}
+	return hits
+}
+
+// checkEmptyCatches reports catch blocks without code or a comment. A catch
+// parameter named _ marks an exception as deliberately ignored.
+func checkEmptyCatches(f *dartFile) []lintHit {
+	var hits []lintHit
+	tokens := f.tokens
+	for i := 0; i < len(tokens); i++ {
+		t := tokens[i]
+		var body int
+
+		switch {
+		case isIdent(t, "catch") && i+1 < len(tokens) && isPunct(tokens[i+1], "("):
+			closeParen := matchingClose(tokens, i+1)
+			if i+2 < len(tokens) && isIdent(tokens[i+2], "_") {
+				continue
+			}
+			body = closeParen + 1
+
+		case isIdent(t, "on") && i > 0 && isPunct(tokens[i-1], "}"):
+			// "on Type {}" without a catch clause.
+			j := i + 1
+			for j < len(tokens) && (tokens[j].kind == dartIdent || isPunct(tokens[j], ".") ||
+				isPunct(tokens[j], "<") || isPunct(tokens[j], ">") || isPunct(tokens[j], ",") || isPunct(tokens[j], "?")) {
+				if isIdent(tokens[j], "catch") {
+					break
+				}
+				j++
+			}
+			if j < len(tokens) && isIdent(tokens[j], "catch") {
+				continue
+			}
+			body = j
+
+		default:
+			continue
+		}
+
+		if body+1 < len(tokens) && isPunct(tokens[body], "{") && isPunct(tokens[body+1], "}") {
+			hits = append(hits, lintHit{
+				line:    t.line,
+				message: "Empty catch block: the error is silently swallowed. Handle or log it, or add a comment explaining why it can be ignored.",
+			})
+		}
+	}
+	return hits
+}
@@ -0,0 +1,352 @@
+package service
+
+// constConstructors are Flutter and dart:ui constructors declared const.
+// Only these are suggested: without type resolution the linter cannot tell
+// whether any other constructor is const.
+var constConstructors = map[string]bool{
+	"Align":                     true,
+	"BorderRadius.all":          true,
+	"Card":                      true,
+	"Center":                    true,
+	"CircularProgressIndicator": true,
+	"Color":                     true,
+	"Column":                    true,
+	"Divider":                   true,
+	"Duration":                  true,
+	"EdgeInsets.all":            true,
+	"EdgeInsets.fromLTRB":       true,
+	"EdgeInsets.only":           true,
+	"EdgeInsets.symmetric":      true,
+	"Expanded":                  true,
+	"Flexible":                  true,
+	"Icon":                      true,
+	"LinearProgressIndicator":   true,
+	"ListTile":                  true,
+	"Offset":                    true,
+	"Padding":                   true,
+	"Placeholder":               true,
+	"Radius.circular":           true,
+	"Row":                       true,
+	"Size":                      true,
+	"Size.square":               true,
+	"SizedBox":                  true,
+	"SizedBox.expand":           true,
+	"SizedBox.shrink":           true,
+	"SizedBox.square":           true,
+	"Spacer":                    true,
+	"Text":                      true,
+	"TextStyle":                 true,
+	"VerticalDivider":           true,
+}
+
+// constValueTypes hold only constant static fields and enum values, so
+// Type.name is a constant expression (Icons.add, Colors.red, Axis.vertical).
+var constValueTypes = map[string]bool{
+	"Alignment":          true,
+	"Axis":               true,
+	"BorderRadius":       true,
+	"BoxFit":             true,
+	"BoxShape":           true,
+	"Clip":               true,
+	"Colors":             true,
+	"CrossAxisAlignment": true,
+	"CupertinoColors":    true,
+	"CupertinoIcons":     true,
+	"Curves":             true,
+	"Duration":           true,
+	"EdgeInsets":         true,
+	"FlexFit":            true,
+	"FontStyle":          true,
+	"FontWeight":         true,
+	"Icons":              true,
+	"MainAxisAlignment":  true,
+	"MainAxisSize":       true,
+	"Offset":             true,
+	"Radius":             true,
+	"Size":               true,
+	"StackFit":           true,
+	"TextAlign":          true,
+	"TextDirection":      true,
+	"TextOverflow":       true,
+	"WrapAlignment":      true,
+}
+
+// checkPreferConstConstructors reports calls of const constructors whose
+// arguments are all constant and that are not already in a const context.
+// Only the outermost call of a constant widget tree is reported.
+func checkPreferConstConstructors(f *dartFile) []lintHit {
+	code := f.code
+	inConst := constContexts(code)
+
+	var hits []lintHit
+	for i := 0; i < len(code); i++ {
+		if inConst[i] {
+			continue
+		}
+		name, open, ok := constructorCall(code, i)
+		if !ok {
+			continue
+		}
+		if i > 0 {
+			prev := code[i-1]
+			if prev.kind == dartPunct && (prev.text == "." || prev.text == "?." || prev.text == "..") {
+				continue
+			}
+			if prev.kind == dartIdent && !expressionKeywords[prev.text] {
+				continue
+			}
+		}
+
+		end := matchingClose(code, open)
+		if !isPunct(code[end], ")") || !constArguments(code[open+1:end]) {
+			continue
+		}
+
+		hits = append(hits, lintHit{
+			line:    code[i].line,
+			message: "Use const with the constructor " + name + "(): all its arguments are constant, so Flutter can create it once and skip rebuilding it.",
+		})
+		i = end
+	}
+	return hits
+}
+
+// constructorCall recognises Name( and Name.named( for a known const
+// constructor and returns the index of the opening parenthesis.
+func constructorCall(code []dartToken, i int) (string, int, bool) {
+	if code[i].kind != dartIdent {
+		return "", 0, false
+	}
+	name := code[i].text
+
+	if i+1 < len(code) && isPunct(code[i+1], "(") && constConstructors[name] {
+		return name, i + 1, true
+	}
+	if i+3 < len(code) && isPunct(code[i+1], ".") && code[i+2].kind == dartIdent && isPunct(code[i+3], "(") {
+		named := name + "." + code[i+2].text
+		if constConstructors[named] {
+			return named, i + 3, true
+		}
+	}
+	return "", 0, false
+}
+
+// constContexts marks tokens where a const keyword would be redundant:
+// const expressions and declarations, annotations and case patterns.
+func constContexts(code []dartToken) []bool {
+	in := make([]bool, len(code))
+	mark := func(from, to int) {
+		for k := from; k <= to && k < len(code); k++ {
+			in[k] = true
+		}
+	}
+
+	for i, t := range code {
+		switch {
+		case isIdent(t, "const"):
+			mark(i, constExpressionEnd(code, i+1))
+
+		case isPunct(t, "@"):
+			j := i + 1
+			for j < len(code) && (code[j].kind == dartIdent || isPunct(code[j], ".")) {
+				j++
+			}
+			if j < len(code) && isPunct(code[j], "(") {
+				mark(i, matchingClose(code, j))
+			}
+
+		case isIdent(t, "case"):
+			mark(i, patternEnd(code, i+1))
+		}
+	}
+	return in
+}
+
+// constExpressionEnd finds the end of what a const keyword applies to:
+// the call or collection literal after it, or the initializer of a const
+// variable.
+func constExpressionEnd(code []dartToken, start int) int {
+	for j := start; j < len(code); j++ {
+		if code[j].kind != dartPunct {
+			continue
+		}
+		switch code[j].text {
+		case "(", "[", "{":
+			return matchingClose(code, j)
+		case "=":
+			return statementEnd(code, j)
+		case ";":
+			return j
+		}
+	}
+	return len(code) - 1
+}
+
+// statementEnd returns the index of the semicolon ending the statement that
+// contains start, or of the bracket closing its enclosing expression.
+func statementEnd(code []dartToken, start int) int {
+	depth := 0
+	for j := start; j < len(code); j++ {
+		if code[j].kind != dartPunct {
+			continue
+		}
+		switch code[j].text {
+		case "(", "[", "{":
+			depth++
+		case ")", "]", "}":
+			depth--
+			if depth < 0 {
+				return j
+			}
+		case ";":
+			if depth == 0 {
+				return j
+			}
+		}
+	}
+	return len(code) - 1
+}
+
+func patternEnd(code []dartToken, start int) int {
+	depth := 0
+	for j := start; j < len(code); j++ {
+		if code[j].kind != dartPunct {
+			continue
+		}
+		switch code[j].text {
+		case "(", "[", "{":
+			depth++
+		case ")", "]", "}":
+			depth--
+			if depth < 0 {
+				return j
+			}
+		case ":", "=>":
+			if depth == 0 {
+				return j
+			}
+		}
+	}
+	return len(code) - 1
+}
+
+// constArguments reports whether every argument in an argument list is a
+// constant expression.
+func constArguments(args []dartToken) bool {
+	for _, arg := range splitTopLevel(args) {
+		if len(arg) == 0 {
+			// Trailing comma.
+			continue
+		}
+		if len(arg) > 2 && arg[0].kind == dartIdent && isPunct(arg[1], ":") {
+			arg = arg[2:]
+		}
+		if !constExpression(arg) {
+			return false
+		}
+	}
+	return true
+}
+
+func constExpression(expr []dartToken) bool {
+	if len(expr) == 0 {
+		return false
+	}
+
+	if isIdent(expr[0], "const") {
+		return true
+	}
+
+	allStrings := true
+	for _, t := range expr {
+		if t.kind != dartString || t.interpolated {
+			allStrings = false
+			break
+		}
+	}
+	if allStrings {
+		return true
+	}
+
+	if len(expr) == 1 {
+		t := expr[0]
+		return t.kind == dartNumber || isIdent(t, "true") || isIdent(t, "false") || isIdent(t, "null")
+	}
+
+	if len(expr) == 2 && isPunct(expr[0], "-") && expr[1].kind == dartNumber {
+		return true
+	}
+
+	if len(expr) == 3 && expr[0].kind == dartIdent && constValueTypes[expr[0].text] &&
+		isPunct(expr[1], ".") && expr[2].kind == dartIdent {
+		return true
+	}
+
+	if list := listLiteralStart(expr); list >= 0 {
+		if matchingClose(expr, list) != len(expr)-1 {
+			return false
+		}
+		for _, element := range splitTopLevel(expr[list+1 : len(expr)-1]) {
+			if len(element) == 0 {
+				continue
+			}
+			if isPunct(element[0], "...") || isIdent(element[0], "if") || isIdent(element[0], "for") {
+				return false
+			}
+			if !constExpression(element) {
+				return false
+			}
+		}
+		return true
+	}
+
+	if _, open, ok := constructorCall(expr, 0); ok {
+		end := matchingClose(expr, open)
+		return end == len(expr)-1 && constArguments(expr[open+1:end])
+	}
+
+	return false
+}
+
+// listLiteralStart returns the index of the opening bracket of a list
+// literal, skipping type arguments as in <Widget>[...], or -1.
+func listLiteralStart(expr []dartToken) int {
+	if isPunct(expr[0], "[") {
+		return 0
+	}
+	if !isPunct(expr[0], "<") {
+		return -1
+	}
+	for j := 1; j < len(expr); j++ {
+		if isPunct(expr[j], ">") {
+			if j+1 < len(expr) && isPunct(expr[j+1], "[") {
+				return j + 1
+			}
+			return -1
+		}
+	}
+	return -1
+}
+
+// splitTopLevel splits tokens at commas outside of nested brackets.
+func splitTopLevel(tokens []dartToken) [][]dartToken {
+	var parts [][]dartToken
+	depth, start := 0, 0
+	for i, t := range tokens {
+		if t.kind != dartPunct {
+			continue
+		}
+		switch t.text {
+		case "(", "[", "{":
+			depth++
+		case ")", "]", "}":
+			depth--
+		case ",":
+			if depth == 0 {
+				parts = append(parts, tokens[start:i])
+				start = i + 1
+			}
+		}
+	}
+	return append(parts, tokens[start:])
+}
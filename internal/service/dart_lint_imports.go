@@ -0,0 +1,219 @@
+package service
+
+import (
+	"fmt"
+	"strings"
+)
+
+// dartLibraryNames lists the public names of the core libraries students
+// import most often. Imports of other libraries are only checked when they
+// have a prefix or a show combinator, since their names are unknown here.
+var dartLibraryNames = map[string][]string{
+	"dart:async": {
+		"AsyncError", "Completer", "EventSink", "Future", "FutureOr", "MultiStreamController",
+		"Stream", "StreamConsumer", "StreamController", "StreamIterator", "StreamSink",
+		"StreamSubscription", "StreamTransformer", "StreamTransformerBase", "StreamView",
+		"SynchronousStreamController", "TimeoutException", "Timer", "Zone",
+		"runZoned", "runZonedGuarded", "scheduleMicrotask", "unawaited",
+		// Extension members declared in dart:async.
+		"ignore", "onError", "wait",
+	},
+	"dart:collection": {
+		"DoubleLinkedQueue", "HashMap", "HashSet", "IterableBase", "IterableMixin",
+		"LinkedHashMap", "LinkedHashSet", "LinkedList", "LinkedListEntry", "ListBase",
+		"ListMixin", "ListQueue", "MapBase", "MapMixin", "MapView", "Queue", "SetBase",
+		"SetMixin", "SplayTreeMap", "SplayTreeSet", "UnmodifiableListView",
+		"UnmodifiableMapView", "UnmodifiableSetView",
+	},
+	"dart:convert": {
+		"AsciiCodec", "Base64Codec", "Base64Decoder", "Base64Encoder", "ByteConversionSink",
+		"Codec", "Converter", "Encoding", "HtmlEscape", "JsonCodec", "JsonDecoder",
+		"JsonEncoder", "JsonUnsupportedObjectError", "Latin1Codec", "LineSplitter",
+		"StringConversionSink", "Utf8Codec", "Utf8Decoder", "Utf8Encoder",
+		"ascii", "base64", "base64Decode", "base64Encode", "base64Url", "htmlEscape",
+		"json", "jsonDecode", "jsonEncode", "latin1", "utf8",
+	},
+	"dart:io": {
+		"ContentType", "Cookie", "Directory", "File", "FileMode", "FileStat",
+		"FileSystemEntity", "FileSystemEntityType", "FileSystemException", "GZipCodec",
+		"HandshakeException", "HttpClient", "HttpClientRequest", "HttpClientResponse",
+		"HttpException", "HttpHeaders", "HttpRequest", "HttpServer", "HttpStatus",
+		"IOException", "IOSink", "InternetAddress", "Link", "OSError", "Platform",
+		"Process", "ProcessResult", "ProcessSignal", "ProcessStartMode", "RandomAccessFile",
+		"SecurityContext", "ServerSocket", "Socket", "SocketException", "Stdin", "Stdout",
+		"TlsException", "WebSocket", "X509Certificate", "ZLibCodec",
+		"exit", "exitCode", "gzip", "pid", "sleep", "stderr", "stdin", "stdout",
+		"systemEncoding", "zlib",
+	},
+	"dart:math": {
+		"MutableRectangle", "Point", "Random", "Rectangle",
+		"acos", "asin", "atan", "atan2", "cos", "e", "exp", "ln10", "ln2", "log",
+		"log10e", "log2e", "max", "min", "pi", "pow", "sin", "sqrt", "sqrt1_2", "sqrt2", "tan",
+	},
+	"dart:typed_data": {
+		"ByteBuffer", "ByteData", "Endian", "Float32List", "Float32x4", "Float32x4List",
+		"Float64List", "Float64x2", "Float64x2List", "Int16List", "Int32List", "Int32x4",
+		"Int32x4List", "Int64List", "Int8List", "TypedData", "Uint16List", "Uint32List",
+		"Uint64List", "Uint8ClampedList", "Uint8List",
+	},
+}
+
+type dartImport struct {
+	uri    string
+	prefix string
+	shown  []string
+	line   int
+}
+
+func checkUnusedImports(f *dartFile) []lintHit {
+	imports, directive := parseDartImports(f.code)
+	if len(imports) == 0 {
+		return nil
+	}
+
+	used := make(map[string]bool)
+	for i, t := range f.code {
+		switch {
+		case directive[i]:
+		case t.kind == dartIdent:
+			used[t.text] = true
+		case t.kind == dartString && t.interpolated:
+			addDartWords(used, t.text)
+		}
+	}
+	// Doc comments reference names as [Name].
+	for _, t := range f.tokens {
+		if t.kind == dartComment && strings.HasPrefix(t.text, "///") {
+			addDartWords(used, t.text)
+		}
+	}
+
+	var hits []lintHit
+	seen := make(map[string]bool)
+	for _, imp := range imports {
+		key := imp.uri + " as " + imp.prefix
+		if len(imp.shown) == 0 {
+			if seen[key] {
+				hits = append(hits, lintHit{
+					line:    imp.line,
+					message: fmt.Sprintf("Duplicate import of '%s': remove it.", imp.uri),
+				})
+				continue
+			}
+			seen[key] = true
+		}
+
+		switch {
+		case imp.prefix != "":
+			if !used[imp.prefix] {
+				hits = append(hits, lintHit{
+					line:    imp.line,
+					message: fmt.Sprintf("Unused import: '%s' is imported as %s but the prefix is never used.", imp.uri, imp.prefix),
+				})
+			}
+		case len(imp.shown) > 0:
+			if !anyUsed(used, imp.shown) && !showsExtension(imp.shown) {
+				hits = append(hits, lintHit{
+					line:    imp.line,
+					message: fmt.Sprintf("Unused import: none of the names shown from '%s' are used.", imp.uri),
+				})
+			}
+		default:
+			if names, ok := dartLibraryNames[imp.uri]; ok && !anyUsed(used, names) {
+				hits = append(hits, lintHit{
+					line:    imp.line,
+					message: fmt.Sprintf("Unused import: nothing from '%s' is used in this file.", imp.uri),
+				})
+			}
+		}
+	}
+	return hits
+}
+
+// parseDartImports reads the import directives and marks their tokens, so
+// names in show combinators do not count as uses.
+func parseDartImports(code []dartToken) ([]dartImport, []bool) {
+	directive := make([]bool, len(code))
+	var imports []dartImport
+
+	for i := 0; i < len(code); i++ {
+		if !isIdent(code[i], "import") || i+1 >= len(code) || code[i+1].kind != dartString {
+			continue
+		}
+		if i > 0 && !isPunct(code[i-1], ";") && !isPunct(code[i-1], ")") && code[i-1].kind != dartIdent {
+			continue
+		}
+
+		imp := dartImport{uri: unquoteDartString(code[i+1].text), line: code[i].line}
+		j := i + 2
+		for ; j < len(code) && !isPunct(code[j], ";"); j++ {
+			switch {
+			case isIdent(code[j], "as") && j+1 < len(code) && code[j+1].kind == dartIdent:
+				imp.prefix = code[j+1].text
+				j++
+			case isIdent(code[j], "show"):
+				for j+1 < len(code) && (code[j+1].kind == dartIdent || isPunct(code[j+1], ",")) &&
+					!isIdent(code[j+1], "show") && !isIdent(code[j+1], "hide") {
+					j++
+					if code[j].kind == dartIdent {
+						imp.shown = append(imp.shown, code[j].text)
+					}
+				}
+			}
+		}
+
+		for k := i; k <= j && k < len(code); k++ {
+			directive[k] = true
+		}
+		imports = append(imports, imp)
+		i = j
+	}
+
+	return imports, directive
+}
+
+func unquoteDartString(s string) string {
+	s = strings.TrimLeft(s, "rR")
+	for _, quote := range []string{`'''`, `"""`, `'`, `"`} {
+		if strings.HasPrefix(s, quote) {
+			return strings.TrimSuffix(strings.TrimPrefix(s, quote), quote)
+		}
+	}
+	return s
+}
+
+func addDartWords(words map[string]bool, s string) {
+	start := -1
+	for i := 0; i <= len(s); i++ {
+		if i < len(s) && isDartIdentPart(s[i]) && s[i] != '$' {
+			if start < 0 {
+				start = i
+			}
+			continue
+		}
+		if start >= 0 {
+			words[s[start:i]] = true
+			start = -1
+		}
+	}
+}
+
+func anyUsed(used map[string]bool, names []string) bool {
+	for _, name := range names {
+		if used[name] {
+			return true
+		}
+	}
+	return false
+}
+
+// showsExtension reports whether a show combinator imports an extension,
+// whose members are used without its name appearing in the code.
+func showsExtension(names []string) bool {
+	for _, name := range names {
+		if strings.HasSuffix(name, "Extension") || strings.HasSuffix(name, "Ext") {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,157 @@
+package service
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/ilyin-ad/flutter-code-mentor/internal/config"
+)
+
+type lintRuleTest struct {
+	name string
+	src  string
+	want []int
+}
+
+// runLintRuleTests checks the lines one rule reports for each source.
+func runLintRuleTests(t *testing.T, rule string, tests []lintRuleTest) {
+	t.Helper()
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []int
+			for _, hit := range dartLintRules[rule].check(newDartFile(tt.src)) {
+				got = append(got, hit.line)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("%s reported lines %v, want %v\n%s", rule, got, tt.want, tt.src)
+			}
+		})
+	}
+}
+
+func TestLintAvoidPrint(t *testing.T) {
+	runLintRuleTests(t, "avoid_print", []lintRuleTest{
+		{name: "call", src: "void main() {\n  print('hi');\n}", want: []int{2}},
+		{name: "after return", src: "void f() => print(1);\nvoid g() { return print(2); }", want: []int{1, 2}},
+		{name: "declaration", src: "void print(String s) {}\nString print2() => '';"},
+		{name: "method", src: "logger.print('x');\nlogger?.print('y');\nbuffer..print('z');"},
+		{name: "tear-off", src: "items.forEach(print);"},
+		{name: "comment", src: "// print('x');\n/* print(\n'y'); */"},
+		{name: "string", src: "final s = 'print(1)';\nfinal t = '''\nprint(2)\n''';"},
+	})
+}
+
+func TestLintUnnecessaryNew(t *testing.T) {
+	runLintRuleTests(t, "unnecessary_new", []lintRuleTest{
+		{name: "constructor call", src: "final a = new Foo();\nfinal b = new Foo.named();", want: []int{1, 2}},
+		{name: "constructor tear-off", src: "final f = Foo.new;\nitems.map(Bar.new);"},
+		{name: "named constructor declaration", src: "class Foo {\n  Foo.new();\n}"},
+		{name: "string and comment", src: "final s = 'new Foo()';\n// new Foo();"},
+	})
+}
+
+func TestLintEmptyCatches(t *testing.T) {
+	runLintRuleTests(t, "empty_catches", []lintRuleTest{
+		{name: "empty catch", src: "try {\n  f();\n} catch (e) {}", want: []int{3}},
+		{name: "empty on clause", src: "try { f(); } on FormatException {}", want: []int{1}},
+		{name: "on with catch", src: "try { f(); }\non FormatException catch (e, s) {}", want: []int{2}},
+		{name: "generic on clause", src: "try { f(); } on Map<String, int> {}", want: []int{1}},
+		{name: "handled", src: "try { f(); } catch (e) { log(e); }"},
+		{name: "explained by a comment", src: "try { f(); } catch (e) {\n  // The cache is optional.\n}"},
+		{name: "ignored on purpose", src: "try { f(); } catch (_) {}"},
+		{name: "named argument on", src: "listen(on: () {});"},
+	})
+}
+
+func TestLintPreferConstConstructors(t *testing.T) {
+	runLintRuleTests(t, "prefer_const_constructors", []lintRuleTest{
+		{name: "constant text", src: "Widget build() {\n  return Text('hi');\n}", want: []int{2}},
+		{name: "named constructor", src: "final p = EdgeInsets.symmetric(horizontal: 8, vertical: -4.5);", want: []int{1}},
+		{name: "constant values", src: "final i = Icon(Icons.add, color: Colors.red, size: null);", want: []int{1}},
+		{
+			name: "outermost call of a constant tree only",
+			src:  "final w = Padding(\n  padding: EdgeInsets.all(8),\n  child: Column(children: <Widget>[Text('a'), Spacer()]),\n);",
+			want: []int{1},
+		},
+		{
+			name: "constant child of a non-constant call",
+			src:  "final w = Padding(\n  padding: EdgeInsets.all(size),\n  child: Text('a'),\n);",
+			want: []int{3},
+		},
+		{name: "already const", src: "final t = const Text('hi');"},
+		{name: "const variable", src: "const w = Column(children: [Text('a')]);"},
+		{name: "const collection", src: "final l = const [Text('a'), SizedBox.shrink()];"},
+		{name: "annotation", src: "@Preview(Size(1, 2))\nvoid f() {}"},
+		{name: "case pattern", src: "switch (s) {\n  case Size(width: 1):\n    break;\n}"},
+		{name: "non-constant argument", src: "final t = Text(name);\nfinal u = SizedBox(width: width * 2);"},
+		{name: "interpolated string", src: "final t = Text('Hello $name');\nfinal u = Text('${1 + 1}');"},
+		{name: "adjacent strings", src: "final t = Text('Hello ' 'world');", want: []int{1}},
+		{name: "collection with spread", src: "final c = Column(children: [...items]);"},
+		{name: "unknown constructor", src: "final w = MyWidget(title: 'x');"},
+		{name: "constructor declaration", src: "class Text {\n  const Text(String s);\n}"},
+		{name: "method named like a constructor", src: "final t = theme.Text('x');"},
+	})
+}
+
+func TestLintUnusedImport(t *testing.T) {
+	runLintRuleTests(t, "unused_import", []lintRuleTest{
+		{name: "used core library", src: "import 'dart:math';\nfinal x = max(1, 2);"},
+		{name: "unused core library", src: "import 'dart:math';\nimport 'dart:convert';\nfinal s = jsonEncode({});", want: []int{1}},
+		{name: "used in interpolation", src: "import 'dart:math';\nfinal s = '${pi}';"},
+		{name: "used in a doc comment", src: "import 'dart:math';\n/// Picks a [Random] seed.\nint seed = 1;"},
+		{name: "unused prefix", src: "import 'package:http/http.dart' as http;\nvoid main() {}", want: []int{1}},
+		{name: "used prefix", src: "import 'package:http/http.dart' as http;\nfinal c = http.Client();"},
+		{name: "shown names unused", src: "import 'package:app/a.dart' show Foo, Bar;\nfinal b = Baz();", want: []int{1}},
+		{name: "shown name used", src: "import 'package:app/a.dart' show Foo, Bar;\nfinal b = Bar();"},
+		{name: "shown extension", src: "import 'package:app/a.dart' show StringExt;\nfinal s = 'a'.shout();"},
+		{name: "duplicate import", src: "import 'dart:math';\nimport 'dart:math';\nfinal x = pi;", want: []int{2}},
+		{name: "unknown library", src: "import 'package:flutter/material.dart';\nvoid main() {}"},
+		{name: "name in a string", src: "import 'dart:math';\nfinal s = 'max';", want: []int{1}},
+	})
+}
+
+func TestDartLinterLint(t *testing.T) {
+	linter, err := NewDartLinter(config.LintConfig{
+		Enabled:   true,
+		Rules:     []string{"avoid_print", "unnecessary_new", "avoid_print"},
+		MaxIssues: 3,
+	})
+	if err != nil {
+		t.Fatalf("NewDartLinter: %v", err)
+	}
+
+	issues := linter.Lint(map[string]string{
+		"lib/b.dart":      "void f() {\n  print(new Foo());\n}",
+		"lib/a.dart":      "void g() {\n  final x = new Foo();\n  print(x);\n}",
+		"lib/a.g.dart":    "void h() { print(1); }",
+		"pubspec.yaml":    "name: print(1)",
+		"lib/skipped.txt": "print(1);",
+	})
+
+	want := []struct {
+		rule string
+		path string
+		line int
+	}{
+		{"unnecessary_new", "lib/a.dart", 2},
+		{"avoid_print", "lib/a.dart", 3},
+		{"avoid_print", "lib/b.dart", 2},
+	}
+	if len(issues) != len(want) {
+		t.Fatalf("Lint returned %d issues %+v, want %d", len(issues), issues, len(want))
+	}
+	for i, w := range want {
+		got := issues[i]
+		if got.Rule != w.rule || got.FilePath != w.path || got.Line != w.line {
+			t.Errorf("issue %d = %s %s:%d, want %s %s:%d", i, got.Rule, got.FilePath, got.Line, w.rule, w.path, w.line)
+		}
+	}
+	if issues[0].CodeSnippet != "final x = new Foo();" || issues[0].FeedbackType != "style_issue" {
+		t.Errorf("issue 0 = %+v", issues[0])
+	}
+
+	if _, err := NewDartLinter(config.LintConfig{Enabled: true, Rules: []string{"no_such_rule"}}); err == nil {
+		t.Error("NewDartLinter accepted an unknown rule")
+	}
+}
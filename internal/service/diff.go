@@ -0,0 +1,216 @@
+package service
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+const (
+	diffContextLines = 3
+	// maxDiffCells bounds the LCS table; larger files are diffed as a full
+	// replacement instead.
+	maxDiffCells = 4_000_000
+)
+
+type FileDiffStatus string
+
+const (
+	FileAdded    FileDiffStatus = "added"
+	FileRemoved  FileDiffStatus = "removed"
+	FileModified FileDiffStatus = "modified"
+)
+
+type FileDiff struct {
+	Path         string
+	Status       FileDiffStatus
+	AddedLines   int
+	RemovedLines int
+	Patch        string
+}
+
+// DiffFiles compares two snapshots of a submission keyed by file path and
+// returns unified diffs for every changed file, sorted by path.
+func DiffFiles(previous, current map[string]string) []FileDiff {
+	paths := make(map[string]bool, len(previous)+len(current))
+	for path := range previous {
+		paths[path] = true
+	}
+	for path := range current {
+		paths[path] = true
+	}
+
+	sorted := make([]string, 0, len(paths))
+	for path := range paths {
+		sorted = append(sorted, path)
+	}
+	sort.Strings(sorted)
+
+	var diffs []FileDiff
+	for _, path := range sorted {
+		before, hadBefore := previous[path]
+		after, hasAfter := current[path]
+		if hadBefore && hasAfter && before == after {
+			continue
+		}
+
+		status := FileModified
+		switch {
+		case !hadBefore:
+			status = FileAdded
+		case !hasAfter:
+			status = FileRemoved
+		}
+
+		patch, added, removed := unifiedDiff(splitLines(before), splitLines(after))
+		diffs = append(diffs, FileDiff{
+			Path:         path,
+			Status:       status,
+			AddedLines:   added,
+			RemovedLines: removed,
+			Patch:        patch,
+		})
+	}
+
+	return diffs
+}
+
+// FormatDiff renders diffs as one text block for prompts and API responses.
+func FormatDiff(diffs []FileDiff) string {
+	var b strings.Builder
+	for _, d := range diffs {
+		name := d.Path
+		if name == "" {
+			name = "code"
+		}
+		b.WriteString(fmt.Sprintf("=== %s (%s, +%d -%d) ===\n", name, d.Status, d.AddedLines, d.RemovedLines))
+		b.WriteString(d.Patch)
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+func splitLines(s string) []string {
+	if s == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(s, "\n"), "\n")
+}
+
+type diffOp struct {
+	kind byte // ' ', '-', '+'
+	line string
+	old  int
+	new  int
+}
+
+func unifiedDiff(a, b []string) (string, int, int) {
+	ops := diffLines(a, b)
+
+	added, removed := 0, 0
+	for _, op := range ops {
+		switch op.kind {
+		case '+':
+			added++
+		case '-':
+			removed++
+		}
+	}
+
+	var out strings.Builder
+	for i := 0; i < len(ops); {
+		if ops[i].kind == ' ' {
+			i++
+			continue
+		}
+
+		start := max(i-diffContextLines, 0)
+		end := i
+		for end < len(ops) {
+			if ops[end].kind != ' ' {
+				end++
+				continue
+			}
+			next := end
+			for next < len(ops) && ops[next].kind == ' ' {
+				next++
+			}
+			if next == len(ops) || next-end > 2*diffContextLines {
+				end = min(end+diffContextLines, len(ops))
+				break
+			}
+			end = next
+		}
+
+		oldStart, newStart, oldCount, newCount := ops[start].old, ops[start].new, 0, 0
+		for _, op := range ops[start:end] {
+			if op.kind != '+' {
+				oldCount++
+			}
+			if op.kind != '-' {
+				newCount++
+			}
+		}
+
+		out.WriteString(fmt.Sprintf("@@ -%d,%d +%d,%d @@\n", oldStart, oldCount, newStart, newCount))
+		for _, op := range ops[start:end] {
+			out.WriteByte(op.kind)
+			out.WriteString(op.line)
+			out.WriteByte('\n')
+		}
+
+		i = end
+	}
+
+	return out.String(), added, removed
+}
+
+// diffLines returns an edit script based on the longest common subsequence
+// of lines. Line numbers in the ops are 1-based.
+func diffLines(a, b []string) []diffOp {
+	n, m := len(a), len(b)
+
+	if n*m > maxDiffCells {
+		ops := make([]diffOp, 0, n+m)
+		for i, line := range a {
+			ops = append(ops, diffOp{kind: '-', line: line, old: i + 1, new: 1})
+		}
+		for j, line := range b {
+			ops = append(ops, diffOp{kind: '+', line: line, old: n + 1, new: j + 1})
+		}
+		return ops
+	}
+
+	lcs := make([][]int32, n+1)
+	for i := range lcs {
+		lcs[i] = make([]int32, m+1)
+	}
+	for i := n - 1; i >= 0; i-- {
+		for j := m - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	ops := make([]diffOp, 0, n+m)
+	i, j := 0, 0
+	for i < n || j < m {
+		switch {
+		case i < n && j < m && a[i] == b[j]:
+			ops = append(ops, diffOp{kind: ' ', line: a[i], old: i + 1, new: j + 1})
+			i++
+			j++
+		case i < n && (j == m || lcs[i+1][j] >= lcs[i][j+1]):
+			ops = append(ops, diffOp{kind: '-', line: a[i], old: i + 1, new: j + 1})
+			i++
+		default:
+			ops = append(ops, diffOp{kind: '+', line: b[j], old: i + 1, new: j + 1})
+			j++
+		}
+	}
+
+	return ops
+}
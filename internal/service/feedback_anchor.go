@@ -0,0 +1,269 @@
+package service
+
+import (
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+)
+
+const (
+	// minSnippetLineLength keeps one-line snippets like "}" from matching
+	// anywhere in a file.
+	minSnippetLineLength = 3
+	// maxSnippetGap is how far apart the parts of a snippet elided with
+	// "..." may be.
+	maxSnippetGap = 200
+)
+
+// AnchorFeedback checks AI feedback against the reviewed files. Each item's
+// snippet is searched for in its file and the line range is set to where it
+// was found; the occurrence closest to the reported lines wins. Items whose
+// snippet is not found keep their (clamped) lines and are flagged as not
+// anchored. A file path that is not among the reviewed files is resolved by
+// path suffix or by finding the snippet in exactly one file; otherwise the
+// item is dropped. The returned notes list every correction made.
+func AnchorFeedback(files map[string]string, items []FeedbackItem) ([]FeedbackItem, []string) {
+	snippetOnly := len(files) == 1 && hasKey(files, "")
+
+	paths := make([]string, 0, len(files))
+	lines := make(map[string][]string, len(files))
+	for filePath, content := range files {
+		paths = append(paths, filePath)
+		lines[filePath] = normalizedLines(content)
+	}
+	sort.Strings(paths)
+
+	var notes []string
+	anchored := make([]FeedbackItem, 0, len(items))
+
+	for i, item := range items {
+		segments := snippetSegments(item.CodeSnippet)
+
+		filePath, ok := resolveFeedbackPath(item.FilePath, paths, snippetOnly)
+		if !ok {
+			filePath, ok = findSnippetFile(segments, paths, lines)
+			if !ok {
+				if item.FilePath != "" {
+					notes = append(notes, fmt.Sprintf("feedbacks[%d] dropped: file %q is not part of the review", i, item.FilePath))
+					continue
+				}
+				// A remark about the whole project, not a specific file.
+				item.LineStart = max(item.LineStart, 1)
+				item.LineEnd = max(item.LineEnd, item.LineStart)
+				item.Anchored = false
+				anchored = append(anchored, item)
+				continue
+			}
+		}
+		if filePath != item.FilePath {
+			notes = append(notes, fmt.Sprintf("feedbacks[%d].file_path %q resolved to %q", i, item.FilePath, filePath))
+			item.FilePath = filePath
+		}
+
+		fileLines := lines[filePath]
+		start, end, found := locateSnippet(fileLines, segments, item.LineStart)
+		if found {
+			if start != item.LineStart || end != item.LineEnd {
+				notes = append(notes, fmt.Sprintf("feedbacks[%d] lines %d-%d moved to %d-%d", i, item.LineStart, item.LineEnd, start, end))
+			}
+			item.LineStart, item.LineEnd = start, end
+			item.Anchored = true
+			anchored = append(anchored, item)
+			continue
+		}
+
+		n := max(len(fileLines), 1)
+		item.LineStart = min(max(item.LineStart, 1), n)
+		item.LineEnd = min(max(item.LineEnd, item.LineStart), n)
+		notes = append(notes, fmt.Sprintf("feedbacks[%d] snippet not found in %q, lines %d-%d not anchored", i, filePath, item.LineStart, item.LineEnd))
+		item.Anchored = false
+		anchored = append(anchored, item)
+	}
+
+	return anchored, notes
+}
+
+// resolveFeedbackPath maps a path reported by the model to a reviewed file.
+// Models often prefix paths with "./", the project folder or the clone
+// directory, or leave out the leading directories.
+func resolveFeedbackPath(reported string, paths []string, snippetOnly bool) (string, bool) {
+	if snippetOnly {
+		return "", true
+	}
+
+	p := strings.TrimSpace(strings.ReplaceAll(reported, "\\", "/"))
+	if p == "" {
+		return "", false
+	}
+	p = strings.TrimPrefix(path.Clean("/"+p), "/")
+
+	var candidates []string
+	for _, filePath := range paths {
+		switch {
+		case filePath == p:
+			return filePath, true
+		case strings.HasSuffix(p, "/"+filePath), strings.HasSuffix(filePath, "/"+p):
+			candidates = append(candidates, filePath)
+		}
+	}
+	if len(candidates) == 1 {
+		return candidates[0], true
+	}
+	return "", false
+}
+
+// findSnippetFile returns the only reviewed file containing the snippet.
+func findSnippetFile(segments [][]string, paths []string, lines map[string][]string) (string, bool) {
+	if len(segments) == 0 {
+		return "", false
+	}
+
+	found := ""
+	for _, filePath := range paths {
+		if _, _, ok := locateSnippet(lines[filePath], segments, 1); ok {
+			if found != "" {
+				return "", false
+			}
+			found = filePath
+		}
+	}
+	return found, found != ""
+}
+
+// locateSnippet finds the snippet in the file and returns its 1-based line
+// range. Of several occurrences, the one starting closest to hint is used.
+func locateSnippet(fileLines []string, segments [][]string, hint int) (int, int, bool) {
+	if len(segments) == 0 {
+		return 0, 0, false
+	}
+
+	bestStart, bestEnd, found := 0, 0, false
+	for s := range fileLines {
+		end, ok := matchSegment(fileLines, segments[0], s, minSnippetLineLength)
+		if !ok {
+			continue
+		}
+		for _, segment := range segments[1:] {
+			end, ok = findSegmentAfter(fileLines, segment, end+1)
+			if !ok {
+				break
+			}
+		}
+		if !ok {
+			continue
+		}
+
+		if !found || abs(s+1-hint) < abs(bestStart-hint) {
+			bestStart, bestEnd, found = s+1, end+1, true
+		}
+	}
+	return bestStart, bestEnd, found
+}
+
+func findSegmentAfter(fileLines []string, segment []string, from int) (int, bool) {
+	for s := from; s < len(fileLines) && s < from+maxSnippetGap; s++ {
+		// Later parts are anchored by the first, so a lone "}" is enough.
+		if end, ok := matchSegment(fileLines, segment, s, 1); ok {
+			return end, true
+		}
+	}
+	return 0, false
+}
+
+// matchSegment matches consecutive snippet lines starting at file line s,
+// skipping blank lines in the file. The first and last lines may be cut off
+// at the front and back, and a one-line snippet of at least minLength may
+// be part of a line. It returns the index of the last matched line.
+func matchSegment(fileLines []string, segment []string, s, minLength int) (int, bool) {
+	j := s
+	for idx, want := range segment {
+		if idx > 0 {
+			for j < len(fileLines) && fileLines[j] == "" {
+				j++
+			}
+		}
+		if j >= len(fileLines) {
+			return 0, false
+		}
+
+		got := fileLines[j]
+		var ok bool
+		switch {
+		case got == "":
+			ok = false
+		case len(segment) == 1:
+			ok = len(want) >= minLength && strings.Contains(got, want)
+		case idx == 0:
+			ok = strings.HasSuffix(got, want)
+		case idx == len(segment)-1:
+			ok = strings.HasPrefix(got, want)
+		default:
+			ok = got == want
+		}
+		if !ok {
+			return 0, false
+		}
+		j++
+	}
+	return j - 1, true
+}
+
+// snippetSegments splits a snippet into its non-blank lines, normalised like
+// the file lines. Lines with "..." separate parts the model left out.
+func snippetSegments(snippet string) [][]string {
+	var segments [][]string
+	var current []string
+	for _, line := range strings.Split(snippet, "\n") {
+		n := normalizeCodeLine(line)
+		switch {
+		case n == "", strings.HasPrefix(n, "```"):
+			continue
+		case isEllipsisLine(n):
+			if len(current) > 0 {
+				segments = append(segments, current)
+				current = nil
+			}
+			continue
+		}
+		current = append(current, n)
+	}
+	if len(current) > 0 {
+		segments = append(segments, current)
+	}
+	return segments
+}
+
+func normalizedLines(content string) []string {
+	lines := strings.Split(content, "\n")
+	for i, line := range lines {
+		lines[i] = normalizeCodeLine(line)
+	}
+	return lines
+}
+
+// normalizeCodeLine ignores indentation and spacing, which models rarely
+// copy exactly.
+func normalizeCodeLine(line string) string {
+	return strings.Join(strings.Fields(line), " ")
+}
+
+func isEllipsisLine(n string) bool {
+	switch strings.Trim(n, "/* ") {
+	case "...", "…":
+		return true
+	}
+	return false
+}
+
+func hasKey(m map[string]string, key string) bool {
+	_, ok := m[key]
+	return ok
+}
+
+func abs(v int) int {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
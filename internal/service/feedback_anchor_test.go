@@ -0,0 +1,250 @@
+package service
+
+import (
+	"reflect"
+	"testing"
+)
+
+const anchorMainDart = `import 'package:flutter/material.dart';
+
+void main() {
+  runApp(const App());
+}
+
+class App extends StatelessWidget {
+  const App({super.key});
+
+  @override
+  Widget build(BuildContext context) {
+    print('building');
+    return MaterialApp(
+      home: Scaffold(
+        body: Center(child: Text('Hello')),
+      ),
+    );
+  }
+}
+`
+
+const anchorCounterDart = `class Counter {
+  int value = 0;
+
+  void increment() {
+    value++;
+  }
+
+  void decrement() {
+    value++;
+  }
+}
+`
+
+func anchorFiles() map[string]string {
+	return map[string]string{
+		"lib/main.dart":             anchorMainDart,
+		"lib/src/counter.dart":      anchorCounterDart,
+		"lib/src/widgets/card.dart": "class InfoCard {}\n",
+	}
+}
+
+func TestResolveFeedbackPath(t *testing.T) {
+	paths := []string{"lib/main.dart", "lib/src/counter.dart", "test/main.dart"}
+
+	tests := []struct {
+		name     string
+		reported string
+		want     string
+		ok       bool
+	}{
+		{name: "exact", reported: "lib/src/counter.dart", want: "lib/src/counter.dart", ok: true},
+		{name: "dot slash prefix", reported: "./lib/src/counter.dart", want: "lib/src/counter.dart", ok: true},
+		{name: "backslashes", reported: `lib\src\counter.dart`, want: "lib/src/counter.dart", ok: true},
+		{name: "project folder prefix", reported: "my_app/lib/src/counter.dart", want: "lib/src/counter.dart", ok: true},
+		{name: "clone directory prefix", reported: "/tmp/clone-123/lib/src/counter.dart", want: "lib/src/counter.dart", ok: true},
+		{name: "leading directories left out", reported: "src/counter.dart", want: "lib/src/counter.dart", ok: true},
+		{name: "file name only", reported: "counter.dart", want: "lib/src/counter.dart", ok: true},
+		{name: "ambiguous suffix", reported: "main.dart", ok: false},
+		{name: "partial name is not a suffix", reported: "ounter.dart", ok: false},
+		{name: "unknown file", reported: "lib/other.dart", ok: false},
+		{name: "empty", reported: "  ", ok: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := resolveFeedbackPath(tt.reported, paths, false)
+			if ok != tt.ok || got != tt.want {
+				t.Errorf("resolveFeedbackPath(%q) = %q, %t; want %q, %t", tt.reported, got, ok, tt.want, tt.ok)
+			}
+		})
+	}
+}
+
+func TestSnippetSegments(t *testing.T) {
+	tests := []struct {
+		name    string
+		snippet string
+		want    [][]string
+	}{
+		{name: "single line", snippet: "  print('x');  ", want: [][]string{{"print('x');"}}},
+		{name: "blank lines and fences dropped", snippet: "```dart\nvoid f() {\n\n  g();\n}\n```", want: [][]string{{"void f() {", "g();", "}"}}},
+		{name: "ellipsis splits", snippet: "void f() {\n  ...\n}", want: [][]string{{"void f() {"}, {"}"}}},
+		{name: "commented ellipsis", snippet: "a();\n// ...\nb();\n/* … */\nc();", want: [][]string{{"a();"}, {"b();"}, {"c();"}}},
+		{name: "leading ellipsis", snippet: "...\nreturn x;", want: [][]string{{"return x;"}}},
+		{name: "empty", snippet: "\n \n", want: nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := snippetSegments(tt.snippet); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("snippetSegments(%q) = %q, want %q", tt.snippet, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAnchorFeedback(t *testing.T) {
+	tests := []struct {
+		name      string
+		item      FeedbackItem
+		dropped   bool
+		wantPath  string
+		wantStart int
+		wantEnd   int
+		anchored  bool
+	}{
+		{
+			name:      "lines corrected to the snippet",
+			item:      FeedbackItem{FilePath: "lib/main.dart", LineStart: 3, LineEnd: 3, CodeSnippet: "print('building');"},
+			wantPath:  "lib/main.dart",
+			wantStart: 12, wantEnd: 12, anchored: true,
+		},
+		{
+			name:      "indentation ignored",
+			item:      FeedbackItem{FilePath: "lib/main.dart", LineStart: 11, LineEnd: 12, CodeSnippet: "Widget build(BuildContext context) {\nprint('building');"},
+			wantPath:  "lib/main.dart",
+			wantStart: 11, wantEnd: 12, anchored: true,
+		},
+		{
+			name: "elided snippet spans the left-out lines",
+			item: FeedbackItem{
+				FilePath:    "lib/main.dart",
+				LineStart:   11,
+				LineEnd:     11,
+				CodeSnippet: "Widget build(BuildContext context) {\n  ...\n  );\n}",
+			},
+			wantPath:  "lib/main.dart",
+			wantStart: 11, wantEnd: 18, anchored: true,
+		},
+		{
+			name: "elided snippet parts must appear in order",
+			item: FeedbackItem{
+				FilePath:    "lib/main.dart",
+				LineStart:   4,
+				LineEnd:     4,
+				CodeSnippet: "print('building');\n...\nvoid main() {",
+			},
+			wantPath:  "lib/main.dart",
+			wantStart: 4, wantEnd: 4, anchored: false,
+		},
+		{
+			name:      "closest occurrence to the reported lines wins",
+			item:      FeedbackItem{FilePath: "lib/src/counter.dart", LineStart: 8, LineEnd: 10, CodeSnippet: "value++;"},
+			wantPath:  "lib/src/counter.dart",
+			wantStart: 9, wantEnd: 9, anchored: true,
+		},
+		{
+			name:      "path resolved by suffix",
+			item:      FeedbackItem{FilePath: "app/lib/src/counter.dart", LineStart: 1, LineEnd: 1, CodeSnippet: "void decrement() {"},
+			wantPath:  "lib/src/counter.dart",
+			wantStart: 8, wantEnd: 8, anchored: true,
+		},
+		{
+			name:      "path resolved by the snippet",
+			item:      FeedbackItem{FilePath: "lib/counter.dart", LineStart: 1, LineEnd: 1, CodeSnippet: "int value = 0;"},
+			wantPath:  "lib/src/counter.dart",
+			wantStart: 2, wantEnd: 2, anchored: true,
+		},
+		{
+			name:    "unknown file dropped",
+			item:    FeedbackItem{FilePath: "lib/missing.dart", LineStart: 1, LineEnd: 1, CodeSnippet: "nowhere();"},
+			dropped: true,
+		},
+		{
+			name:      "project remark kept",
+			item:      FeedbackItem{LineStart: 0, LineEnd: 0, Description: "Add tests"},
+			wantStart: 1, wantEnd: 1, anchored: false,
+		},
+		{
+			name:      "short snippet not matched inside a line",
+			item:      FeedbackItem{FilePath: "lib/src/counter.dart", LineStart: 5, LineEnd: 5, CodeSnippet: "}"},
+			wantPath:  "lib/src/counter.dart",
+			wantStart: 5, wantEnd: 5, anchored: false,
+		},
+		{
+			name:      "not found past the end clamped",
+			item:      FeedbackItem{FilePath: "lib/src/widgets/card.dart", LineStart: 40, LineEnd: 45, CodeSnippet: "missing();"},
+			wantPath:  "lib/src/widgets/card.dart",
+			wantStart: 2, wantEnd: 2, anchored: false,
+		},
+		{
+			name:      "not found with start past the end clamped",
+			item:      FeedbackItem{FilePath: "lib/src/counter.dart", LineStart: 50, LineEnd: 3, CodeSnippet: "missing();"},
+			wantPath:  "lib/src/counter.dart",
+			wantStart: 12, wantEnd: 12, anchored: false,
+		},
+		{
+			name:      "not found with reversed lines",
+			item:      FeedbackItem{FilePath: "lib/src/counter.dart", LineStart: 6, LineEnd: 2, CodeSnippet: "missing();"},
+			wantPath:  "lib/src/counter.dart",
+			wantStart: 6, wantEnd: 6, anchored: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, notes := AnchorFeedback(anchorFiles(), []FeedbackItem{tt.item})
+
+			if tt.dropped {
+				if len(got) != 0 {
+					t.Fatalf("AnchorFeedback() kept %+v, want it dropped", got[0])
+				}
+				if len(notes) != 1 {
+					t.Errorf("notes = %q, want one note for the dropped item", notes)
+				}
+				return
+			}
+			if len(got) != 1 {
+				t.Fatalf("AnchorFeedback() returned %d items, want 1", len(got))
+			}
+
+			item := got[0]
+			if item.FilePath != tt.wantPath {
+				t.Errorf("FilePath = %q, want %q", item.FilePath, tt.wantPath)
+			}
+			if item.LineStart != tt.wantStart || item.LineEnd != tt.wantEnd {
+				t.Errorf("lines = %d-%d, want %d-%d", item.LineStart, item.LineEnd, tt.wantStart, tt.wantEnd)
+			}
+			if item.LineStart > item.LineEnd {
+				t.Errorf("LineStart %d is after LineEnd %d", item.LineStart, item.LineEnd)
+			}
+			if item.Anchored != tt.anchored {
+				t.Errorf("Anchored = %t, want %t", item.Anchored, tt.anchored)
+			}
+		})
+	}
+}
+
+func TestAnchorFeedbackSnippetOnly(t *testing.T) {
+	files := map[string]string{"": "void f() {\n  g();\n}\n"}
+	items := []FeedbackItem{
+		{FilePath: "main.dart", LineStart: 1, LineEnd: 1, CodeSnippet: "g();"},
+	}
+
+	got, _ := AnchorFeedback(files, items)
+	if len(got) != 1 {
+		t.Fatalf("AnchorFeedback() returned %d items, want 1", len(got))
+	}
+	if got[0].FilePath != "" || got[0].LineStart != 2 || got[0].LineEnd != 2 || !got[0].Anchored {
+		t.Errorf("AnchorFeedback() = %+v, want line 2 of the pasted code, anchored", got[0])
+	}
+}
@@ -0,0 +1,97 @@
+package service
+
+import (
+	"fmt"
+	"path"
+	"strings"
+)
+
+// projectMetadataFiles are always sent along with the code: they describe
+// dependencies, lints and platform setup the reviewer needs to judge the
+// project, and task patterns cannot exclude them.
+var projectMetadataFiles = map[string]bool{
+	"pubspec.yaml":                             true,
+	"analysis_options.yaml":                    true,
+	"l10n.yaml":                                true,
+	"android/build.gradle":                     true,
+	"android/build.gradle.kts":                 true,
+	"android/app/build.gradle":                 true,
+	"android/app/build.gradle.kts":             true,
+	"android/app/src/main/AndroidManifest.xml": true,
+	"ios/Podfile":                              true,
+	"ios/Runner/Info.plist":                    true,
+}
+
+var skippedDirs = map[string]bool{
+	".git":         true,
+	"build":        true,
+	".dart_tool":   true,
+	"node_modules": true,
+	".idea":        true,
+	".fvm":         true,
+}
+
+// ValidateGlobPattern reports whether pattern can be used in task file
+// selection rules.
+func ValidateGlobPattern(pattern string) error {
+	if strings.TrimSpace(pattern) == "" {
+		return fmt.Errorf("pattern is empty")
+	}
+	if strings.HasPrefix(pattern, "/") {
+		return fmt.Errorf("pattern must be relative to the repository root")
+	}
+	for _, segment := range strings.Split(pattern, "/") {
+		if segment == "**" {
+			continue
+		}
+		if _, err := path.Match(segment, ""); err != nil {
+			return fmt.Errorf("invalid pattern segment %q: %w", segment, err)
+		}
+	}
+	return nil
+}
+
+// matchGlob matches a slash-separated path against a pattern where "**"
+// stands for any number of directories and the other segments follow
+// path.Match. A pattern that ends in a directory matches everything below it.
+func matchGlob(pattern, filePath string) bool {
+	patternParts := strings.Split(strings.TrimSuffix(pattern, "/"), "/")
+	pathParts := strings.Split(filePath, "/")
+	return matchSegments(patternParts, pathParts)
+}
+
+func matchSegments(pattern, parts []string) bool {
+	for len(pattern) > 0 {
+		if pattern[0] == "**" {
+			if len(pattern) == 1 {
+				return true
+			}
+			for i := 0; i <= len(parts); i++ {
+				if matchSegments(pattern[1:], parts[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+
+		if len(parts) == 0 {
+			return false
+		}
+		if ok, _ := path.Match(pattern[0], parts[0]); !ok {
+			return false
+		}
+		pattern, parts = pattern[1:], parts[1:]
+	}
+
+	// Remaining parts are below a matched directory.
+	return true
+}
+
+func matchAnyGlob(patterns []string, filePath string) bool {
+	for _, pattern := range patterns {
+		if matchGlob(pattern, filePath) {
+			return true
+		}
+	}
+	return false
+}
@@ -1,6 +1,8 @@
 package service
 
 import (
+	"context"
+
 	"github.com/ilyin-ad/flutter-code-mentor/internal/config"
 	"go.uber.org/fx"
 	"go.uber.org/zap"
@@ -10,12 +12,39 @@ func FxModule() fx.Option {
 	return fx.Module(
 		"service",
 		fx.Provide(
-			func(cfg *config.Config, logger *zap.Logger) AIService {
-				return NewAIService(cfg.DeepSeekAPIKey, cfg.DeepSeekAPIURL, logger)
+			func(cfg *config.Config) *CircuitBreaker {
+				return NewCircuitBreaker(cfg.AI.BreakerThreshold, cfg.AI.BreakerCooldown)
+			},
+			func(breaker *CircuitBreaker) ProviderHealth {
+				return breaker
+			},
+			func(cfg *config.Config, breaker *CircuitBreaker, logger *zap.Logger) (LLMProvider, error) {
+				return NewLLMProvider(cfg.AI, breaker, logger)
+			},
+			NewAIService,
+			NewTokenService,
+			func(cfg *config.Config) RepositorySources {
+				return NewRepositorySources(cfg.Repository)
 			},
-			func(logger *zap.Logger) GitHubService {
-				return NewGitHubService(logger)
+			NewGitHubService,
+			func(cfg *config.Config) (DartLinter, error) {
+				return NewDartLinter(cfg.Lint)
 			},
 		),
+		fx.Invoke(func(lc fx.Lifecycle, githubService GitHubService, logger *zap.Logger) {
+			lc.Append(fx.Hook{
+				OnStart: func(ctx context.Context) error {
+					removed, err := githubService.ReclaimWorkspaces()
+					if err != nil {
+						logger.Warn("Failed to reclaim orphaned clone workspaces", zap.Error(err))
+						return nil
+					}
+					if removed > 0 {
+						logger.Info("Reclaimed orphaned clone workspaces", zap.Int("count", removed))
+					}
+					return nil
+				},
+			})
+		}),
 	)
 }
@@ -2,66 +2,293 @@ package service
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"os"
-	"os/exec"
 	"path/filepath"
+	"regexp"
+	"sort"
 	"strings"
+	"sync"
 
+	"github.com/ilyin-ad/flutter-code-mentor/internal/config"
+	"github.com/ilyin-ad/flutter-code-mentor/internal/domain"
 	"go.uber.org/zap"
 )
 
+var (
+	ErrRefNotFound = errors.New("branch, tag or commit not found")
+
+	commitSHAPattern = regexp.MustCompile(`^[0-9a-fA-F]{40}([0-9a-fA-F]{24})?$`)
+	gitRefPattern    = regexp.MustCompile(`^[A-Za-z0-9._/-]+$`)
+)
+
+// ValidateGitRef rejects refs that are not plain branch, tag or commit
+// names, so they cannot be mistaken for git options.
+func ValidateGitRef(ref string) error {
+	switch {
+	case len(ref) > 255:
+		return fmt.Errorf("ref is longer than 255 characters")
+	case !gitRefPattern.MatchString(ref):
+		return fmt.Errorf("ref may only contain letters, digits, '.', '_', '-' and '/'")
+	case strings.HasPrefix(ref, "-"), strings.HasPrefix(ref, "/"), strings.HasSuffix(ref, "/"),
+		strings.Contains(ref, ".."), strings.Contains(ref, "//"), strings.HasSuffix(ref, ".lock"):
+		return fmt.Errorf("ref is not a valid branch, tag or commit name")
+	}
+	return nil
+}
+
 type GitHubService interface {
-	CloneRepository(ctx context.Context, githubURL string) (string, error)
-	GetDartFiles(repoPath string) ([]string, error)
+	ResolveRef(ctx context.Context, repo *RepositoryURL, ref string) (string, error)
+	CloneRepository(ctx context.Context, repo *RepositoryURL, commitSHA string) (string, error)
+	ExtractArchive(content []byte, name string) (workspace string, root string, err error)
+	SelectFiles(repoPath string, rules domain.FileSelectionRules) ([]string, error)
 	ReadFile(filePath string) (string, error)
 	Cleanup(repoPath string) error
+	ReclaimWorkspaces() (int, error)
 }
 
 type githubService struct {
-	logger  *zap.Logger
-	tempDir string
+	logger    *zap.Logger
+	tempDir   string
+	defaults  config.ReviewFilesConfig
+	workspace config.WorkspaceConfig
+	archive   config.ArchiveConfig
+
+	mu       sync.Mutex
+	reserved map[string]int64
 }
 
-func NewGitHubService(logger *zap.Logger) GitHubService {
-	tempDir := filepath.Join(os.TempDir(), "flutter-code-mentor")
-	os.MkdirAll(tempDir, 0755)
+func NewGitHubService(cfg *config.Config, logger *zap.Logger) GitHubService {
+	tempDir := cfg.Workspace.Dir
+	if tempDir == "" {
+		tempDir = filepath.Join(os.TempDir(), "flutter-code-mentor")
+	}
+	os.MkdirAll(tempDir, 0700)
 
 	return &githubService{
-		logger:  logger,
-		tempDir: tempDir,
+		logger:    logger,
+		tempDir:   tempDir,
+		defaults:  cfg.ReviewFiles,
+		workspace: cfg.Workspace,
+		archive:   cfg.Archive,
+		reserved:  make(map[string]int64),
 	}
 }
 
-func (s *githubService) CloneRepository(ctx context.Context, githubURL string) (string, error) {
-	s.logger.Info("Cloning GitHub repository", zap.String("url", githubURL))
+// ResolveRef returns the commit SHA a branch, tag or full commit SHA points
+// to right now. An empty ref resolves the default branch. A commit SHA must
+// already be on the remote, so a commit pushed after the deadline cannot be
+// pinned in advance.
+func (s *githubService) ResolveRef(ctx context.Context, repo *RepositoryURL, ref string) (string, error) {
+	if commitSHAPattern.MatchString(ref) {
+		sha := strings.ToLower(ref)
+		if err := s.verifyRemoteCommit(ctx, repo, sha); err != nil {
+			return "", err
+		}
+		return sha, nil
+	}
+
+	// Candidates in the order git resolves an ambiguous name: peeled
+	// annotated tags, tags, then branches.
+	var candidates []string
+	switch {
+	case ref == "":
+		candidates = []string{"HEAD"}
+	case strings.HasPrefix(ref, "refs/"):
+		candidates = []string{ref + "^{}", ref}
+	default:
+		candidates = []string{"refs/tags/" + ref + "^{}", "refs/tags/" + ref, "refs/heads/" + ref}
+	}
+
+	if s.workspace.CloneTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, s.workspace.CloneTimeout)
+		defer cancel()
+	}
+
+	output, err := s.git(ctx, "", repo, append([]string{"ls-remote", "--", repo.CloneURL}, candidates...)...)
+	if err != nil {
+		return "", fmt.Errorf("failed to list remote refs: %w", err)
+	}
+
+	refs := make(map[string]string)
+	for _, line := range strings.Split(output, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 2 {
+			refs[fields[1]] = fields[0]
+		}
+	}
+
+	for _, name := range candidates {
+		if sha, ok := refs[name]; ok {
+			s.logger.Info("Resolved repository ref",
+				zap.String("url", repo.URL),
+				zap.String("ref", ref),
+				zap.String("commit_sha", sha),
+			)
+			return sha, nil
+		}
+	}
 
-	repoName := s.extractRepoName(githubURL)
-	repoPath := filepath.Join(s.tempDir, repoName)
+	return "", fmt.Errorf("%w: %q", ErrRefNotFound, ref)
+}
 
-	if _, err := os.Stat(repoPath); err == nil {
-		s.logger.Info("Repository already exists, removing old clone", zap.String("path", repoPath))
+// verifyRemoteCommit fetches the commit into a scratch repository without
+// blobs. Servers that refuse to fetch a bare SHA are asked for all branches
+// and tags instead; the commit must then be among what they brought.
+func (s *githubService) verifyRemoteCommit(ctx context.Context, repo *RepositoryURL, sha string) error {
+	scratch, err := s.newWorkspace(repo.Name, int64(s.workspace.CloneMaxSizeMB)<<20)
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(scratch)
+	defer s.releaseWorkspace(scratch)
+
+	err = s.withCloneLimits(ctx, scratch, func(ctx context.Context) error {
+		if _, err := s.git(ctx, scratch, repo, "init", "--quiet", "--bare"); err != nil {
+			return err
+		}
+
+		if _, err := s.git(ctx, scratch, repo, "fetch", "--quiet", "--depth", "1", "--filter=blob:none",
+			"--no-recurse-submodules", "--", repo.CloneURL, sha); err == nil {
+			return nil
+		} else if ctx.Err() != nil {
+			return err
+		}
+
+		if _, err := s.git(ctx, scratch, repo, "fetch", "--quiet", "--filter=blob:none", "--no-recurse-submodules",
+			"--", repo.CloneURL, "+refs/heads/*:refs/heads/*", "+refs/tags/*:refs/tags/*"); err != nil {
+			return err
+		}
+		if _, err := s.git(ctx, scratch, repo, "cat-file", "-e", sha+"^{commit}"); err != nil {
+			return fmt.Errorf("%w: commit %s is not on the remote", ErrRefNotFound, sha)
+		}
+		return nil
+	})
+	if err != nil {
+		if errors.Is(err, ErrRefNotFound) {
+			return err
+		}
+		return fmt.Errorf("failed to verify commit on the remote: %w", err)
+	}
+
+	s.logger.Info("Verified commit on the remote",
+		zap.String("url", repo.URL),
+		zap.String("commit_sha", sha),
+	)
+	return nil
+}
+
+// CloneRepository checks out the repository at commitSHA. It fetches only
+// that commit when the server allows it and falls back to a full clone.
+// An empty commitSHA clones the default branch, as for submissions made
+// before commits were pinned.
+func (s *githubService) CloneRepository(ctx context.Context, repo *RepositoryURL, commitSHA string) (string, error) {
+	s.logger.Info("Cloning repository",
+		zap.String("provider", repo.Provider),
+		zap.String("url", repo.URL),
+		zap.String("commit_sha", commitSHA),
+	)
+
+	repoPath, err := s.newWorkspace(repo.Name, int64(s.workspace.CloneMaxSizeMB)<<20)
+	if err != nil {
+		return "", err
+	}
+
+	err = s.withCloneLimits(ctx, repoPath, func(ctx context.Context) error {
+		return s.checkout(ctx, repo, commitSHA, repoPath)
+	})
+	s.releaseWorkspace(repoPath)
+	if err != nil {
 		os.RemoveAll(repoPath)
+		return "", fmt.Errorf("failed to clone repository: %w", err)
 	}
 
-	cmd := exec.CommandContext(ctx, "git", "clone", "--depth", "1", githubURL, repoPath)
+	s.logger.Info("Repository cloned successfully", zap.String("path", repoPath))
+	return repoPath, nil
+}
+
+func (s *githubService) checkout(ctx context.Context, repo *RepositoryURL, commitSHA, repoPath string) error {
+	if commitSHA == "" {
+		if _, err := s.git(ctx, "", repo, "clone", "--depth", "1", "--no-recurse-submodules", "--", repo.CloneURL, repoPath); err != nil {
+			return err
+		}
+		return s.updateSubmodules(ctx, repo, repoPath)
+	}
+
+	if _, err := s.git(ctx, repoPath, repo, "init", "--quiet"); err != nil {
+		return err
+	}
+	if _, err := s.git(ctx, repoPath, repo, "remote", "add", "origin", repo.CloneURL); err != nil {
+		return err
+	}
+
+	if _, err := s.git(ctx, repoPath, repo, "fetch", "--depth", "1", "--no-recurse-submodules", "origin", commitSHA); err != nil {
+		if ctx.Err() != nil {
+			return err
+		}
+		s.logger.Info("Fetching a single commit is not supported, fetching full history",
+			zap.String("url", repo.URL),
+		)
+		if _, err := s.git(ctx, repoPath, repo, "fetch", "--no-recurse-submodules", "origin"); err != nil {
+			return err
+		}
+	}
+
+	if _, err := s.git(ctx, repoPath, repo, "checkout", "--quiet", "--detach", commitSHA); err != nil {
+		return fmt.Errorf("commit %s is not available: %w", commitSHA, err)
+	}
+	return s.updateSubmodules(ctx, repo, repoPath)
+}
+
+// updateSubmodules fetches submodules only when enabled in the config; they
+// can point to arbitrary hosts, so they are skipped by default.
+func (s *githubService) updateSubmodules(ctx context.Context, repo *RepositoryURL, repoPath string) error {
+	if !s.workspace.CloneSubmodules {
+		return nil
+	}
+	_, err := s.git(ctx, repoPath, repo, "submodule", "update", "--init", "--recursive", "--depth", "1")
+	return err
+}
+
+func (s *githubService) git(ctx context.Context, dir string, repo *RepositoryURL, args ...string) (string, error) {
+	cmd := gitCommand(ctx, dir, repo.Provider == RepositoryLocal, args...)
+
 	output, err := cmd.CombinedOutput()
 	if err != nil {
-		s.logger.Error("Failed to clone repository",
+		s.logger.Error("Git command failed",
+			zap.String("command", args[0]),
 			zap.Error(err),
 			zap.String("output", string(output)),
 		)
-		return "", fmt.Errorf("failed to clone repository: %w", err)
+		return "", fmt.Errorf("git %s: %w", args[0], err)
 	}
-
-	s.logger.Info("Repository cloned successfully", zap.String("path", repoPath))
-	return repoPath, nil
+	return string(output), nil
 }
 
-func (s *githubService) GetDartFiles(repoPath string) ([]string, error) {
-	s.logger.Info("Searching for Dart files", zap.String("path", repoPath))
+// SelectFiles lists the repository files to review: Dart sources that pass
+// the task's include and exclude patterns, plus the project metadata files.
+// Generated code and files over the size limit are skipped, and when there
+// are more sources than the file limit the least important ones are dropped.
+func (s *githubService) SelectFiles(repoPath string, rules domain.FileSelectionRules) ([]string, error) {
+	s.logger.Info("Selecting repository files for review",
+		zap.String("path", repoPath),
+		zap.Strings("include_patterns", rules.IncludePatterns),
+		zap.Strings("exclude_patterns", rules.ExcludePatterns),
+	)
+
+	maxFileSize := int64(s.defaults.MaxFileSizeKB) * 1024
+	if rules.MaxFileSizeKB != nil {
+		maxFileSize = int64(*rules.MaxFileSizeKB) * 1024
+	}
+	maxFiles := s.defaults.MaxFiles
+	if rules.MaxFiles != nil {
+		maxFiles = *rules.MaxFiles
+	}
 
-	var dartFiles []string
+	var metadataFiles, sourceFiles []string
+	oversized := 0
 
 	err := filepath.Walk(repoPath, func(path string, info os.FileInfo, err error) error {
 		if err != nil {
@@ -69,21 +296,44 @@ func (s *githubService) GetDartFiles(repoPath string) ([]string, error) {
 		}
 
 		if info.IsDir() {
-			dirName := info.Name()
-			if dirName == ".git" || dirName == "build" || dirName == ".dart_tool" || dirName == "node_modules" {
+			if skippedDirs[info.Name()] {
 				return filepath.SkipDir
 			}
 			return nil
 		}
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+
+		relPath, err := filepath.Rel(repoPath, path)
+		if err != nil {
+			return err
+		}
+		relPath = filepath.ToSlash(relPath)
 
-		if strings.HasSuffix(path, ".dart") {
-			relPath, err := filepath.Rel(repoPath, path)
-			if err != nil {
-				return err
+		isMetadata := projectMetadataFiles[relPath]
+		if !isMetadata {
+			if !strings.HasSuffix(relPath, ".dart") || isGeneratedDartFile(relPath) {
+				return nil
+			}
+			if len(rules.IncludePatterns) > 0 && !matchAnyGlob(rules.IncludePatterns, relPath) {
+				return nil
+			}
+			if matchAnyGlob(rules.ExcludePatterns, relPath) {
+				return nil
 			}
-			dartFiles = append(dartFiles, relPath)
 		}
 
+		if maxFileSize > 0 && info.Size() > maxFileSize {
+			oversized++
+			return nil
+		}
+
+		if isMetadata {
+			metadataFiles = append(metadataFiles, relPath)
+		} else {
+			sourceFiles = append(sourceFiles, relPath)
+		}
 		return nil
 	})
 
@@ -91,8 +341,33 @@ func (s *githubService) GetDartFiles(repoPath string) ([]string, error) {
 		return nil, fmt.Errorf("failed to walk directory: %w", err)
 	}
 
-	s.logger.Info("Found Dart files", zap.Int("count", len(dartFiles)))
-	return dartFiles, nil
+	sort.Slice(sourceFiles, func(i, j int) bool {
+		pi, pj := filePriority(sourceFiles[i]), filePriority(sourceFiles[j])
+		if pi != pj {
+			return pi < pj
+		}
+		return sourceFiles[i] < sourceFiles[j]
+	})
+
+	dropped := 0
+	if maxFiles > 0 && len(sourceFiles) > maxFiles {
+		dropped = len(sourceFiles) - maxFiles
+		sourceFiles = sourceFiles[:maxFiles]
+	}
+
+	s.logger.Info("Selected repository files",
+		zap.Int("source_files", len(sourceFiles)),
+		zap.Int("metadata_files", len(metadataFiles)),
+		zap.Int("oversized_skipped", oversized),
+		zap.Int("over_limit_dropped", dropped),
+	)
+
+	if len(sourceFiles) == 0 {
+		return nil, nil
+	}
+
+	sort.Strings(metadataFiles)
+	return append(sourceFiles, metadataFiles...), nil
 }
 
 func (s *githubService) ReadFile(filePath string) (string, error) {
@@ -107,11 +382,3 @@ func (s *githubService) Cleanup(repoPath string) error {
 	s.logger.Info("Cleaning up repository", zap.String("path", repoPath))
 	return os.RemoveAll(repoPath)
 }
-
-func (s *githubService) extractRepoName(githubURL string) string {
-	parts := strings.Split(strings.TrimSuffix(githubURL, ".git"), "/")
-	if len(parts) > 0 {
-		return parts[len(parts)-1]
-	}
-	return "repo"
-}
@@ -0,0 +1,217 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ilyin-ad/flutter-code-mentor/internal/config"
+	"go.uber.org/zap"
+)
+
+// testRepo is a bare repository on disk with two commits on main and a tag
+// on the first one.
+type testRepo struct {
+	url    *RepositoryURL
+	work   string
+	first  string
+	second string
+}
+
+func runGit(t *testing.T, dir string, args ...string) string {
+	t.Helper()
+
+	cmd := exec.Command("git", append([]string{"-c", "user.name=Test", "-c", "user.email=test@example.com", "-c", "protocol.file.allow=always"}, args...)...)
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), "GIT_CONFIG_NOSYSTEM=1", "GIT_CONFIG_GLOBAL="+os.DevNull)
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("git %s: %v\n%s", strings.Join(args, " "), err, output)
+	}
+	return strings.TrimSpace(string(output))
+}
+
+func newTestRepo(t *testing.T) *testRepo {
+	t.Helper()
+
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+
+	root := t.TempDir()
+	bare := filepath.Join(root, "app.git")
+	work := filepath.Join(root, "work")
+
+	runGit(t, root, "init", "--quiet", "--bare", "--initial-branch=main", bare)
+	runGit(t, root, "clone", "--quiet", "file://"+bare, work)
+	runGit(t, work, "checkout", "--quiet", "-B", "main")
+
+	writeFile(t, filepath.Join(work, "lib", "main.dart"), "void main() {}\n")
+	runGit(t, work, "add", ".")
+	runGit(t, work, "commit", "--quiet", "-m", "first")
+	first := runGit(t, work, "rev-parse", "HEAD")
+	runGit(t, work, "tag", "-a", "v1", "-m", "v1")
+
+	writeFile(t, filepath.Join(work, "lib", "main.dart"), "void main() { print('v2'); }\n")
+	runGit(t, work, "commit", "--quiet", "-am", "second")
+	second := runGit(t, work, "rev-parse", "HEAD")
+
+	runGit(t, work, "push", "--quiet", "origin", "main", "v1")
+
+	sources := NewRepositorySources(config.RepositoryConfig{AllowLocalURLs: true})
+	repo, err := sources.Parse("file://" + bare)
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+
+	return &testRepo{url: repo, work: work, first: first, second: second}
+}
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func newTestGitHubService(t *testing.T) *githubService {
+	t.Helper()
+
+	cfg := &config.Config{
+		Workspace: config.WorkspaceConfig{
+			Dir:            t.TempDir(),
+			DiskQuotaMB:    100,
+			CloneMaxSizeMB: 50,
+			CloneTimeout:   30 * time.Second,
+		},
+	}
+	return NewGitHubService(cfg, zap.NewNop()).(*githubService)
+}
+
+func TestResolveRef(t *testing.T) {
+	repo := newTestRepo(t)
+	s := newTestGitHubService(t)
+	ctx := context.Background()
+
+	tests := []struct {
+		name string
+		ref  string
+		want string
+	}{
+		{name: "default branch", ref: "", want: repo.second},
+		{name: "branch", ref: "main", want: repo.second},
+		{name: "full ref", ref: "refs/heads/main", want: repo.second},
+		{name: "annotated tag is peeled", ref: "v1", want: repo.first},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := s.ResolveRef(ctx, repo.url, tt.ref)
+			if err != nil {
+				t.Fatalf("ResolveRef(%q): %v", tt.ref, err)
+			}
+			if got != tt.want {
+				t.Errorf("ResolveRef(%q) = %s, want %s", tt.ref, got, tt.want)
+			}
+		})
+	}
+
+	if _, err := s.ResolveRef(ctx, repo.url, "missing"); !errors.Is(err, ErrRefNotFound) {
+		t.Errorf("ResolveRef(missing) error = %v, want ErrRefNotFound", err)
+	}
+}
+
+func TestResolveRefCommitSHA(t *testing.T) {
+	repo := newTestRepo(t)
+	s := newTestGitHubService(t)
+	ctx := context.Background()
+
+	got, err := s.ResolveRef(ctx, repo.url, strings.ToUpper(repo.first))
+	if err != nil {
+		t.Fatalf("ResolveRef(pushed commit): %v", err)
+	}
+	if got != repo.first {
+		t.Errorf("ResolveRef(pushed commit) = %s, want %s", got, repo.first)
+	}
+
+	writeFile(t, filepath.Join(repo.work, "lib", "main.dart"), "void main() { print('late'); }\n")
+	runGit(t, repo.work, "commit", "--quiet", "-am", "not pushed")
+	unpushed := runGit(t, repo.work, "rev-parse", "HEAD")
+
+	if _, err := s.ResolveRef(ctx, repo.url, unpushed); !errors.Is(err, ErrRefNotFound) {
+		t.Errorf("ResolveRef(unpushed commit) error = %v, want ErrRefNotFound", err)
+	}
+
+	entries, err := os.ReadDir(s.tempDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 0 {
+		t.Errorf("commit checks left %d workspace(s) behind", len(entries))
+	}
+}
+
+func TestCloneRepository(t *testing.T) {
+	repo := newTestRepo(t)
+	s := newTestGitHubService(t)
+	ctx := context.Background()
+
+	tests := []struct {
+		name      string
+		commitSHA string
+		want      string
+	}{
+		{name: "pinned older commit", commitSHA: repo.first, want: "void main() {}\n"},
+		{name: "pinned head commit", commitSHA: repo.second, want: "void main() { print('v2'); }\n"},
+		{name: "default branch", commitSHA: "", want: "void main() { print('v2'); }\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path, err := s.CloneRepository(ctx, repo.url, tt.commitSHA)
+			if err != nil {
+				t.Fatalf("CloneRepository: %v", err)
+			}
+			defer s.Cleanup(path)
+
+			got, err := os.ReadFile(filepath.Join(path, "lib", "main.dart"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("lib/main.dart = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCloneRepositoryGatesFileProtocol(t *testing.T) {
+	repo := newTestRepo(t)
+	s := newTestGitHubService(t)
+
+	// A file:// clone URL behind a remote provider must not be followed.
+	remote := *repo.url
+	remote.Provider = RepositoryGitHub
+
+	if path, err := s.CloneRepository(context.Background(), &remote, ""); err == nil {
+		s.Cleanup(path)
+		t.Fatal("CloneRepository followed a file:// URL for a remote provider")
+	}
+
+	entries, err := os.ReadDir(s.tempDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 0 {
+		t.Errorf("failed clone left %d workspace(s) behind", len(entries))
+	}
+}
@@ -0,0 +1,106 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/ilyin-ad/flutter-code-mentor/internal/config"
+)
+
+const (
+	defaultAnthropicURL       = "https://api.anthropic.com/v1/messages"
+	defaultAnthropicModel     = "claude-3-5-sonnet-latest"
+	anthropicAPIVersion       = "2023-06-01"
+	anthropicDefaultMaxTokens = 8192
+)
+
+type anthropicProvider struct {
+	apiKey string
+	apiURL string
+	model  string
+	client *http.Client
+}
+
+type anthropicRequest struct {
+	Model     string    `json:"model"`
+	MaxTokens int       `json:"max_tokens"`
+	System    string    `json:"system,omitempty"`
+	Messages  []message `json:"messages"`
+}
+
+type anthropicResponse struct {
+	Content []struct {
+		Type string `json:"type"`
+		Text string `json:"text"`
+	} `json:"content"`
+	Usage struct {
+		InputTokens  int `json:"input_tokens"`
+		OutputTokens int `json:"output_tokens"`
+	} `json:"usage"`
+}
+
+func newAnthropicProvider(cfg config.AIConfig, client *http.Client) (LLMProvider, error) {
+	if cfg.APIKey == "" {
+		return nil, fmt.Errorf("anthropic provider requires AI_API_KEY")
+	}
+
+	return &anthropicProvider{
+		apiKey: cfg.APIKey,
+		apiURL: valueOrDefault(cfg.APIURL, defaultAnthropicURL),
+		model:  valueOrDefault(cfg.Model, defaultAnthropicModel),
+		client: client,
+	}, nil
+}
+
+func (p *anthropicProvider) Name() string {
+	return "anthropic"
+}
+
+func (p *anthropicProvider) Model() string {
+	return p.model
+}
+
+func (p *anthropicProvider) Complete(ctx context.Context, systemPrompt, userPrompt string) (*Completion, error) {
+	reqBody := anthropicRequest{
+		Model:     p.model,
+		MaxTokens: anthropicDefaultMaxTokens,
+		System:    systemPrompt,
+		Messages: []message{
+			{
+				Role:    "user",
+				Content: userPrompt,
+			},
+		},
+	}
+
+	headers := map[string]string{
+		"x-api-key":         p.apiKey,
+		"anthropic-version": anthropicAPIVersion,
+	}
+
+	var resp anthropicResponse
+	if err := postJSON(ctx, p.client, p.apiURL, headers, reqBody, &resp); err != nil {
+		return nil, err
+	}
+
+	var content strings.Builder
+	for _, block := range resp.Content {
+		if block.Type == "text" {
+			content.WriteString(block.Text)
+		}
+	}
+
+	if content.Len() == 0 {
+		return nil, fmt.Errorf("no response from AI")
+	}
+
+	return &Completion{
+		Content: content.String(),
+		Usage: TokenUsage{
+			PromptTokens:     resp.Usage.InputTokens,
+			CompletionTokens: resp.Usage.OutputTokens,
+		},
+	}, nil
+}
@@ -0,0 +1,87 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/ilyin-ad/flutter-code-mentor/internal/config"
+)
+
+const (
+	defaultOllamaURL   = "http://localhost:11434/api/chat"
+	defaultOllamaModel = "qwen2.5-coder"
+)
+
+// ollamaProvider talks to a local Ollama-style server, so no API key is needed.
+type ollamaProvider struct {
+	apiURL string
+	model  string
+	client *http.Client
+}
+
+type ollamaChatRequest struct {
+	Model    string    `json:"model"`
+	Messages []message `json:"messages"`
+	Stream   bool      `json:"stream"`
+	Format   string    `json:"format,omitempty"`
+}
+
+type ollamaChatResponse struct {
+	Message struct {
+		Content string `json:"content"`
+	} `json:"message"`
+	PromptEvalCount int `json:"prompt_eval_count"`
+	EvalCount       int `json:"eval_count"`
+}
+
+func newOllamaProvider(cfg config.AIConfig, client *http.Client) (LLMProvider, error) {
+	return &ollamaProvider{
+		apiURL: valueOrDefault(cfg.APIURL, defaultOllamaURL),
+		model:  valueOrDefault(cfg.Model, defaultOllamaModel),
+		client: client,
+	}, nil
+}
+
+func (p *ollamaProvider) Name() string {
+	return "ollama"
+}
+
+func (p *ollamaProvider) Model() string {
+	return p.model
+}
+
+func (p *ollamaProvider) Complete(ctx context.Context, systemPrompt, userPrompt string) (*Completion, error) {
+	reqBody := ollamaChatRequest{
+		Model: p.model,
+		Messages: []message{
+			{
+				Role:    "system",
+				Content: systemPrompt,
+			},
+			{
+				Role:    "user",
+				Content: userPrompt,
+			},
+		},
+		Stream: false,
+		Format: "json",
+	}
+
+	var resp ollamaChatResponse
+	if err := postJSON(ctx, p.client, p.apiURL, nil, reqBody, &resp); err != nil {
+		return nil, err
+	}
+
+	if resp.Message.Content == "" {
+		return nil, fmt.Errorf("no response from AI")
+	}
+
+	return &Completion{
+		Content: resp.Message.Content,
+		Usage: TokenUsage{
+			PromptTokens:     resp.PromptEvalCount,
+			CompletionTokens: resp.EvalCount,
+		},
+	}, nil
+}
@@ -0,0 +1,120 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/ilyin-ad/flutter-code-mentor/internal/config"
+)
+
+const (
+	defaultDeepSeekURL   = "https://api.deepseek.com/chat/completions"
+	defaultDeepSeekModel = "deepseek-chat"
+	defaultOpenAIURL     = "https://api.openai.com/v1/chat/completions"
+	defaultOpenAIModel   = "gpt-4o-mini"
+)
+
+// openAIProvider speaks the chat-completions format shared by OpenAI,
+// DeepSeek and most self-hosted gateways.
+type openAIProvider struct {
+	name   string
+	apiKey string
+	apiURL string
+	model  string
+	client *http.Client
+}
+
+type chatCompletionRequest struct {
+	Model    string    `json:"model"`
+	Messages []message `json:"messages"`
+	Stream   bool      `json:"stream"`
+}
+
+type message struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+type chatCompletionResponse struct {
+	Choices []struct {
+		Message struct {
+			Content string `json:"content"`
+		} `json:"message"`
+	} `json:"choices"`
+	Usage struct {
+		PromptTokens     int `json:"prompt_tokens"`
+		CompletionTokens int `json:"completion_tokens"`
+	} `json:"usage"`
+}
+
+func newDeepSeekProvider(cfg config.AIConfig, client *http.Client) (LLMProvider, error) {
+	if cfg.APIKey == "" {
+		return nil, fmt.Errorf("deepseek provider requires AI_API_KEY or DEEPSEEK_API_KEY")
+	}
+
+	return &openAIProvider{
+		name:   "deepseek",
+		apiKey: cfg.APIKey,
+		apiURL: valueOrDefault(cfg.APIURL, defaultDeepSeekURL),
+		model:  valueOrDefault(cfg.Model, defaultDeepSeekModel),
+		client: client,
+	}, nil
+}
+
+func newOpenAIProvider(cfg config.AIConfig, client *http.Client) (LLMProvider, error) {
+	return &openAIProvider{
+		name:   "openai",
+		apiKey: cfg.APIKey,
+		apiURL: valueOrDefault(cfg.APIURL, defaultOpenAIURL),
+		model:  valueOrDefault(cfg.Model, defaultOpenAIModel),
+		client: client,
+	}, nil
+}
+
+func (p *openAIProvider) Name() string {
+	return p.name
+}
+
+func (p *openAIProvider) Model() string {
+	return p.model
+}
+
+func (p *openAIProvider) Complete(ctx context.Context, systemPrompt, userPrompt string) (*Completion, error) {
+	reqBody := chatCompletionRequest{
+		Model: p.model,
+		Messages: []message{
+			{
+				Role:    "system",
+				Content: systemPrompt,
+			},
+			{
+				Role:    "user",
+				Content: userPrompt,
+			},
+		},
+		Stream: false,
+	}
+
+	headers := map[string]string{}
+	if p.apiKey != "" {
+		headers["Authorization"] = "Bearer " + p.apiKey
+	}
+
+	var resp chatCompletionResponse
+	if err := postJSON(ctx, p.client, p.apiURL, headers, reqBody, &resp); err != nil {
+		return nil, err
+	}
+
+	if len(resp.Choices) == 0 {
+		return nil, fmt.Errorf("no response from AI")
+	}
+
+	return &Completion{
+		Content: resp.Choices[0].Message.Content,
+		Usage: TokenUsage{
+			PromptTokens:     resp.Usage.PromptTokens,
+			CompletionTokens: resp.Usage.CompletionTokens,
+		},
+	}, nil
+}
@@ -0,0 +1,121 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/ilyin-ad/flutter-code-mentor/internal/config"
+	"go.uber.org/zap"
+)
+
+// LLMProvider hides the wire format of a concrete chat model API.
+type LLMProvider interface {
+	Name() string
+	Model() string
+	Complete(ctx context.Context, systemPrompt, userPrompt string) (*Completion, error)
+}
+
+// Completion is the answer of a model call. Usage is zero when the API does
+// not report token counts.
+type Completion struct {
+	Content string
+	Usage   TokenUsage
+}
+
+type TokenUsage struct {
+	PromptTokens     int
+	CompletionTokens int
+}
+
+func (u TokenUsage) Add(other TokenUsage) TokenUsage {
+	return TokenUsage{
+		PromptTokens:     u.PromptTokens + other.PromptTokens,
+		CompletionTokens: u.CompletionTokens + other.CompletionTokens,
+	}
+}
+
+type providerFactory func(cfg config.AIConfig, client *http.Client) (LLMProvider, error)
+
+var llmProviders = map[string]providerFactory{
+	"deepseek":  newDeepSeekProvider,
+	"openai":    newOpenAIProvider,
+	"ollama":    newOllamaProvider,
+	"anthropic": newAnthropicProvider,
+}
+
+// NewLLMProvider builds the configured provider behind a rate limiter,
+// retries and the circuit breaker.
+func NewLLMProvider(cfg config.AIConfig, breaker *CircuitBreaker, logger *zap.Logger) (LLMProvider, error) {
+	factory, ok := llmProviders[strings.ToLower(cfg.Provider)]
+	if !ok {
+		names := make([]string, 0, len(llmProviders))
+		for name := range llmProviders {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		return nil, fmt.Errorf("unknown AI provider %q, expected one of: %s", cfg.Provider, strings.Join(names, ", "))
+	}
+
+	client := &http.Client{
+		Timeout: cfg.Timeout,
+	}
+
+	provider, err := factory(cfg, client)
+	if err != nil {
+		return nil, err
+	}
+
+	return newResilientProvider(provider, cfg, breaker, logger), nil
+}
+
+func valueOrDefault(value, def string) string {
+	if value == "" {
+		return def
+	}
+	return value
+}
+
+func postJSON(ctx context.Context, client *http.Client, url string, headers map[string]string, reqBody any, respBody any) error {
+	jsonData, err := json.Marshal(reqBody)
+	if err != nil {
+		return fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(jsonData))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	for key, value := range headers {
+		req.Header.Set(key, value)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return &APIError{
+			StatusCode: resp.StatusCode,
+			RetryAfter: parseRetryAfter(resp.Header.Get("Retry-After"), time.Now()),
+			Body:       string(body),
+		}
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(respBody); err != nil {
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,359 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/ilyin-ad/flutter-code-mentor/internal/config"
+	"go.uber.org/zap"
+	"golang.org/x/time/rate"
+)
+
+// APIError is a non-200 answer of the provider. RetryAfter is zero when the
+// response carries no usable Retry-After header.
+type APIError struct {
+	StatusCode int
+	RetryAfter time.Duration
+	Body       string
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("API request failed with status %d: %s", e.StatusCode, e.Body)
+}
+
+// Transient reports whether the same request may succeed later.
+func (e *APIError) Transient() bool {
+	return e.StatusCode == http.StatusTooManyRequests || e.StatusCode >= http.StatusInternalServerError
+}
+
+// ProviderUnavailableError is returned without calling the provider while
+// the circuit breaker is open.
+type ProviderUnavailableError struct {
+	Until time.Time
+}
+
+func (e *ProviderUnavailableError) Error() string {
+	return fmt.Sprintf("AI provider is unavailable until %s", e.Until.Format(time.RFC3339))
+}
+
+// ProviderHealth tells whether AI calls are being refused, and until when.
+type ProviderHealth interface {
+	PausedUntil() (time.Time, bool)
+}
+
+type breakerState int
+
+const (
+	breakerClosed breakerState = iota
+	breakerOpen
+	breakerHalfOpen
+)
+
+// CircuitBreaker counts consecutive failed provider calls, a call with all
+// its retries counting once (see resilientProvider.Complete). At the
+// threshold it opens and refuses calls for the cooldown, then lets one probe
+// through: its success closes the breaker, its failure opens it again.
+type CircuitBreaker struct {
+	threshold int
+	cooldown  time.Duration
+	now       func() time.Time
+
+	mu        sync.Mutex
+	state     breakerState
+	failures  int
+	openUntil time.Time
+	probing   bool
+}
+
+func NewCircuitBreaker(threshold int, cooldown time.Duration) *CircuitBreaker {
+	return &CircuitBreaker{
+		threshold: max(threshold, 1),
+		cooldown:  cooldown,
+		now:       time.Now,
+	}
+}
+
+// Allow reserves a call, or returns a *ProviderUnavailableError.
+func (b *CircuitBreaker) Allow() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := b.now()
+	switch b.state {
+	case breakerOpen:
+		if now.Before(b.openUntil) {
+			return &ProviderUnavailableError{Until: b.openUntil}
+		}
+		b.state = breakerHalfOpen
+		b.probing = true
+		return nil
+	case breakerHalfOpen:
+		if b.probing {
+			return &ProviderUnavailableError{Until: now.Add(b.cooldown)}
+		}
+		b.probing = true
+		return nil
+	default:
+		return nil
+	}
+}
+
+func (b *CircuitBreaker) Success() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.state = breakerClosed
+	b.failures = 0
+	b.probing = false
+}
+
+// Failure records a failed call and reports whether it opened the breaker.
+func (b *CircuitBreaker) Failure() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.failures++
+	b.probing = false
+	if b.state == breakerOpen {
+		return false
+	}
+	if b.state == breakerHalfOpen || b.failures >= b.threshold {
+		b.state = breakerOpen
+		b.openUntil = b.now().Add(b.cooldown)
+		return true
+	}
+	return false
+}
+
+// Release returns a reserved call whose outcome says nothing about the
+// provider, such as one cancelled by the caller.
+func (b *CircuitBreaker) Release() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.probing = false
+}
+
+// PausedUntil reports the end of the cooldown while the breaker is open.
+func (b *CircuitBreaker) PausedUntil() (time.Time, bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.state == breakerOpen && b.now().Before(b.openUntil) {
+		return b.openUntil, true
+	}
+	return time.Time{}, false
+}
+
+// resilientProvider shapes the calls of all reviews to one provider: a
+// token bucket limits their rate, transient failures are retried with
+// backoff and the circuit breaker stops calls while the provider is down.
+type resilientProvider struct {
+	LLMProvider
+	limiter        *rate.Limiter
+	breaker        *CircuitBreaker
+	maxRetries     int
+	retryBaseDelay time.Duration
+	retryMaxDelay  time.Duration
+	logger         *zap.Logger
+
+	mu        sync.Mutex
+	notBefore time.Time
+}
+
+func newResilientProvider(provider LLMProvider, cfg config.AIConfig, breaker *CircuitBreaker, logger *zap.Logger) *resilientProvider {
+	limit := rate.Inf
+	if cfg.RequestsPerMinute > 0 {
+		limit = rate.Every(time.Minute / time.Duration(cfg.RequestsPerMinute))
+	}
+
+	return &resilientProvider{
+		LLMProvider:    provider,
+		limiter:        rate.NewLimiter(limit, max(cfg.RequestBurst, 1)),
+		breaker:        breaker,
+		maxRetries:     max(cfg.MaxRetries, 0),
+		retryBaseDelay: cfg.RetryBaseDelay,
+		retryMaxDelay:  cfg.RetryMaxDelay,
+		logger:         logger,
+	}
+}
+
+// Complete counts as one call for the circuit breaker, however often it is
+// retried: only a call that still fails once its retries are used up is a
+// failure. Throttling is left to the Retry-After hold and never opens the
+// breaker.
+func (p *resilientProvider) Complete(ctx context.Context, systemPrompt, userPrompt string) (*Completion, error) {
+	if err := p.breaker.Allow(); err != nil {
+		return nil, err
+	}
+
+	completion, err := p.completeWithRetries(ctx, systemPrompt, userPrompt)
+
+	var apiErr *APIError
+	isAPIErr := errors.As(err, &apiErr)
+	var unavailableErr *ProviderUnavailableError
+	switch {
+	case err == nil:
+		p.breaker.Success()
+	case ctx.Err() != nil, errors.As(err, &unavailableErr):
+		p.breaker.Release()
+	case isAPIErr && !apiErr.Transient():
+		// The provider answered; the request itself is at fault.
+		p.breaker.Success()
+	case isAPIErr && apiErr.StatusCode == http.StatusTooManyRequests:
+		p.breaker.Release()
+	default:
+		if p.breaker.Failure() {
+			p.logger.Warn("AI provider circuit breaker opened",
+				zap.String("provider", p.Name()),
+				zap.Error(err),
+			)
+		}
+	}
+
+	return completion, err
+}
+
+func (p *resilientProvider) completeWithRetries(ctx context.Context, systemPrompt, userPrompt string) (*Completion, error) {
+	for attempt := 0; ; attempt++ {
+		if err := p.wait(ctx); err != nil {
+			return nil, err
+		}
+
+		// Other calls may have opened the breaker while this one waited.
+		if until, paused := p.breaker.PausedUntil(); attempt > 0 && paused {
+			return nil, &ProviderUnavailableError{Until: until}
+		}
+
+		completion, err := p.LLMProvider.Complete(ctx, systemPrompt, userPrompt)
+		if err == nil {
+			return completion, nil
+		}
+		if ctx.Err() != nil {
+			return nil, err
+		}
+
+		var apiErr *APIError
+		isAPIErr := errors.As(err, &apiErr)
+		if isAPIErr && !apiErr.Transient() {
+			return nil, err
+		}
+
+		var retryAfter time.Duration
+		if isAPIErr {
+			retryAfter = apiErr.RetryAfter
+			if retryAfter > 0 {
+				p.holdUntil(time.Now().Add(retryAfter))
+			}
+		}
+
+		if attempt >= p.maxRetries || retryAfter > p.retryMaxDelay {
+			return nil, err
+		}
+
+		delay := retryAfter
+		if delay == 0 {
+			delay = p.backoff(attempt)
+		}
+
+		// The review has a deadline; a retry that cannot start before it
+		// would only hide the provider's error behind a timeout.
+		if deadline, ok := ctx.Deadline(); ok && time.Now().Add(delay).After(deadline) {
+			return nil, err
+		}
+
+		p.logger.Warn("AI provider call failed, retrying",
+			zap.String("provider", p.Name()),
+			zap.Int("attempt", attempt+1),
+			zap.Duration("delay", delay),
+			zap.Error(err),
+		)
+
+		if err := sleepContext(ctx, delay); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// wait blocks until a Retry-After of an earlier call has passed and the
+// token bucket admits the call.
+func (p *resilientProvider) wait(ctx context.Context) error {
+	p.mu.Lock()
+	notBefore := p.notBefore
+	p.mu.Unlock()
+
+	if err := sleepContext(ctx, time.Until(notBefore)); err != nil {
+		return err
+	}
+
+	return p.limiter.Wait(ctx)
+}
+
+// holdUntil delays all calls, not only the retry of the throttled one.
+func (p *resilientProvider) holdUntil(t time.Time) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if t.After(p.notBefore) {
+		p.notBefore = t
+	}
+}
+
+// backoff doubles the base delay per attempt up to the maximum and picks a
+// random delay in its upper half, so parallel reviews do not retry in step.
+func (p *resilientProvider) backoff(attempt int) time.Duration {
+	delay := p.retryBaseDelay
+	for i := 0; i < attempt && delay < p.retryMaxDelay; i++ {
+		delay *= 2
+	}
+	delay = min(delay, p.retryMaxDelay)
+	if delay <= 0 {
+		return 0
+	}
+
+	half := delay / 2
+	return half + rand.N(delay-half+1)
+}
+
+func sleepContext(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// parseRetryAfter reads a Retry-After header given in seconds or as an HTTP
+// date.
+func parseRetryAfter(value string, now time.Time) time.Duration {
+	if value == "" {
+		return 0
+	}
+
+	if seconds, err := strconv.Atoi(value); err == nil {
+		if seconds <= 0 {
+			return 0
+		}
+		return time.Duration(seconds) * time.Second
+	}
+
+	if at, err := http.ParseTime(value); err == nil && at.After(now) {
+		return at.Sub(now)
+	}
+
+	return 0
+}
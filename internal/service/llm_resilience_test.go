@@ -0,0 +1,372 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ilyin-ad/flutter-code-mentor/internal/config"
+	"go.uber.org/zap"
+)
+
+const okCompletion = `{"choices":[{"message":{"content":"ok"}}],"usage":{"prompt_tokens":3,"completion_tokens":1}}`
+
+// scriptedServer answers the i-th request with responses[i] and repeats the
+// last response after that. It counts the requests it served.
+type scriptedServer struct {
+	*httptest.Server
+	requests atomic.Int32
+}
+
+type scriptedResponse struct {
+	status int
+	header map[string]string
+}
+
+func newScriptedServer(t *testing.T, responses ...scriptedResponse) *scriptedServer {
+	t.Helper()
+
+	s := &scriptedServer{}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		i := int(s.requests.Add(1)) - 1
+		resp := responses[min(i, len(responses)-1)]
+		for key, value := range resp.header {
+			w.Header().Set(key, value)
+		}
+		w.WriteHeader(resp.status)
+		if resp.status == http.StatusOK {
+			w.Write([]byte(okCompletion))
+		} else {
+			w.Write([]byte(`{"error":"scripted"}`))
+		}
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func newTestResilientProvider(t *testing.T, url string, breaker *CircuitBreaker) *resilientProvider {
+	t.Helper()
+
+	cfg := config.AIConfig{
+		Provider:       "openai",
+		APIURL:         url,
+		Model:          "test-model",
+		Timeout:        5 * time.Second,
+		RequestBurst:   1,
+		MaxRetries:     2,
+		RetryBaseDelay: 20 * time.Millisecond,
+		RetryMaxDelay:  5 * time.Second,
+	}
+	if breaker == nil {
+		breaker = NewCircuitBreaker(10, time.Minute)
+	}
+
+	provider, err := NewLLMProvider(cfg, breaker, zap.NewNop())
+	if err != nil {
+		t.Fatalf("NewLLMProvider: %v", err)
+	}
+	return provider.(*resilientProvider)
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name  string
+		value string
+		want  time.Duration
+	}{
+		{name: "missing", value: "", want: 0},
+		{name: "seconds", value: "7", want: 7 * time.Second},
+		{name: "zero seconds", value: "0", want: 0},
+		{name: "negative seconds", value: "-3", want: 0},
+		{name: "HTTP date", value: now.Add(90 * time.Second).Format(http.TimeFormat), want: 90 * time.Second},
+		{name: "HTTP date in the past", value: now.Add(-time.Minute).Format(http.TimeFormat), want: 0},
+		{name: "garbage", value: "soon", want: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := parseRetryAfter(tt.value, now); got != tt.want {
+				t.Errorf("parseRetryAfter(%q) = %s, want %s", tt.value, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestResilientProviderHonoursRetryAfter(t *testing.T) {
+	tests := []struct {
+		name       string
+		retryAfter func() string
+		minWait    time.Duration
+	}{
+		{
+			name:       "seconds",
+			retryAfter: func() string { return "1" },
+			minWait:    time.Second,
+		},
+		{
+			// HTTP dates have a resolution of one second.
+			name:       "HTTP date",
+			retryAfter: func() string { return time.Now().Add(2 * time.Second).UTC().Format(http.TimeFormat) },
+			minWait:    time.Second,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := newScriptedServer(t,
+				scriptedResponse{status: http.StatusTooManyRequests, header: map[string]string{"Retry-After": tt.retryAfter()}},
+				scriptedResponse{status: http.StatusOK},
+			)
+			p := newTestResilientProvider(t, server.URL, nil)
+
+			start := time.Now()
+			completion, err := p.Complete(context.Background(), "system", "user")
+			if err != nil {
+				t.Fatalf("Complete: %v", err)
+			}
+			if elapsed := time.Since(start); elapsed < tt.minWait {
+				t.Errorf("retried after %s, want at least %s", elapsed, tt.minWait)
+			}
+			if completion.Content != "ok" || server.requests.Load() != 2 {
+				t.Errorf("got %q after %d requests, want \"ok\" after 2", completion.Content, server.requests.Load())
+			}
+
+			// The hold applies to every call, not only to the throttled one.
+			p.mu.Lock()
+			notBefore := p.notBefore
+			p.mu.Unlock()
+			if !notBefore.After(start) {
+				t.Errorf("Retry-After did not hold other calls")
+			}
+		})
+	}
+}
+
+func TestResilientProviderRetriesServerErrors(t *testing.T) {
+	server := newScriptedServer(t,
+		scriptedResponse{status: http.StatusServiceUnavailable},
+		scriptedResponse{status: http.StatusBadGateway},
+		scriptedResponse{status: http.StatusOK},
+	)
+	p := newTestResilientProvider(t, server.URL, nil)
+
+	start := time.Now()
+	if _, err := p.Complete(context.Background(), "system", "user"); err != nil {
+		t.Fatalf("Complete: %v", err)
+	}
+
+	// Two backoffs of at least half of 20ms and 40ms.
+	if elapsed := time.Since(start); elapsed < 30*time.Millisecond {
+		t.Errorf("retried after %s, want at least 30ms of backoff", elapsed)
+	}
+	if got := server.requests.Load(); got != 3 {
+		t.Errorf("made %d requests, want 3", got)
+	}
+}
+
+func TestResilientProviderGivesUpAfterMaxRetries(t *testing.T) {
+	server := newScriptedServer(t, scriptedResponse{status: http.StatusInternalServerError})
+	p := newTestResilientProvider(t, server.URL, nil)
+
+	_, err := p.Complete(context.Background(), "system", "user")
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusInternalServerError {
+		t.Fatalf("Complete error = %v, want APIError 500", err)
+	}
+	if got := server.requests.Load(); got != 3 {
+		t.Errorf("made %d requests, want 1 plus 2 retries", got)
+	}
+}
+
+func TestResilientProviderDoesNotRetryClientErrors(t *testing.T) {
+	server := newScriptedServer(t, scriptedResponse{status: http.StatusBadRequest})
+	breaker := NewCircuitBreaker(1, time.Minute)
+	p := newTestResilientProvider(t, server.URL, breaker)
+
+	_, err := p.Complete(context.Background(), "system", "user")
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusBadRequest {
+		t.Fatalf("Complete error = %v, want APIError 400", err)
+	}
+	if got := server.requests.Load(); got != 1 {
+		t.Errorf("made %d requests, want 1", got)
+	}
+	if _, paused := breaker.PausedUntil(); paused {
+		t.Error("a rejected request opened the circuit breaker")
+	}
+}
+
+func TestResilientProviderStopsRetryingAtDeadline(t *testing.T) {
+	server := newScriptedServer(t,
+		scriptedResponse{status: http.StatusTooManyRequests, header: map[string]string{"Retry-After": "3"}},
+		scriptedResponse{status: http.StatusOK},
+	)
+	p := newTestResilientProvider(t, server.URL, nil)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	start := time.Now()
+	_, err := p.Complete(ctx, "system", "user")
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusTooManyRequests {
+		t.Fatalf("Complete error = %v, want APIError 429", err)
+	}
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Errorf("waited %s for a retry past the deadline", elapsed)
+	}
+}
+
+func TestResilientProviderCountsCallsNotAttempts(t *testing.T) {
+	server := newScriptedServer(t, scriptedResponse{status: http.StatusServiceUnavailable})
+	breaker := NewCircuitBreaker(2, time.Minute)
+	p := newTestResilientProvider(t, server.URL, breaker)
+
+	// One call with all its retries is a single failure.
+	if _, err := p.Complete(context.Background(), "system", "user"); err == nil {
+		t.Fatal("Complete succeeded against a failing provider")
+	}
+	if _, paused := breaker.PausedUntil(); paused {
+		t.Fatal("the retries of one call opened the breaker")
+	}
+
+	if _, err := p.Complete(context.Background(), "system", "user"); err == nil {
+		t.Fatal("Complete succeeded against a failing provider")
+	}
+	if _, paused := breaker.PausedUntil(); !paused {
+		t.Fatal("two failed calls did not open the breaker")
+	}
+
+	_, err := p.Complete(context.Background(), "system", "user")
+	var unavailable *ProviderUnavailableError
+	if !errors.As(err, &unavailable) {
+		t.Fatalf("Complete error = %v, want ProviderUnavailableError", err)
+	}
+	if got := server.requests.Load(); got != 6 {
+		t.Errorf("made %d requests, want 6 and none while the breaker is open", got)
+	}
+}
+
+func TestResilientProviderThrottlingDoesNotOpenBreaker(t *testing.T) {
+	server := newScriptedServer(t, scriptedResponse{status: http.StatusTooManyRequests})
+	breaker := NewCircuitBreaker(1, time.Minute)
+	p := newTestResilientProvider(t, server.URL, breaker)
+
+	for range 2 {
+		_, err := p.Complete(context.Background(), "system", "user")
+		var apiErr *APIError
+		if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusTooManyRequests {
+			t.Fatalf("Complete error = %v, want APIError 429", err)
+		}
+	}
+	if _, paused := breaker.PausedUntil(); paused {
+		t.Error("429 answers opened the circuit breaker")
+	}
+}
+
+func TestResilientProviderBackoff(t *testing.T) {
+	p := &resilientProvider{retryBaseDelay: 100 * time.Millisecond, retryMaxDelay: time.Second}
+
+	for attempt, d := range []time.Duration{
+		100 * time.Millisecond,
+		200 * time.Millisecond,
+		400 * time.Millisecond,
+		800 * time.Millisecond,
+		time.Second,
+		time.Second,
+	} {
+		seen := make(map[time.Duration]bool)
+		for range 200 {
+			got := p.backoff(attempt)
+			if got < d/2 || got > d {
+				t.Fatalf("backoff(%d) = %s, want within [%s, %s]", attempt, got, d/2, d)
+			}
+			seen[got] = true
+		}
+		if len(seen) < 2 {
+			t.Errorf("backoff(%d) is not jittered", attempt)
+		}
+	}
+}
+
+type fakeClock struct {
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	return c.now
+}
+
+func TestCircuitBreaker(t *testing.T) {
+	clock := &fakeClock{now: time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)}
+	b := NewCircuitBreaker(2, time.Minute)
+	b.now = clock.Now
+
+	allow := func(want bool) {
+		t.Helper()
+		err := b.Allow()
+		var unavailable *ProviderUnavailableError
+		switch {
+		case want && err != nil:
+			t.Fatalf("Allow() = %v, want a call", err)
+		case !want && !errors.As(err, &unavailable):
+			t.Fatalf("Allow() = %v, want ProviderUnavailableError", err)
+		}
+	}
+
+	// Closed: failures below the threshold keep it closed.
+	allow(true)
+	if b.Failure() {
+		t.Fatal("first failure opened the breaker")
+	}
+	allow(true)
+	b.Success()
+	allow(true)
+	if b.Failure() {
+		t.Fatal("a success did not reset the failure count")
+	}
+
+	// Open at the threshold, for the cooldown.
+	allow(true)
+	if !b.Failure() {
+		t.Fatal("second consecutive failure did not open the breaker")
+	}
+	if until, paused := b.PausedUntil(); !paused || !until.Equal(clock.now.Add(time.Minute)) {
+		t.Fatalf("PausedUntil() = %s, %t; want cooldown end", until, paused)
+	}
+	allow(false)
+
+	// Half-open after the cooldown: one probe at a time.
+	clock.now = clock.now.Add(time.Minute)
+	allow(true)
+	allow(false)
+	if _, paused := b.PausedUntil(); paused {
+		t.Error("half-open breaker reports a pause")
+	}
+
+	// A released probe lets the next one through.
+	b.Release()
+	allow(true)
+
+	// A failed probe opens it again.
+	if !b.Failure() {
+		t.Fatal("failed probe did not reopen the breaker")
+	}
+	allow(false)
+
+	// A successful probe closes it.
+	clock.now = clock.now.Add(time.Minute)
+	allow(true)
+	b.Success()
+	allow(true)
+	allow(true)
+	if _, paused := b.PausedUntil(); paused {
+		t.Error("closed breaker reports a pause")
+	}
+}
@@ -0,0 +1,175 @@
+package service
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"text/template"
+
+	"github.com/ilyin-ad/flutter-code-mentor/internal/domain"
+)
+
+// PromptTemplateData is what a prompt template can refer to. Code holds the
+// pasted code or the project files; Rubric is the built-in list of review
+// categories and severity levels, for templates that only add to it.
+type PromptTemplateData struct {
+	Kind            string
+	TaskTitle       string
+	TaskDescription string
+	Criteria        string
+	Code            string
+	Rubric          string
+	ResponseFormat  string
+}
+
+// PromptTemplate replaces the built-in user prompt, and optionally the
+// system prompt, of code and project reviews. The response format is
+// appended when the template leaves it out, so the answer stays parseable.
+type PromptTemplate struct {
+	system string
+	user   *template.Template
+}
+
+// ParsePromptTemplate parses a user prompt written in text/template syntax
+// and checks it against sample data: every field it uses must exist and it
+// must include {{.Code}}.
+func ParsePromptTemplate(systemPrompt, userPrompt string) (*PromptTemplate, error) {
+	user, err := template.New("user_prompt").Option("missingkey=error").Parse(userPrompt)
+	if err != nil {
+		return nil, fmt.Errorf("invalid template: %w", err)
+	}
+
+	tmpl := &PromptTemplate{system: strings.TrimSpace(systemPrompt), user: user}
+
+	const codeMarker = "\x00code\x00"
+	sample, err := tmpl.execute(PromptTemplateData{
+		Kind:            "code",
+		TaskTitle:       "Task",
+		TaskDescription: "Description",
+		Criteria:        "1. Criterion",
+		Code:            codeMarker,
+		Rubric:          "Rubric",
+		ResponseFormat:  "Response format",
+	})
+	if err != nil {
+		return nil, err
+	}
+	if !strings.Contains(sample, codeMarker) {
+		return nil, fmt.Errorf("template must include the submitted code with {{.Code}}")
+	}
+
+	return tmpl, nil
+}
+
+func (t *PromptTemplate) execute(data PromptTemplateData) (string, error) {
+	var out bytes.Buffer
+	if err := t.user.Execute(&out, data); err != nil {
+		return "", fmt.Errorf("failed to render template: %w", err)
+	}
+	return out.String(), nil
+}
+
+func (t *PromptTemplate) render(data PromptTemplateData) (string, error) {
+	prompt, err := t.execute(data)
+	if err != nil {
+		return "", err
+	}
+	if !strings.Contains(prompt, data.ResponseFormat) {
+		prompt = strings.TrimRight(prompt, "\n") + "\n\n" + data.ResponseFormat
+	}
+	return prompt, nil
+}
+
+// WithPromptTemplate makes the code or project prompt inside prompt use
+// tmpl. A nil tmpl keeps the built-in prompt.
+func WithPromptTemplate(prompt ReviewPrompt, tmpl *PromptTemplate) ReviewPrompt {
+	if tmpl == nil {
+		return prompt
+	}
+
+	switch p := prompt.(type) {
+	case *codePrompt:
+		c := *p
+		c.template = tmpl
+		return &c
+	case *projectPrompt:
+		c := *p
+		c.template = tmpl
+		return &c
+	case *reReviewPrompt:
+		c := *p
+		c.base = WithPromptTemplate(p.base, tmpl)
+		return &c
+	case *staticAnalysisPrompt:
+		c := *p
+		c.base = WithPromptTemplate(p.base, tmpl)
+		return &c
+	case *languagePrompt:
+		c := *p
+		c.base = WithPromptTemplate(p.base, tmpl)
+		return &c
+	}
+	return prompt
+}
+
+func templateSystemPrompt(tmpl *PromptTemplate, builtin string) string {
+	if tmpl == nil || tmpl.system == "" {
+		return builtin
+	}
+	return tmpl.system
+}
+
+// renderTemplate renders the user prompt from tmpl, falling back to the
+// built-in prompt if there is no template or it fails on this submission.
+// Callers check the template with templateError first, so the fallback only
+// guards against a template that was never checked.
+func renderTemplate(tmpl *PromptTemplate, kind string, multiFile bool, code string, task *domain.Task, criteria []*domain.TaskCriteria, builtin func() string) string {
+	if tmpl == nil {
+		return builtin()
+	}
+
+	prompt, err := tmpl.render(templateData(kind, multiFile, code, task, criteria))
+	if err != nil {
+		return builtin()
+	}
+	return prompt
+}
+
+func templateData(kind string, multiFile bool, code string, task *domain.Task, criteria []*domain.TaskCriteria) PromptTemplateData {
+	data := PromptTemplateData{
+		Kind:           kind,
+		Criteria:       buildCriteriaList(criteria),
+		Code:           code,
+		Rubric:         buildReviewRubric(multiFile),
+		ResponseFormat: buildResponseFormat(multiFile, len(criteria) > 0),
+	}
+	if task != nil {
+		data.TaskTitle = task.Title
+		data.TaskDescription = task.Description
+	}
+	return data
+}
+
+// templateError renders the template of prompt, looking through wrapping
+// prompts, and returns why it fails on this submission, if it does.
+func templateError(prompt ReviewPrompt, task *domain.Task, criteria []*domain.TaskCriteria) error {
+	switch p := prompt.(type) {
+	case *codePrompt:
+		if p.template != nil {
+			_, err := p.template.render(templateData(p.Kind(), false, p.code, task, criteria))
+			return err
+		}
+	case *projectPrompt:
+		if p.template != nil {
+			_, err := p.template.render(templateData(p.Kind(), true, p.filesSection(), task, criteria))
+			return err
+		}
+	case *reReviewPrompt:
+		return templateError(p.base, task, criteria)
+	case *staticAnalysisPrompt:
+		return templateError(p.base, task, criteria)
+	case *languagePrompt:
+		return templateError(p.base, task, criteria)
+	}
+	return nil
+}
@@ -0,0 +1,216 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/ilyin-ad/flutter-code-mentor/internal/config"
+)
+
+var ErrUnsupportedRepositoryURL = errors.New("unsupported repository URL")
+
+const (
+	RepositoryGitHub     = "github"
+	RepositoryGitLab     = "gitlab"
+	RepositoryBitbucket  = "bitbucket"
+	RepositorySelfHosted = "self_hosted"
+	RepositoryLocal      = "local"
+)
+
+var (
+	publicRepositoryHosts = map[string]string{
+		"github.com":        RepositoryGitHub,
+		"www.github.com":    RepositoryGitHub,
+		"gitlab.com":        RepositoryGitLab,
+		"www.gitlab.com":    RepositoryGitLab,
+		"bitbucket.org":     RepositoryBitbucket,
+		"www.bitbucket.org": RepositoryBitbucket,
+	}
+
+	repositorySegmentPattern = regexp.MustCompile(`^[A-Za-z0-9_.-]+$`)
+	scpLikeURLPattern        = regexp.MustCompile(`^git@([A-Za-z0-9.-]+):(.+)$`)
+)
+
+// RepositoryURL is a parsed and normalised link to a student repository.
+// URL is the canonical address stored with the submission, CloneURL is what
+// git clone receives. Public hosts are always cloned over HTTPS; self-hosted
+// ones use the scheme from the config, which may be git:// for a git daemon.
+type RepositoryURL struct {
+	Provider string
+	Host     string
+	Owner    string
+	Name     string
+	URL      string
+	CloneURL string
+}
+
+// RepositorySources recognises the git hosts students may submit from:
+// GitHub, GitLab, Bitbucket and the self-hosted instances from the config.
+type RepositorySources interface {
+	Parse(raw string) (*RepositoryURL, error)
+	Hosts() []string
+}
+
+type repositorySources struct {
+	selfHosted     map[string]string
+	allowLocalURLs bool
+}
+
+func NewRepositorySources(cfg config.RepositoryConfig) RepositorySources {
+	selfHosted := make(map[string]string, len(cfg.SelfHostedHosts))
+	for _, entry := range cfg.SelfHostedHosts {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		scheme := "https"
+		if u, err := url.Parse(entry); err == nil && u.Host != "" {
+			scheme, entry = u.Scheme, u.Host
+		}
+		selfHosted[strings.ToLower(entry)] = scheme
+	}
+
+	return &repositorySources{
+		selfHosted:     selfHosted,
+		allowLocalURLs: cfg.AllowLocalURLs,
+	}
+}
+
+func (s *repositorySources) Hosts() []string {
+	selfHosted := make([]string, 0, len(s.selfHosted))
+	for host := range s.selfHosted {
+		selfHosted = append(selfHosted, host)
+	}
+	sort.Strings(selfHosted)
+
+	return append([]string{"github.com", "gitlab.com", "bitbucket.org"}, selfHosted...)
+}
+
+// Parse accepts web, clone and SSH links (with or without scheme and .git
+// suffix, with trailing branch or file paths) and reduces them to the
+// repository itself.
+func (s *repositorySources) Parse(raw string) (*RepositoryURL, error) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return nil, fmt.Errorf("%w: empty URL", ErrUnsupportedRepositoryURL)
+	}
+
+	if strings.HasPrefix(raw, "file://") {
+		return s.parseLocal(raw)
+	}
+
+	if m := scpLikeURLPattern.FindStringSubmatch(raw); m != nil {
+		raw = "https://" + m[1] + "/" + m[2]
+	} else if !strings.Contains(raw, "://") {
+		raw = "https://" + raw
+	}
+
+	u, err := url.Parse(raw)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrUnsupportedRepositoryURL, err)
+	}
+	if u.User != nil {
+		return nil, fmt.Errorf("%w: credentials must not be part of the URL", ErrUnsupportedRepositoryURL)
+	}
+
+	scheme := strings.ToLower(u.Scheme)
+	if scheme != "https" && scheme != "http" && scheme != "ssh" && scheme != "git" {
+		return nil, fmt.Errorf("%w: scheme %q is not supported", ErrUnsupportedRepositoryURL, u.Scheme)
+	}
+
+	host := strings.ToLower(u.Host)
+	provider, ok := publicRepositoryHosts[host]
+	if ok {
+		host = strings.TrimPrefix(host, "www.")
+		scheme = "https"
+	} else if selfHostedScheme, known := s.selfHosted[host]; known {
+		provider = RepositorySelfHosted
+		scheme = selfHostedScheme
+	} else {
+		return nil, fmt.Errorf("%w: host %q is not allowed", ErrUnsupportedRepositoryURL, u.Host)
+	}
+
+	segments, err := repositoryPath(provider, u.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	owner := strings.Join(segments[:len(segments)-1], "/")
+	name := segments[len(segments)-1]
+	webURL := fmt.Sprintf("%s://%s/%s/%s", scheme, host, owner, name)
+
+	return &RepositoryURL{
+		Provider: provider,
+		Host:     host,
+		Owner:    owner,
+		Name:     name,
+		URL:      webURL,
+		CloneURL: webURL + ".git",
+	}, nil
+}
+
+// repositoryPath extracts owner and repository segments from a URL path.
+// GitHub and Bitbucket repositories are always owner/name; GitLab and
+// self-hosted instances allow nested groups and mark the end of the
+// repository path with "/-/".
+func repositoryPath(provider, urlPath string) ([]string, error) {
+	p := strings.Trim(urlPath, "/")
+	if i := strings.Index(p, "/-/"); i >= 0 {
+		p = p[:i]
+	}
+
+	segments := strings.Split(p, "/")
+	switch provider {
+	case RepositoryGitHub, RepositoryBitbucket:
+		if len(segments) > 2 {
+			segments = segments[:2]
+		}
+	}
+
+	if len(segments) < 2 {
+		return nil, fmt.Errorf("%w: expected a path like owner/repository", ErrUnsupportedRepositoryURL)
+	}
+
+	segments[len(segments)-1] = strings.TrimSuffix(segments[len(segments)-1], ".git")
+	for _, segment := range segments {
+		if segment == "." || segment == ".." || !repositorySegmentPattern.MatchString(segment) {
+			return nil, fmt.Errorf("%w: invalid path segment %q", ErrUnsupportedRepositoryURL, segment)
+		}
+	}
+
+	return segments, nil
+}
+
+// parseLocal handles file:// URLs, which are only allowed in development
+// and tests to review repositories on the local disk.
+func (s *repositorySources) parseLocal(raw string) (*RepositoryURL, error) {
+	if !s.allowLocalURLs {
+		return nil, fmt.Errorf("%w: local repositories are disabled", ErrUnsupportedRepositoryURL)
+	}
+
+	u, err := url.Parse(raw)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrUnsupportedRepositoryURL, err)
+	}
+	if u.Host != "" || !path.IsAbs(u.Path) {
+		return nil, fmt.Errorf("%w: file URL must have an absolute path", ErrUnsupportedRepositoryURL)
+	}
+
+	clean := path.Clean(u.Path)
+	name := strings.TrimSuffix(path.Base(clean), ".git")
+	if !repositorySegmentPattern.MatchString(name) {
+		return nil, fmt.Errorf("%w: invalid repository name %q", ErrUnsupportedRepositoryURL, name)
+	}
+
+	return &RepositoryURL{
+		Provider: RepositoryLocal,
+		Name:     name,
+		URL:      "file://" + clean,
+		CloneURL: "file://" + clean,
+	}, nil
+}
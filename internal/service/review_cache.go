@@ -0,0 +1,130 @@
+package service
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"sort"
+	"strings"
+
+	"github.com/ilyin-ad/flutter-code-mentor/internal/domain"
+)
+
+// reviewCacheVersion is part of every review cache key. Bump it when the
+// built-in prompts or the response parsing change, so reviews made with the
+// old ones are not reused.
+const reviewCacheVersion = 2
+
+// ReviewCacheKeyInput is everything a cached review depends on.
+// TemplateID and TemplateVersion are zero for the built-in prompt.
+type ReviewCacheKeyInput struct {
+	Kind            string
+	Files           map[string]string
+	LintIssues      []LintIssue
+	Task            *domain.Task
+	Criteria        []*domain.TaskCriteria
+	TemplateID      int
+	TemplateVersion int
+	Language        domain.Language
+	Model           string
+}
+
+type reviewCacheKeyCriterion struct {
+	ID          int    `json:"id"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	IsMandatory bool   `json:"is_mandatory"`
+	Weight      int    `json:"weight"`
+}
+
+type reviewCacheKeyLintIssue struct {
+	Rule     string `json:"rule"`
+	FilePath string `json:"file_path"`
+	Line     int    `json:"line"`
+}
+
+type reviewCacheKeyFile struct {
+	Path    string `json:"path"`
+	Content string `json:"content"`
+}
+
+// ReviewCacheKey returns the hex SHA-256 of the normalised input. Files
+// that differ only in line endings or trailing whitespace get the same key;
+// their line numbers are the same, so the feedback still fits once it is
+// anchored to the submission's own files.
+func ReviewCacheKey(in ReviewCacheKeyInput) string {
+	paths := make([]string, 0, len(in.Files))
+	for path := range in.Files {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	files := make([]reviewCacheKeyFile, len(paths))
+	for i, path := range paths {
+		files[i] = reviewCacheKeyFile{Path: path, Content: normalizeSource(in.Files[path])}
+	}
+
+	criteria := make([]reviewCacheKeyCriterion, len(in.Criteria))
+	for i, c := range in.Criteria {
+		criteria[i] = reviewCacheKeyCriterion{
+			ID:          c.ID,
+			Name:        c.CriterionName,
+			Description: c.CriterionDescription,
+			IsMandatory: c.IsMandatory,
+			Weight:      c.Weight,
+		}
+	}
+
+	lintIssues := make([]reviewCacheKeyLintIssue, len(in.LintIssues))
+	for i, issue := range in.LintIssues {
+		lintIssues[i] = reviewCacheKeyLintIssue{Rule: issue.Rule, FilePath: issue.FilePath, Line: issue.Line}
+	}
+
+	key := struct {
+		Version         int                       `json:"version"`
+		Kind            string                    `json:"kind"`
+		Files           []reviewCacheKeyFile      `json:"files"`
+		LintIssues      []reviewCacheKeyLintIssue `json:"lint_issues"`
+		TaskID          int                       `json:"task_id"`
+		TaskTitle       string                    `json:"task_title"`
+		TaskDescription string                    `json:"task_description"`
+		Criteria        []reviewCacheKeyCriterion `json:"criteria"`
+		TemplateID      int                       `json:"template_id"`
+		TemplateVersion int                       `json:"template_version"`
+		Language        domain.Language           `json:"language"`
+		Model           string                    `json:"model"`
+	}{
+		Version:         reviewCacheVersion,
+		Kind:            in.Kind,
+		Files:           files,
+		LintIssues:      lintIssues,
+		Criteria:        criteria,
+		TemplateID:      in.TemplateID,
+		TemplateVersion: in.TemplateVersion,
+		Language:        in.Language,
+		Model:           in.Model,
+	}
+	if in.Task != nil {
+		key.TaskID = in.Task.ID
+		key.TaskTitle = in.Task.Title
+		key.TaskDescription = in.Task.Description
+	}
+
+	// Marshalling plain strings, ints and slices cannot fail.
+	encoded, _ := json.Marshal(key)
+	sum := sha256.Sum256(encoded)
+	return hex.EncodeToString(sum[:])
+}
+
+// normalizeSource drops a byte order mark, CRLF line endings, trailing
+// whitespace and trailing blank lines without changing line numbers.
+func normalizeSource(content string) string {
+	content = strings.TrimPrefix(content, "\ufeff")
+	content = strings.ReplaceAll(content, "\r\n", "\n")
+
+	lines := strings.Split(content, "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimRight(line, " \t\r")
+	}
+	return strings.TrimRight(strings.Join(lines, "\n"), "\n")
+}
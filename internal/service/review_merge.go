@@ -0,0 +1,91 @@
+package service
+
+import (
+	"github.com/ilyin-ad/flutter-code-mentor/internal/domain"
+)
+
+var verdictRank = map[domain.CriterionVerdict]int{
+	domain.VerdictNotMet:       0,
+	domain.VerdictPartiallyMet: 1,
+	domain.VerdictMet:          2,
+}
+
+// mergeReviewResults combines the reviews of the parts of one split project.
+// Every criterion keeps its best verdict, since each part only sees some of
+// the files and a criterion met anywhere in the project is met. The overall
+// status is derived from the merged criteria and feedback rather than taken
+// from the parts, whose statuses only judge their own files. Confidence is
+// the average over parts.
+func mergeReviewResults(results []*CodeReviewResult, criteria []*domain.TaskCriteria) *CodeReviewResult {
+	merged := &CodeReviewResult{
+		AIModel: results[0].AIModel,
+	}
+
+	criteriaIndex := make(map[int]int)
+	resolved := make(map[int]bool)
+	confidence := 0.0
+
+	for _, r := range results {
+		confidence += r.AIConfidence
+		merged.ExecutionTimeMs += r.ExecutionTimeMs
+		merged.PromptTokens += r.PromptTokens
+		merged.CompletionTokens += r.CompletionTokens
+		merged.EstimatedCost += r.EstimatedCost
+		merged.Feedbacks = append(merged.Feedbacks, r.Feedbacks...)
+
+		for _, c := range r.Criteria {
+			i, ok := criteriaIndex[c.CriterionID]
+			if !ok {
+				criteriaIndex[c.CriterionID] = len(merged.Criteria)
+				merged.Criteria = append(merged.Criteria, c)
+				continue
+			}
+			if verdictRank[c.Verdict] > verdictRank[merged.Criteria[i].Verdict] {
+				merged.Criteria[i] = c
+			}
+		}
+
+		for _, id := range r.ResolvedFeedbackIDs {
+			if !resolved[id] {
+				resolved[id] = true
+				merged.ResolvedFeedbackIDs = append(merged.ResolvedFeedbackIDs, id)
+			}
+		}
+	}
+
+	merged.AIConfidence = confidence / float64(len(results))
+	merged.OverallStatus = mergedOverallStatus(merged, criteria)
+
+	return merged
+}
+
+// mergedOverallStatus applies the rubric of the review prompt to the whole
+// project: critical or major issues and unmet mandatory criteria fail it,
+// moderate issues and criteria not fully met need improvement.
+func mergedOverallStatus(merged *CodeReviewResult, criteria []*domain.TaskCriteria) string {
+	mandatory := make(map[int]bool, len(criteria))
+	for _, c := range criteria {
+		mandatory[c.ID] = c.IsMandatory
+	}
+
+	status := "passed"
+	for _, c := range merged.Criteria {
+		switch {
+		case c.Verdict == domain.VerdictNotMet && mandatory[c.CriterionID]:
+			return "failed"
+		case c.Verdict != domain.VerdictMet:
+			status = "needs_improvement"
+		}
+	}
+
+	for _, fb := range merged.Feedbacks {
+		switch {
+		case fb.Severity >= 4:
+			return "failed"
+		case fb.Severity == 3:
+			status = "needs_improvement"
+		}
+	}
+
+	return status
+}
@@ -0,0 +1,599 @@
+package service
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/ilyin-ad/flutter-code-mentor/internal/domain"
+	"go.uber.org/zap"
+)
+
+// ReviewPrompt is a prompt strategy for one kind of submission. The review
+// pipeline in aiService is shared, only the prompt text differs.
+// ResponseFormat is the JSON schema the answer must follow, which a request
+// to repair a broken answer repeats.
+type ReviewPrompt interface {
+	Kind() string
+	SystemPrompt() string
+	UserPrompt(task *domain.Task, criteria []*domain.TaskCriteria) string
+	ResponseFormat(criteria []*domain.TaskCriteria) string
+	LogFields() []zap.Field
+}
+
+// splittablePrompt is implemented by prompts that can be reviewed in several
+// calls when they do not fit into maxTokens.
+type splittablePrompt interface {
+	Split(task *domain.Task, criteria []*domain.TaskCriteria, maxTokens int) []ReviewPrompt
+}
+
+type codePrompt struct {
+	code     string
+	template *PromptTemplate
+}
+
+func NewCodePrompt(code string) ReviewPrompt {
+	return &codePrompt{code: code}
+}
+
+func (p *codePrompt) Kind() string {
+	return "code"
+}
+
+func (p *codePrompt) SystemPrompt() string {
+	return templateSystemPrompt(p.template, "You are an expert Flutter/Dart code reviewer. Analyze code and provide structured feedback in JSON format.")
+}
+
+func (p *codePrompt) ResponseFormat(criteria []*domain.TaskCriteria) string {
+	return buildResponseFormat(false, len(criteria) > 0)
+}
+
+func (p *codePrompt) LogFields() []zap.Field {
+	return []zap.Field{zap.Int("code_length", len(p.code))}
+}
+
+func (p *codePrompt) UserPrompt(task *domain.Task, criteria []*domain.TaskCriteria) string {
+	return renderTemplate(p.template, p.Kind(), false, p.code, task, criteria, func() string {
+		return p.builtinUserPrompt(task, criteria)
+	})
+}
+
+func (p *codePrompt) builtinUserPrompt(task *domain.Task, criteria []*domain.TaskCriteria) string {
+	return fmt.Sprintf(`Analyze the following Flutter/Dart code and provide a detailed code review.
+%s%s
+Code to review:
+%s
+
+%s
+
+Provide confidence as a decimal between 0 and 1.
+
+IMPORTANT: Pay special attention to the task-specific criteria listed above. Check if the code meets these requirements and include them in your feedback if they are not satisfied.`,
+		buildTaskSection(task), buildCriteriaSection(criteria), p.code, buildReviewInstructions(false, len(criteria) > 0))
+}
+
+type projectPrompt struct {
+	files    map[string]string
+	template *PromptTemplate
+
+	part       int
+	parts      int
+	otherFiles []string
+
+	// oversized lists the files too large for any part; only the first
+	// part carries them, so they are reported once.
+	oversized []string
+}
+
+func NewProjectPrompt(files map[string]string) ReviewPrompt {
+	return &projectPrompt{files: files}
+}
+
+func (p *projectPrompt) Kind() string {
+	return "project"
+}
+
+func (p *projectPrompt) SystemPrompt() string {
+	return templateSystemPrompt(p.template, "You are an expert Flutter/Dart code reviewer. Analyze Flutter/Dart projects and provide structured feedback in JSON format.")
+}
+
+func (p *projectPrompt) ResponseFormat(criteria []*domain.TaskCriteria) string {
+	return buildResponseFormat(true, len(criteria) > 0)
+}
+
+func (p *projectPrompt) LogFields() []zap.Field {
+	fields := []zap.Field{zap.Int("files_count", len(p.files))}
+	if p.parts > 1 {
+		fields = append(fields, zap.Int("part", p.part), zap.Int("parts", p.parts))
+	}
+	return fields
+}
+
+// Split drops generated files and packs the rest into as many prompts as
+// needed to stay within maxTokens, most important files first. Files that
+// do not fit into a prompt on their own are left out and recorded.
+func (p *projectPrompt) Split(task *domain.Task, criteria []*domain.TaskCriteria, maxTokens int) []ReviewPrompt {
+	paths, _ := prioritizeFiles(p.files)
+	if len(paths) == 0 {
+		return nil
+	}
+
+	empty := &projectPrompt{template: p.template, parts: 2, otherFiles: paths}
+	overhead := estimateTokens(empty.SystemPrompt()) + estimateTokens(empty.UserPrompt(task, criteria))
+	groups, oversized := groupFilesByBudget(p.files, paths, maxTokens-overhead)
+	if len(groups) == 0 {
+		groups = []map[string]string{{}}
+	}
+
+	prompts := make([]ReviewPrompt, len(groups))
+	for i, group := range groups {
+		chunk := &projectPrompt{files: group, template: p.template, part: i + 1, parts: len(groups)}
+		if i == 0 {
+			chunk.oversized = oversized
+		}
+		if len(groups) > 1 {
+			for _, path := range paths {
+				if _, ok := group[path]; !ok {
+					chunk.otherFiles = append(chunk.otherFiles, path)
+				}
+			}
+		}
+		prompts[i] = chunk
+	}
+	return prompts
+}
+
+func (p *projectPrompt) UserPrompt(task *domain.Task, criteria []*domain.TaskCriteria) string {
+	filesContent := p.filesSection()
+	return renderTemplate(p.template, p.Kind(), true, filesContent, task, criteria, func() string {
+		return fmt.Sprintf(`Analyze the following Flutter/Dart project and provide a detailed code review.
+%s%s
+%s
+
+%s
+
+Provide confidence as a decimal between 0 and 1.
+IMPORTANT: Always include "file_path" field in each feedback item to indicate which file the issue is in.
+IMPORTANT: Pay special attention to the task-specific criteria listed above. Check if the project meets these requirements and include them in your feedback if they are not satisfied.`,
+			buildTaskSection(task), buildCriteriaSection(criteria), filesContent, buildReviewInstructions(true, len(criteria) > 0))
+	})
+}
+
+// filesSection lists the files of the prompt, most important first.
+func (p *projectPrompt) filesSection() string {
+	paths := make([]string, 0, len(p.files))
+	for filePath := range p.files {
+		paths = append(paths, filePath)
+	}
+	sort.Slice(paths, func(i, j int) bool {
+		pi, pj := filePriority(paths[i]), filePriority(paths[j])
+		if pi != pj {
+			return pi < pj
+		}
+		return paths[i] < paths[j]
+	})
+
+	var filesContent strings.Builder
+	if p.parts > 1 {
+		filesContent.WriteString(fmt.Sprintf(`This is part %d of %d of the project. The other parts are reviewed separately:
+only report issues in the files shown below, and judge task criteria by these files only.
+Other project files (not shown): %s
+
+`, p.part, p.parts, strings.Join(p.otherFiles, ", ")))
+	}
+	filesContent.WriteString("Flutter/Dart project files:\n\n")
+
+	for _, filePath := range paths {
+		filesContent.WriteString(fmt.Sprintf("=== File: %s ===\n", filePath))
+		filesContent.WriteString(p.files[filePath])
+		filesContent.WriteString("\n\n")
+	}
+
+	return filesContent.String()
+}
+
+// reReviewPrompt extends the prompt of a resubmission with the changes since
+// the previous attempt and the issues reported on it.
+type reReviewPrompt struct {
+	base  ReviewPrompt
+	diffs []FileDiff
+	prior []*domain.ReviewFeedback
+}
+
+func NewReReviewPrompt(base ReviewPrompt, diffs []FileDiff, prior []*domain.ReviewFeedback) ReviewPrompt {
+	return &reReviewPrompt{base: base, diffs: diffs, prior: prior}
+}
+
+// Split splits the underlying project prompt and gives every part the diff
+// and previous issues of its own files. Issues without a file go to the
+// first part.
+func (p *reReviewPrompt) Split(task *domain.Task, criteria []*domain.TaskCriteria, maxTokens int) []ReviewPrompt {
+	base, ok := p.base.(splittablePrompt)
+	if !ok {
+		return []ReviewPrompt{p}
+	}
+
+	parts := base.Split(task, criteria, maxTokens-estimateTokens(p.section()))
+	prompts := make([]ReviewPrompt, len(parts))
+	for i, part := range parts {
+		files := reviewedFiles(part)
+		if files == nil || len(parts) == 1 {
+			prompts[i] = &reReviewPrompt{base: part, diffs: p.diffs, prior: p.prior}
+			continue
+		}
+
+		sub := &reReviewPrompt{base: part}
+		for _, d := range p.diffs {
+			if _, ok := files[d.Path]; ok {
+				sub.diffs = append(sub.diffs, d)
+			}
+		}
+		for _, fb := range p.prior {
+			if fb.FilePath == nil || *fb.FilePath == "" {
+				if i == 0 {
+					sub.prior = append(sub.prior, fb)
+				}
+				continue
+			}
+			if _, ok := files[*fb.FilePath]; ok {
+				sub.prior = append(sub.prior, fb)
+			}
+		}
+		prompts[i] = sub
+	}
+	return prompts
+}
+
+func (p *reReviewPrompt) Kind() string {
+	return p.base.Kind() + "_rereview"
+}
+
+func (p *reReviewPrompt) SystemPrompt() string {
+	return p.base.SystemPrompt()
+}
+
+func (p *reReviewPrompt) ResponseFormat(criteria []*domain.TaskCriteria) string {
+	format := p.base.ResponseFormat(criteria)
+	if len(p.prior) > 0 {
+		format += "\nInclude the top-level \"resolved_feedback_ids\" array with the IDs of the previous issues that are fixed."
+	}
+	return format
+}
+
+func (p *reReviewPrompt) LogFields() []zap.Field {
+	return append(p.base.LogFields(),
+		zap.Int("changed_files_count", len(p.diffs)),
+		zap.Int("prior_feedbacks_count", len(p.prior)),
+	)
+}
+
+func (p *reReviewPrompt) UserPrompt(task *domain.Task, criteria []*domain.TaskCriteria) string {
+	return p.base.UserPrompt(task, criteria) + p.section()
+}
+
+func (p *reReviewPrompt) section() string {
+	var section strings.Builder
+	section.WriteString("\n\nThis is a resubmission of a previously reviewed solution.\n")
+
+	if len(p.diffs) > 0 {
+		section.WriteString("\nChanges since the previous attempt (unified diff):\n")
+		section.WriteString(FormatDiff(p.diffs))
+	} else {
+		section.WriteString("\nNo changes to these files since the previous attempt, or the diff is not available.\n")
+	}
+
+	if len(p.prior) > 0 {
+		section.WriteString("\nIssues reported on the previous attempt:\n")
+		for _, fb := range p.prior {
+			location := fmt.Sprintf("line %d", fb.LineStart)
+			if fb.LineEnd != nil && *fb.LineEnd > fb.LineStart {
+				location = fmt.Sprintf("lines %d-%d", fb.LineStart, *fb.LineEnd)
+			}
+			if fb.FilePath != nil && *fb.FilePath != "" {
+				location = *fb.FilePath + ", " + location
+			}
+			section.WriteString(fmt.Sprintf("- [ID: %d] (%s, %s, severity %d) %s\n",
+				fb.ID, fb.FeedbackType, location, fb.Severity, fb.Description))
+		}
+
+		section.WriteString(`
+Add a top-level "resolved_feedback_ids" array to your JSON with the IDs of the previous issues that are fixed in this attempt, for example "resolved_feedback_ids": [12, 15].
+Do not repeat fixed issues in "feedbacks". Report issues that are still present again, with their current location.`)
+	}
+
+	return section.String()
+}
+
+// staticAnalysisPrompt lists the lint findings of the pre-pass, so the
+// model leaves them out and spends its answer on higher-level problems.
+type staticAnalysisPrompt struct {
+	base   ReviewPrompt
+	issues []LintIssue
+}
+
+func NewStaticAnalysisPrompt(base ReviewPrompt, issues []LintIssue) ReviewPrompt {
+	if len(issues) == 0 {
+		return base
+	}
+	return &staticAnalysisPrompt{base: base, issues: issues}
+}
+
+// Split splits the underlying prompt and gives every part the findings in
+// its own files.
+func (p *staticAnalysisPrompt) Split(task *domain.Task, criteria []*domain.TaskCriteria, maxTokens int) []ReviewPrompt {
+	base, ok := p.base.(splittablePrompt)
+	if !ok {
+		return []ReviewPrompt{p}
+	}
+
+	parts := base.Split(task, criteria, maxTokens-estimateTokens(p.section()))
+	prompts := make([]ReviewPrompt, len(parts))
+	for i, part := range parts {
+		files := reviewedFiles(part)
+		if files == nil || len(parts) == 1 {
+			prompts[i] = NewStaticAnalysisPrompt(part, p.issues)
+			continue
+		}
+
+		var issues []LintIssue
+		for _, issue := range p.issues {
+			if _, ok := files[issue.FilePath]; ok {
+				issues = append(issues, issue)
+			}
+		}
+		prompts[i] = NewStaticAnalysisPrompt(part, issues)
+	}
+	return prompts
+}
+
+func (p *staticAnalysisPrompt) Kind() string {
+	return p.base.Kind()
+}
+
+func (p *staticAnalysisPrompt) SystemPrompt() string {
+	return p.base.SystemPrompt()
+}
+
+func (p *staticAnalysisPrompt) ResponseFormat(criteria []*domain.TaskCriteria) string {
+	return p.base.ResponseFormat(criteria)
+}
+
+func (p *staticAnalysisPrompt) LogFields() []zap.Field {
+	return append(p.base.LogFields(), zap.Int("lint_issues_count", len(p.issues)))
+}
+
+func (p *staticAnalysisPrompt) UserPrompt(task *domain.Task, criteria []*domain.TaskCriteria) string {
+	return p.base.UserPrompt(task, criteria) + p.section()
+}
+
+func (p *staticAnalysisPrompt) section() string {
+	var section strings.Builder
+	section.WriteString("\n\nStatic analysis has already reported these issues, they are part of the review:\n")
+	for _, issue := range p.issues {
+		location := fmt.Sprintf("line %d", issue.Line)
+		if issue.FilePath != "" {
+			location = issue.FilePath + ", " + location
+		}
+		section.WriteString(fmt.Sprintf("- [%s] (%s) %s\n", issue.Rule, location, issue.Message))
+	}
+	section.WriteString(`
+Do not report these issues or other instances of the same lint rules again.
+Focus "feedbacks" on problems static analysis cannot find: logic errors, state management, widget structure, architecture, error handling and the task requirements.`)
+
+	return section.String()
+}
+
+// languageNames are the names models understand best for each language.
+var languageNames = map[domain.Language]string{
+	domain.LanguageEnglish: "English",
+	domain.LanguageRussian: "Russian",
+}
+
+// languagePrompt asks for the feedback text in the student's language. The
+// JSON keys and enum values stay English, since the response is parsed.
+type languagePrompt struct {
+	base     ReviewPrompt
+	language domain.Language
+}
+
+func NewLanguagePrompt(base ReviewPrompt, language domain.Language) ReviewPrompt {
+	if _, ok := languageNames[language]; !ok {
+		return base
+	}
+	return &languagePrompt{base: base, language: language}
+}
+
+func (p *languagePrompt) Split(task *domain.Task, criteria []*domain.TaskCriteria, maxTokens int) []ReviewPrompt {
+	base, ok := p.base.(splittablePrompt)
+	if !ok {
+		return []ReviewPrompt{p}
+	}
+
+	parts := base.Split(task, criteria, maxTokens-estimateTokens(p.section()))
+	prompts := make([]ReviewPrompt, len(parts))
+	for i, part := range parts {
+		prompts[i] = &languagePrompt{base: part, language: p.language}
+	}
+	return prompts
+}
+
+func (p *languagePrompt) Kind() string {
+	return p.base.Kind()
+}
+
+func (p *languagePrompt) SystemPrompt() string {
+	return p.base.SystemPrompt() + fmt.Sprintf(" Write all explanations for the student in %s.", languageNames[p.language])
+}
+
+func (p *languagePrompt) ResponseFormat(criteria []*domain.TaskCriteria) string {
+	return p.base.ResponseFormat(criteria)
+}
+
+func (p *languagePrompt) LogFields() []zap.Field {
+	return append(p.base.LogFields(), zap.String("language", string(p.language)))
+}
+
+func (p *languagePrompt) UserPrompt(task *domain.Task, criteria []*domain.TaskCriteria) string {
+	return p.base.UserPrompt(task, criteria) + p.section()
+}
+
+func (p *languagePrompt) section() string {
+	return fmt.Sprintf(`
+
+Language: write "description", the comments in "suggested_fix" and "evidence" in %s.
+Keep the JSON keys, the "type", "verdict" and "overall_status" values and the code itself unchanged.`, languageNames[p.language])
+}
+
+// reviewedFiles returns the project files a prompt covers, looking through
+// wrapping prompts. It is nil for a single pasted snippet.
+func reviewedFiles(prompt ReviewPrompt) map[string]string {
+	switch p := prompt.(type) {
+	case *projectPrompt:
+		return p.files
+	case *reReviewPrompt:
+		return reviewedFiles(p.base)
+	case *staticAnalysisPrompt:
+		return reviewedFiles(p.base)
+	case *languagePrompt:
+		return reviewedFiles(p.base)
+	}
+	return nil
+}
+
+// oversizedFiles returns the files a split prompt left out because they
+// exceed the prompt budget on their own.
+func oversizedFiles(prompt ReviewPrompt) []string {
+	switch p := prompt.(type) {
+	case *projectPrompt:
+		return p.oversized
+	case *reReviewPrompt:
+		return oversizedFiles(p.base)
+	case *staticAnalysisPrompt:
+		return oversizedFiles(p.base)
+	case *languagePrompt:
+		return oversizedFiles(p.base)
+	}
+	return nil
+}
+
+func buildTaskSection(task *domain.Task) string {
+	if task == nil {
+		return ""
+	}
+	return fmt.Sprintf("\n\nTask description:\n%s\n", task.Description)
+}
+
+func buildCriteriaSection(criteria []*domain.TaskCriteria) string {
+	if len(criteria) == 0 {
+		return ""
+	}
+
+	return "\n\nTask-specific criteria to check:\n" + buildCriteriaList(criteria)
+}
+
+// buildCriteriaList numbers the criteria with the IDs the model must use in
+// its verdicts.
+func buildCriteriaList(criteria []*domain.TaskCriteria) string {
+	var list strings.Builder
+	for i, c := range criteria {
+		mandatory := "Optional"
+		if c.IsMandatory {
+			mandatory = "Mandatory"
+		}
+		list.WriteString(fmt.Sprintf("%d. [ID: %d, %s, Weight: %d] %s: %s\n",
+			i+1, c.ID, mandatory, c.Weight, c.CriterionName, c.CriterionDescription))
+	}
+	return list.String()
+}
+
+// buildReviewInstructions is the tail of the built-in prompts: the response
+// schema, the review rubric and the rules for criteria verdicts.
+func buildReviewInstructions(multiFile, withCriteria bool) string {
+	return buildResponseSchema(multiFile, withCriteria) + "\n\n" + buildReviewRubric(multiFile) + buildCriteriaRules(withCriteria)
+}
+
+// buildResponseFormat is what a prompt template must ask for so the answer
+// can be parsed. It is appended to templates that do not include it.
+func buildResponseFormat(multiFile, withCriteria bool) string {
+	format := buildResponseSchema(multiFile, withCriteria) + buildCriteriaRules(withCriteria) +
+		"\n\nProvide confidence as a decimal between 0 and 1."
+	if multiFile {
+		format += "\nAlways include the \"file_path\" field in each feedback item to indicate which file the issue is in."
+	}
+	return format
+}
+
+func buildResponseSchema(multiFile, withCriteria bool) string {
+	filePathLine := ""
+	if multiFile {
+		filePathLine = "\n      \"file_path\": \"lib/main.dart\","
+	}
+
+	criteriaBlock := ""
+	if withCriteria {
+		criteriaBlock = `,
+  "criteria": [
+    {
+      "criterion_id": 1,
+      "verdict": "met|partially_met|not_met",
+      "evidence": "where and how the code meets or misses the criterion"
+    }
+  ]`
+	}
+
+	return fmt.Sprintf(`Provide your response in the following JSON format:
+{
+  "overall_status": "passed|failed|needs_improvement",
+  "confidence": 0.95,
+  "feedbacks": [
+    {
+      "type": "critical_error|logic_error|style_issue|performance|security_risk|improvement",%s
+      "line_start": 10,
+      "line_end": 15,
+      "code_snippet": "problematic code here",
+      "suggested_fix": "corrected code here",
+      "description": "detailed explanation of the issue",
+      "severity": 1-5
+    }
+  ]%s
+}`, filePathLine, criteriaBlock)
+}
+
+func buildReviewRubric(multiFile bool) string {
+	projectCriterion := ""
+	if multiFile {
+		projectCriterion = "\n7. **Project Structure**: Proper file organization, separation of concerns"
+	}
+
+	return fmt.Sprintf(`Review criteria:
+1. **Critical Errors**: Syntax errors, null safety violations, type mismatches
+2. **Logic Errors**: Incorrect business logic, potential runtime errors
+3. **Style Issues**: Code formatting, naming conventions, Flutter best practices
+4. **Performance**: Inefficient algorithms, unnecessary rebuilds, memory leaks
+5. **Security**: Exposed sensitive data, insecure API calls
+6. **Improvements**: Better patterns, code organization, widget composition%s
+
+Severity levels:
+- 5: Critical (blocks functionality)
+- 4: Major (significant impact)
+- 3: Moderate (noticeable issue)
+- 2: Minor (cosmetic or style)
+- 1: Suggestion (optional improvement)
+
+Overall status:
+- "passed": Code is production-ready with minor or no issues
+- "needs_improvement": Code works but has moderate issues
+- "failed": Code has critical errors or major problems`, projectCriterion)
+}
+
+func buildCriteriaRules(withCriteria bool) string {
+	if !withCriteria {
+		return ""
+	}
+	return `
+
+Task criteria verdicts:
+- Return exactly one entry in "criteria" for every task-specific criterion, using its ID
+- "met": fully implemented; "partially_met": implemented with gaps; "not_met": missing or broken
+- "evidence" must point to the concrete code (file, class or line) that justifies the verdict`
+}
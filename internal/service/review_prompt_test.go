@@ -0,0 +1,290 @@
+package service
+
+import (
+	"errors"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/ilyin-ad/flutter-code-mentor/internal/domain"
+)
+
+func testTask() *domain.Task {
+	return &domain.Task{Title: "Counter", Description: "Build a counter app with a reset button."}
+}
+
+func testCriteria() []*domain.TaskCriteria {
+	return []*domain.TaskCriteria{
+		{ID: 7, CriterionName: "Reset", CriterionDescription: "A button resets the counter", IsMandatory: true, Weight: 3},
+		{ID: 9, CriterionName: "Tests", CriterionDescription: "Widget tests cover the counter", Weight: 1},
+	}
+}
+
+func TestBuiltinUserPrompts(t *testing.T) {
+	files := map[string]string{
+		"test/widget_test.dart": "void main() { testWidgets(); }",
+		"lib/home.dart":         "class Home {}",
+		"lib/main.dart":         "void main() => runApp(App());",
+	}
+
+	tests := []struct {
+		name      string
+		prompt    ReviewPrompt
+		criteria  []*domain.TaskCriteria
+		multiFile bool
+		contains  []string
+		excludes  []string
+	}{
+		{
+			name:     "code without criteria",
+			prompt:   NewCodePrompt("void main() {}"),
+			contains: []string{"void main() {}", "Build a counter app", `"overall_status"`},
+			excludes: []string{`"criterion_id"`, `"file_path"`, "Task-specific criteria"},
+		},
+		{
+			name:     "code with criteria",
+			prompt:   NewCodePrompt("void main() {}"),
+			criteria: testCriteria(),
+			contains: []string{"[ID: 7, Mandatory, Weight: 3] Reset", "[ID: 9, Optional, Weight: 1] Tests", `"criterion_id"`, "Task criteria verdicts"},
+			excludes: []string{`"file_path"`},
+		},
+		{
+			name:      "project",
+			prompt:    NewProjectPrompt(files),
+			multiFile: true,
+			contains:  []string{"=== File: lib/main.dart ===", `"file_path": "lib/main.dart"`, "Project Structure"},
+			excludes:  []string{`"criterion_id"`, "This is part"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.prompt.UserPrompt(testTask(), tt.criteria)
+			for _, want := range tt.contains {
+				if !strings.Contains(got, want) {
+					t.Errorf("user prompt does not contain %q", want)
+				}
+			}
+			for _, unwanted := range tt.excludes {
+				if strings.Contains(got, unwanted) {
+					t.Errorf("user prompt contains %q", unwanted)
+				}
+			}
+			if !strings.Contains(got, buildResponseSchema(tt.multiFile, len(tt.criteria) > 0)) {
+				t.Error("user prompt does not include its response schema")
+			}
+			if got := tt.prompt.ResponseFormat(tt.criteria); got != buildResponseFormat(tt.multiFile, len(tt.criteria) > 0) {
+				t.Errorf("ResponseFormat() = %q", got)
+			}
+		})
+	}
+}
+
+func TestProjectPromptOrdersFilesByPriority(t *testing.T) {
+	prompt := NewProjectPrompt(map[string]string{
+		"test/widget_test.dart": "// test",
+		"pubspec.yaml":          "name: app",
+		"lib/widgets/b.dart":    "// b",
+		"lib/main.dart":         "// main",
+		"lib/a.dart":            "// a",
+	})
+
+	got := prompt.UserPrompt(testTask(), nil)
+
+	order := []string{"lib/main.dart", "lib/a.dart", "lib/widgets/b.dart", "pubspec.yaml", "test/widget_test.dart"}
+	last := -1
+	for _, path := range order {
+		i := strings.Index(got, "=== File: "+path+" ===")
+		if i < last {
+			t.Errorf("%s is listed out of order", path)
+		}
+		last = i
+	}
+}
+
+func TestProjectPromptSplit(t *testing.T) {
+	// Each file fills most of the minimum part budget, so every part holds
+	// one of them; huge.dart does not fit into any part.
+	body := strings.Repeat("x", 2100)
+	files := map[string]string{
+		"lib/main.dart":       body,
+		"lib/a.dart":          body,
+		"lib/b.dart":          body,
+		"lib/model.g.dart":    body,
+		"lib/huge.dart":       strings.Repeat("y", 4000),
+		"test/main_test.dart": body,
+	}
+
+	prompt := NewProjectPrompt(files).(splittablePrompt)
+	parts := prompt.Split(testTask(), nil, 100)
+
+	if len(parts) != 4 {
+		t.Fatalf("got %d parts, want 4", len(parts))
+	}
+
+	var covered []string
+	for i, part := range parts {
+		partFiles := reviewedFiles(part)
+		if len(partFiles) != 1 {
+			t.Errorf("part %d has %d files, want 1", i+1, len(partFiles))
+		}
+		for path := range partFiles {
+			covered = append(covered, path)
+		}
+
+		user := part.UserPrompt(testTask(), nil)
+		if !strings.Contains(user, "This is part ") || !strings.Contains(user, " of 4 of the project") {
+			t.Errorf("part %d does not say which part it is", i+1)
+		}
+
+		wantOversized := []string(nil)
+		if i == 0 {
+			wantOversized = []string{"lib/huge.dart"}
+		}
+		if got := oversizedFiles(part); !reflect.DeepEqual(got, wantOversized) {
+			t.Errorf("part %d oversized files = %v, want %v", i+1, got, wantOversized)
+		}
+	}
+
+	sort.Strings(covered)
+	want := []string{"lib/a.dart", "lib/b.dart", "lib/main.dart", "test/main_test.dart"}
+	if !reflect.DeepEqual(covered, want) {
+		t.Errorf("parts cover %v, want %v without generated and oversized files", covered, want)
+	}
+}
+
+func TestReReviewPrompt(t *testing.T) {
+	path := "lib/main.dart"
+	lineEnd := 12
+	prior := []*domain.ReviewFeedback{
+		{ID: 41, FeedbackType: "logic_error", FilePath: &path, LineStart: 10, LineEnd: &lineEnd, Severity: 4, Description: "Counter never resets"},
+	}
+	diffs := []FileDiff{{Path: path, Status: "modified", AddedLines: 2, RemovedLines: 1, Patch: "+reset();"}}
+
+	tests := []struct {
+		name     string
+		prompt   ReviewPrompt
+		kind     string
+		contains []string
+		resolved bool
+	}{
+		{
+			name:     "with previous issues",
+			prompt:   NewReReviewPrompt(NewProjectPrompt(map[string]string{path: "void main() {}"}), diffs, prior),
+			kind:     "project_rereview",
+			contains: []string{"=== lib/main.dart (modified, +2 -1) ===", "+reset();", "[ID: 41] (logic_error, lib/main.dart, lines 10-12, severity 4) Counter never resets", `"resolved_feedback_ids"`},
+			resolved: true,
+		},
+		{
+			name:     "without changes or issues",
+			prompt:   NewReReviewPrompt(NewCodePrompt("void main() {}"), nil, nil),
+			kind:     "code_rereview",
+			contains: []string{"This is a resubmission", "No changes to these files"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.prompt.Kind(); got != tt.kind {
+				t.Errorf("Kind() = %q, want %q", got, tt.kind)
+			}
+
+			user := tt.prompt.UserPrompt(testTask(), nil)
+			for _, want := range tt.contains {
+				if !strings.Contains(user, want) {
+					t.Errorf("user prompt does not contain %q", want)
+				}
+			}
+
+			format := tt.prompt.ResponseFormat(nil)
+			if got := strings.Contains(format, "resolved_feedback_ids"); got != tt.resolved {
+				t.Errorf("response format asks for resolved_feedback_ids: %t, want %t", got, tt.resolved)
+			}
+		})
+	}
+}
+
+func TestReReviewPromptSplitKeepsIssuesWithTheirFiles(t *testing.T) {
+	body := strings.Repeat("x", 2100)
+	a, b := "lib/a.dart", "lib/b.dart"
+	prior := []*domain.ReviewFeedback{
+		{ID: 1, FilePath: &a, Description: "issue in a"},
+		{ID: 2, FilePath: &b, Description: "issue in b"},
+		{ID: 3, Description: "issue without a file"},
+	}
+	diffs := []FileDiff{{Path: a, Patch: "+a"}, {Path: b, Patch: "+b"}}
+
+	prompt := NewReReviewPrompt(NewProjectPrompt(map[string]string{a: body, b: body}), diffs, prior).(splittablePrompt)
+	parts := prompt.Split(testTask(), nil, 100)
+	if len(parts) != 2 {
+		t.Fatalf("got %d parts, want 2", len(parts))
+	}
+
+	for i, part := range parts {
+		sub := part.(*reReviewPrompt)
+		for path := range reviewedFiles(sub) {
+			for _, d := range sub.diffs {
+				if d.Path != path {
+					t.Errorf("part %d with %s carries the diff of %s", i+1, path, d.Path)
+				}
+			}
+			for _, fb := range sub.prior {
+				if fb.FilePath != nil && *fb.FilePath != path {
+					t.Errorf("part %d with %s carries issue %d of %s", i+1, path, fb.ID, *fb.FilePath)
+				}
+				if fb.FilePath == nil && i != 0 {
+					t.Errorf("part %d carries the issue without a file", i+1)
+				}
+			}
+		}
+	}
+}
+
+func TestWrappingPrompts(t *testing.T) {
+	base := NewCodePrompt("void main() { print('hi'); }")
+
+	if got := NewStaticAnalysisPrompt(base, nil); got != base {
+		t.Error("NewStaticAnalysisPrompt without issues wrapped the prompt")
+	}
+	if got := NewLanguagePrompt(base, domain.Language("de")); got != base {
+		t.Error("NewLanguagePrompt with an unsupported language wrapped the prompt")
+	}
+
+	lint := NewStaticAnalysisPrompt(base, []LintIssue{{Rule: "avoid_print", Line: 1, Message: "Avoid print calls"}})
+	if user := lint.UserPrompt(testTask(), nil); !strings.Contains(user, "- [avoid_print] (line 1) Avoid print calls") {
+		t.Errorf("static analysis prompt does not list the issue:\n%s", user)
+	}
+
+	russian := NewLanguagePrompt(lint, domain.LanguageRussian)
+	if system := russian.SystemPrompt(); !strings.HasSuffix(system, "Write all explanations for the student in Russian.") {
+		t.Errorf("system prompt = %q, want the language instruction", system)
+	}
+	if user := russian.UserPrompt(testTask(), nil); !strings.Contains(user, "avoid_print") || !strings.Contains(user, `"evidence" in Russian`) {
+		t.Error("language prompt lost the static analysis section or its own")
+	}
+
+	for _, prompt := range []ReviewPrompt{lint, russian} {
+		if prompt.Kind() != base.Kind() {
+			t.Errorf("Kind() = %q, want the base kind %q", prompt.Kind(), base.Kind())
+		}
+		if prompt.ResponseFormat(testCriteria()) != base.ResponseFormat(testCriteria()) {
+			t.Error("wrapping prompt changed the response format")
+		}
+	}
+}
+
+func TestBuildRepairPrompt(t *testing.T) {
+	format := NewProjectPrompt(nil).ResponseFormat(testCriteria())
+	got := buildRepairPrompt(`{"overall_status": }`, errors.New("invalid character '}'"), format)
+
+	for _, want := range []string{
+		"Your previous answer could not be used: invalid character '}'.",
+		format,
+		"Previous answer:\n{\"overall_status\": }",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("repair prompt does not contain %q", want)
+		}
+	}
+}
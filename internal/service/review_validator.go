@@ -0,0 +1,347 @@
+package service
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/ilyin-ad/flutter-code-mentor/internal/domain"
+)
+
+var (
+	validOverallStatuses = map[string]bool{
+		"passed":            true,
+		"failed":            true,
+		"needs_improvement": true,
+	}
+
+	overallStatusAliases = map[string]string{
+		"pass":        "passed",
+		"ok":          "passed",
+		"success":     "passed",
+		"fail":        "failed",
+		"error":       "failed",
+		"needs_work":  "needs_improvement",
+		"improvement": "needs_improvement",
+		"partial":     "needs_improvement",
+	}
+
+	feedbackTypeAliases = map[string]string{
+		"critical":          "critical_error",
+		"error":             "critical_error",
+		"syntax_error":      "critical_error",
+		"type_error":        "critical_error",
+		"null_safety":       "critical_error",
+		"compile_error":     "critical_error",
+		"logic":             "logic_error",
+		"logical_error":     "logic_error",
+		"bug":               "logic_error",
+		"runtime_error":     "logic_error",
+		"style":             "style_issue",
+		"formatting":        "style_issue",
+		"naming":            "style_issue",
+		"code_style":        "style_issue",
+		"perf":              "performance",
+		"performance_issue": "performance",
+		"security":          "security_risk",
+		"security_issue":    "security_risk",
+		"vulnerability":     "security_risk",
+		"suggestion":        "improvement",
+		"best_practice":     "improvement",
+		"refactoring":       "improvement",
+		"maintainability":   "improvement",
+		"criteria":          "improvement",
+	}
+
+	validVerdicts = map[string]bool{
+		"met":           true,
+		"partially_met": true,
+		"not_met":       true,
+	}
+
+	verdictAliases = map[string]string{
+		"yes":           "met",
+		"passed":        "met",
+		"satisfied":     "met",
+		"fulfilled":     "met",
+		"done":          "met",
+		"partial":       "partially_met",
+		"partially":     "partially_met",
+		"partly_met":    "partially_met",
+		"no":            "not_met",
+		"failed":        "not_met",
+		"missing":       "not_met",
+		"unmet":         "not_met",
+		"not_satisfied": "not_met",
+	}
+
+	firstIntPattern = regexp.MustCompile(`-?\d+`)
+)
+
+const (
+	defaultSeverity   = 3
+	maxFilePathLength = 500
+)
+
+// flexInt accepts numbers, numeric strings and ranges like "1-5" (the first
+// number wins), because models rarely stick to the declared type.
+type flexInt int
+
+func (v *flexInt) UnmarshalJSON(data []byte) error {
+	var n float64
+	if err := json.Unmarshal(data, &n); err == nil {
+		*v = flexInt(n)
+		return nil
+	}
+
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		*v = 0
+		return nil
+	}
+
+	n2, err := strconv.Atoi(firstIntPattern.FindString(s))
+	if err != nil {
+		*v = 0
+		return nil
+	}
+	*v = flexInt(n2)
+	return nil
+}
+
+type flexFloat float64
+
+func (v *flexFloat) UnmarshalJSON(data []byte) error {
+	var n float64
+	if err := json.Unmarshal(data, &n); err == nil {
+		*v = flexFloat(n)
+		return nil
+	}
+
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		*v = 0
+		return nil
+	}
+
+	n, err := strconv.ParseFloat(strings.TrimSuffix(strings.TrimSpace(s), "%"), 64)
+	if err != nil {
+		*v = 0
+		return nil
+	}
+	*v = flexFloat(n)
+	return nil
+}
+
+// parseReviewResponse extracts the review JSON from a raw model answer and
+// normalises it to the values accepted by the code_reviews and
+// review_feedback constraints. The returned notes list every correction made.
+func parseReviewResponse(content string) (*aiReviewResponse, []string, error) {
+	raw, ok := extractJSONObject(content)
+	if !ok {
+		return nil, nil, fmt.Errorf("failed to parse AI response: no JSON object found")
+	}
+
+	raw = stripTrailingCommas(raw)
+
+	var aiReview aiReviewResponse
+	if err := json.Unmarshal([]byte(raw), &aiReview); err != nil {
+		return nil, nil, fmt.Errorf("failed to parse AI response: %w", err)
+	}
+
+	notes := normalizeReview(&aiReview)
+	return &aiReview, notes, nil
+}
+
+func extractJSONObject(content string) (string, bool) {
+	start := strings.Index(content, "{")
+	if start < 0 {
+		return "", false
+	}
+
+	depth := 0
+	inString := false
+	escaped := false
+	for i := start; i < len(content); i++ {
+		c := content[i]
+		if inString {
+			switch {
+			case escaped:
+				escaped = false
+			case c == '\\':
+				escaped = true
+			case c == '"':
+				inString = false
+			}
+			continue
+		}
+
+		switch c {
+		case '"':
+			inString = true
+		case '{':
+			depth++
+		case '}':
+			depth--
+			if depth == 0 {
+				return content[start : i+1], true
+			}
+		}
+	}
+
+	return "", false
+}
+
+func stripTrailingCommas(raw string) string {
+	var b strings.Builder
+	b.Grow(len(raw))
+
+	inString := false
+	escaped := false
+	for i := 0; i < len(raw); i++ {
+		c := raw[i]
+		if inString {
+			switch {
+			case escaped:
+				escaped = false
+			case c == '\\':
+				escaped = true
+			case c == '"':
+				inString = false
+			}
+			b.WriteByte(c)
+			continue
+		}
+
+		if c == '"' {
+			inString = true
+		}
+
+		if c == ',' {
+			j := i + 1
+			for j < len(raw) && strings.ContainsRune(" \t\r\n", rune(raw[j])) {
+				j++
+			}
+			if j < len(raw) && (raw[j] == '}' || raw[j] == ']') {
+				continue
+			}
+		}
+
+		b.WriteByte(c)
+	}
+
+	return b.String()
+}
+
+func normalizeReview(review *aiReviewResponse) []string {
+	var notes []string
+
+	confidence := float64(review.Confidence)
+	if confidence > 1 && confidence <= 100 {
+		confidence /= 100
+	}
+	confidence = clampFloat(confidence, 0, 1)
+	if confidence != float64(review.Confidence) {
+		notes = append(notes, fmt.Sprintf("confidence %v clamped to %v", review.Confidence, confidence))
+		review.Confidence = flexFloat(confidence)
+	}
+
+	maxSeverity := 0
+	for i := range review.Feedbacks {
+		fb := &review.Feedbacks[i]
+
+		feedbackType := normalizeEnum(fb.Type, domain.FeedbackTypes, feedbackTypeAliases, "improvement")
+		if feedbackType != fb.Type {
+			notes = append(notes, fmt.Sprintf("feedbacks[%d].type %q mapped to %q", i, fb.Type, feedbackType))
+			fb.Type = feedbackType
+		}
+
+		severity := int(fb.Severity)
+		if severity == 0 {
+			severity = defaultSeverity
+		}
+		severity = clampInt(severity, 1, 5)
+		if severity != int(fb.Severity) {
+			notes = append(notes, fmt.Sprintf("feedbacks[%d].severity %d set to %d", i, fb.Severity, severity))
+			fb.Severity = flexInt(severity)
+		}
+		maxSeverity = max(maxSeverity, severity)
+
+		if fb.LineStart < 1 {
+			notes = append(notes, fmt.Sprintf("feedbacks[%d].line_start %d set to 1", i, fb.LineStart))
+			fb.LineStart = 1
+		}
+		if fb.LineEnd < fb.LineStart {
+			if fb.LineEnd != 0 {
+				notes = append(notes, fmt.Sprintf("feedbacks[%d].line_end %d set to %d", i, fb.LineEnd, fb.LineStart))
+			}
+			fb.LineEnd = fb.LineStart
+		}
+
+		if len(fb.FilePath) > maxFilePathLength {
+			fb.FilePath = fb.FilePath[:maxFilePathLength]
+		}
+	}
+
+	for i := range review.Criteria {
+		c := &review.Criteria[i]
+
+		verdict := normalizeEnum(c.Verdict, validVerdicts, verdictAliases, "not_met")
+		if verdict != c.Verdict {
+			notes = append(notes, fmt.Sprintf("criteria[%d].verdict %q mapped to %q", i, c.Verdict, verdict))
+			c.Verdict = verdict
+		}
+	}
+
+	fallback := "passed"
+	switch {
+	case maxSeverity >= 5:
+		fallback = "failed"
+	case maxSeverity >= 3:
+		fallback = "needs_improvement"
+	}
+	status := normalizeEnum(review.OverallStatus, validOverallStatuses, overallStatusAliases, fallback)
+	if status != review.OverallStatus {
+		notes = append(notes, fmt.Sprintf("overall_status %q mapped to %q", review.OverallStatus, status))
+		review.OverallStatus = status
+	}
+
+	return notes
+}
+
+func normalizeEnum(value string, valid map[string]bool, aliases map[string]string, fallback string) string {
+	key := strings.ToLower(strings.TrimSpace(value))
+	key = strings.NewReplacer(" ", "_", "-", "_").Replace(key)
+
+	if valid[key] {
+		return key
+	}
+	if alias, ok := aliases[key]; ok {
+		return alias
+	}
+	return fallback
+}
+
+func clampInt(v, lo, hi int) int {
+	return min(max(v, lo), hi)
+}
+
+func clampFloat(v, lo, hi float64) float64 {
+	return min(max(v, lo), hi)
+}
+
+// buildRepairPrompt asks for the broken answer again in responseFormat, the
+// format of the prompt that produced it; the repair call does not repeat the
+// original user prompt, so the format is spelled out here.
+func buildRepairPrompt(previous string, parseErr error, responseFormat string) string {
+	return fmt.Sprintf(`Your previous answer could not be used: %v.
+
+Return ONLY one valid JSON object. Do not add any text, comments or markdown around it.
+
+%s
+
+Previous answer:
+%s`, parseErr, responseFormat, previous)
+}
@@ -0,0 +1,346 @@
+package service
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestExtractJSONObject(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    string
+		ok      bool
+	}{
+		{name: "bare object", content: `{"a":1}`, want: `{"a":1}`, ok: true},
+		{name: "prose around", content: "Here is the review:\n{\"a\":1}\nHope it helps!", want: `{"a":1}`, ok: true},
+		{name: "markdown fence", content: "```json\n{\"a\":{\"b\":[1,2]}}\n```", want: `{"a":{"b":[1,2]}}`, ok: true},
+		{name: "braces inside strings", content: `{"code":"if (x) { y(); }","q":"\"}"}`, want: `{"code":"if (x) { y(); }","q":"\"}"}`, ok: true},
+		{name: "first object wins", content: `{"a":1} and {"b":2}`, want: `{"a":1}`, ok: true},
+		{name: "no object", content: "I cannot review this code.", ok: false},
+		{name: "unterminated", content: `{"a":{"b":1}`, ok: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := extractJSONObject(tt.content)
+			if ok != tt.ok || got != tt.want {
+				t.Errorf("extractJSONObject() = %q, %t; want %q, %t", got, ok, tt.want, tt.ok)
+			}
+		})
+	}
+}
+
+func TestStripTrailingCommas(t *testing.T) {
+	tests := []struct {
+		name string
+		raw  string
+		want string
+	}{
+		{name: "object", raw: `{"a":1,}`, want: `{"a":1}`},
+		{name: "array with whitespace", raw: "{\"a\":[1,2,\n  ]}", want: "{\"a\":[1,2\n  ]}"},
+		{name: "nested", raw: `{"a":[{"b":1,},],}`, want: `{"a":[{"b":1}]}`},
+		{name: "commas inside strings kept", raw: `{"a":"x,}","b":"y,]"}`, want: `{"a":"x,}","b":"y,]"}`},
+		{name: "escaped quote inside string", raw: `{"a":"\",}",}`, want: `{"a":"\",}"}`},
+		{name: "valid JSON unchanged", raw: `{"a":[1,2],"b":3}`, want: `{"a":[1,2],"b":3}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := stripTrailingCommas(tt.raw); got != tt.want {
+				t.Errorf("stripTrailingCommas(%q) = %q, want %q", tt.raw, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNormalizeEnum(t *testing.T) {
+	tests := []struct {
+		name     string
+		value    string
+		valid    map[string]bool
+		aliases  map[string]string
+		fallback string
+		want     string
+	}{
+		{name: "valid status", value: "passed", valid: validOverallStatuses, aliases: overallStatusAliases, fallback: "failed", want: "passed"},
+		{name: "case and spaces", value: " Needs Improvement ", valid: validOverallStatuses, aliases: overallStatusAliases, fallback: "failed", want: "needs_improvement"},
+		{name: "dashes", value: "needs-improvement", valid: validOverallStatuses, aliases: overallStatusAliases, fallback: "failed", want: "needs_improvement"},
+		{name: "status alias", value: "OK", valid: validOverallStatuses, aliases: overallStatusAliases, fallback: "failed", want: "passed"},
+		{name: "unknown status", value: "great", valid: validOverallStatuses, aliases: overallStatusAliases, fallback: "failed", want: "failed"},
+		{name: "verdict alias", value: "Partially", valid: validVerdicts, aliases: verdictAliases, fallback: "not_met", want: "partially_met"},
+		{name: "negative verdict alias", value: "not satisfied", valid: validVerdicts, aliases: verdictAliases, fallback: "met", want: "not_met"},
+		{name: "empty verdict", value: "", valid: validVerdicts, aliases: verdictAliases, fallback: "not_met", want: "not_met"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := normalizeEnum(tt.value, tt.valid, tt.aliases, tt.fallback); got != tt.want {
+				t.Errorf("normalizeEnum(%q) = %q, want %q", tt.value, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNormalizeReviewFeedback(t *testing.T) {
+	tests := []struct {
+		name string
+		in   feedbackJSON
+		want feedbackJSON
+	}{
+		{
+			name: "valid item unchanged",
+			in:   feedbackJSON{Type: "logic_error", LineStart: 3, LineEnd: 5, Severity: 4},
+			want: feedbackJSON{Type: "logic_error", LineStart: 3, LineEnd: 5, Severity: 4},
+		},
+		{
+			name: "type alias",
+			in:   feedbackJSON{Type: "Bug", LineStart: 1, LineEnd: 1, Severity: 3},
+			want: feedbackJSON{Type: "logic_error", LineStart: 1, LineEnd: 1, Severity: 3},
+		},
+		{
+			name: "unknown type",
+			in:   feedbackJSON{Type: "nitpick", LineStart: 1, LineEnd: 1, Severity: 1},
+			want: feedbackJSON{Type: "improvement", LineStart: 1, LineEnd: 1, Severity: 1},
+		},
+		{
+			name: "severity above range",
+			in:   feedbackJSON{Type: "performance", LineStart: 1, LineEnd: 1, Severity: 9},
+			want: feedbackJSON{Type: "performance", LineStart: 1, LineEnd: 1, Severity: 5},
+		},
+		{
+			name: "negative severity",
+			in:   feedbackJSON{Type: "performance", LineStart: 1, LineEnd: 1, Severity: -2},
+			want: feedbackJSON{Type: "performance", LineStart: 1, LineEnd: 1, Severity: 1},
+		},
+		{
+			name: "missing severity",
+			in:   feedbackJSON{Type: "performance", LineStart: 1, LineEnd: 1},
+			want: feedbackJSON{Type: "performance", LineStart: 1, LineEnd: 1, Severity: defaultSeverity},
+		},
+		{
+			name: "missing lines",
+			in:   feedbackJSON{Type: "style_issue", Severity: 2},
+			want: feedbackJSON{Type: "style_issue", LineStart: 1, LineEnd: 1, Severity: 2},
+		},
+		{
+			name: "end before start",
+			in:   feedbackJSON{Type: "style_issue", LineStart: 10, LineEnd: 4, Severity: 2},
+			want: feedbackJSON{Type: "style_issue", LineStart: 10, LineEnd: 10, Severity: 2},
+		},
+		{
+			name: "missing end",
+			in:   feedbackJSON{Type: "style_issue", LineStart: 7, Severity: 2},
+			want: feedbackJSON{Type: "style_issue", LineStart: 7, LineEnd: 7, Severity: 2},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			review := &aiReviewResponse{OverallStatus: "passed", Confidence: 0.9, Feedbacks: []feedbackJSON{tt.in}}
+			normalizeReview(review)
+			if got := review.Feedbacks[0]; got != tt.want {
+				t.Errorf("normalizeReview() feedback = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNormalizeReviewConfidence(t *testing.T) {
+	tests := []struct {
+		name string
+		in   flexFloat
+		want flexFloat
+	}{
+		{name: "fraction", in: 0.75, want: 0.75},
+		{name: "percent", in: 85, want: 0.85},
+		{name: "above percent range", in: 250, want: 1},
+		{name: "negative", in: -0.3, want: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			review := &aiReviewResponse{OverallStatus: "passed", Confidence: tt.in}
+			notes := normalizeReview(review)
+			if review.Confidence != tt.want {
+				t.Errorf("confidence %v normalised to %v, want %v", tt.in, review.Confidence, tt.want)
+			}
+			if (tt.in != tt.want) != (len(notes) > 0) {
+				t.Errorf("notes = %q for confidence %v", notes, tt.in)
+			}
+		})
+	}
+}
+
+func TestNormalizeReviewOverallStatus(t *testing.T) {
+	tests := []struct {
+		name     string
+		status   string
+		severity flexInt
+		want     string
+	}{
+		{name: "valid status kept", status: "passed", severity: 5, want: "passed"},
+		{name: "alias", status: "fail", severity: 1, want: "failed"},
+		{name: "unknown with critical issue", status: "?", severity: 5, want: "failed"},
+		{name: "unknown with moderate issue", status: "?", severity: 3, want: "needs_improvement"},
+		{name: "unknown with minor issue", status: "?", severity: 2, want: "passed"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			review := &aiReviewResponse{
+				OverallStatus: tt.status,
+				Confidence:    0.5,
+				Feedbacks:     []feedbackJSON{{Type: "improvement", LineStart: 1, LineEnd: 1, Severity: tt.severity}},
+			}
+			normalizeReview(review)
+			if review.OverallStatus != tt.want {
+				t.Errorf("overall_status %q normalised to %q, want %q", tt.status, review.OverallStatus, tt.want)
+			}
+		})
+	}
+}
+
+func TestNormalizeReviewVerdicts(t *testing.T) {
+	review := &aiReviewResponse{
+		OverallStatus: "passed",
+		Confidence:    0.5,
+		Criteria: []criterionJSON{
+			{CriterionID: 1, Verdict: "met"},
+			{CriterionID: 2, Verdict: "Yes"},
+			{CriterionID: 3, Verdict: "partly met"},
+			{CriterionID: 4, Verdict: "unclear"},
+		},
+	}
+
+	notes := normalizeReview(review)
+
+	want := []string{"met", "met", "partially_met", "not_met"}
+	for i, c := range review.Criteria {
+		if c.Verdict != want[i] {
+			t.Errorf("criteria[%d].verdict = %q, want %q", i, c.Verdict, want[i])
+		}
+	}
+	if len(notes) != 3 {
+		t.Errorf("got %d notes, want one per changed verdict: %q", len(notes), notes)
+	}
+}
+
+func TestFlexNumbers(t *testing.T) {
+	intTests := []struct {
+		raw  string
+		want flexInt
+	}{
+		{raw: `4`, want: 4},
+		{raw: `4.0`, want: 4},
+		{raw: `"3"`, want: 3},
+		{raw: `"1-5"`, want: 1},
+		{raw: `"line 12"`, want: 12},
+		{raw: `"none"`, want: 0},
+		{raw: `null`, want: 0},
+		{raw: `[1]`, want: 0},
+	}
+	for _, tt := range intTests {
+		var got flexInt
+		if err := json.Unmarshal([]byte(tt.raw), &got); err != nil || got != tt.want {
+			t.Errorf("flexInt(%s) = %d, %v; want %d", tt.raw, got, err, tt.want)
+		}
+	}
+
+	floatTests := []struct {
+		raw  string
+		want flexFloat
+	}{
+		{raw: `0.8`, want: 0.8},
+		{raw: `"0.8"`, want: 0.8},
+		{raw: `"85%"`, want: 85},
+		{raw: `"high"`, want: 0},
+	}
+	for _, tt := range floatTests {
+		var got flexFloat
+		if err := json.Unmarshal([]byte(tt.raw), &got); err != nil || got != tt.want {
+			t.Errorf("flexFloat(%s) = %v, %v; want %v", tt.raw, got, err, tt.want)
+		}
+	}
+}
+
+func TestParseReviewResponse(t *testing.T) {
+	tests := []struct {
+		name      string
+		content   string
+		status    string
+		feedbacks int
+		criteria  int
+		resolved  []flexInt
+		notes     int
+		wantErr   bool
+	}{
+		{
+			name:      "clean answer",
+			content:   `{"overall_status":"passed","confidence":0.9,"feedbacks":[{"type":"improvement","line_start":1,"line_end":2,"description":"d","severity":1}]}`,
+			status:    "passed",
+			feedbacks: 1,
+		},
+		{
+			name: "fenced answer with prose and trailing commas",
+			content: "Sure! Here is my review:\n```json\n" + `{
+  "overall_status": "Needs Improvement",
+  "confidence": "80%",
+  "feedbacks": [
+    {"type": "bug", "line_start": "3", "line_end": 1, "description": "d", "severity": "4/5",},
+  ],
+  "criteria": [{"criterion_id": "7", "verdict": "yes", "evidence": "e"},],
+}` + "\n```\nLet me know if you need more.",
+			status:    "needs_improvement",
+			feedbacks: 1,
+			criteria:  1,
+			notes:     5,
+		},
+		{
+			name:     "resolved feedback of a resubmission",
+			content:  `{"overall_status":"passed","confidence":1,"feedbacks":[],"resolved_feedback_ids":[12,"15"]}`,
+			status:   "passed",
+			resolved: []flexInt{12, 15},
+		},
+		{
+			name:    "no JSON",
+			content: "The code looks fine to me.",
+			wantErr: true,
+		},
+		{
+			name:    "broken JSON",
+			content: `{"overall_status": "passed", "feedbacks": [}`,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			review, notes, err := parseReviewResponse(tt.content)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("parseReviewResponse() = %+v, want an error", review)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseReviewResponse(): %v", err)
+			}
+
+			if review.OverallStatus != tt.status {
+				t.Errorf("overall_status = %q, want %q", review.OverallStatus, tt.status)
+			}
+			if len(review.Feedbacks) != tt.feedbacks || len(review.Criteria) != tt.criteria {
+				t.Errorf("got %d feedbacks and %d criteria, want %d and %d",
+					len(review.Feedbacks), len(review.Criteria), tt.feedbacks, tt.criteria)
+			}
+			if !reflect.DeepEqual(review.ResolvedFeedbackIDs, tt.resolved) {
+				t.Errorf("resolved_feedback_ids = %v, want %v", review.ResolvedFeedbackIDs, tt.resolved)
+			}
+			if len(notes) != tt.notes {
+				t.Errorf("got %d notes, want %d: %q", len(notes), tt.notes, notes)
+			}
+		})
+	}
+}
@@ -0,0 +1,116 @@
+package service
+
+import (
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// minFileBudget keeps some room for files even when the task description and
+// criteria alone come close to the prompt limit.
+const minFileBudget = 1000
+
+var generatedDartSuffixes = []string{
+	".g.dart",
+	".freezed.dart",
+	".gr.dart",
+	".mocks.dart",
+	".config.dart",
+	".pb.dart",
+	".pbenum.dart",
+	".pbjson.dart",
+	".pbserver.dart",
+}
+
+// estimateTokens approximates the token count of source code at roughly 3.5
+// characters per token. It errs on the high side for typical Dart.
+func estimateTokens(s string) int {
+	return (utf8.RuneCountInString(s)*2 + 6) / 7
+}
+
+func isGeneratedDartFile(path string) bool {
+	for _, suffix := range generatedDartSuffixes {
+		if strings.HasSuffix(path, suffix) {
+			return true
+		}
+	}
+	return false
+}
+
+// filePriority orders project files by how much they matter for a review:
+// the entry point, then application code, then everything else, then tests.
+func filePriority(path string) int {
+	path = strings.ReplaceAll(path, "\\", "/")
+	switch {
+	case path == "lib/main.dart":
+		return 0
+	case strings.HasPrefix(path, "lib/"):
+		return 1
+	case strings.HasPrefix(path, "test/"),
+		strings.HasPrefix(path, "integration_test/"),
+		strings.HasSuffix(path, "_test.dart"):
+		return 3
+	default:
+		return 2
+	}
+}
+
+// prioritizeFiles drops generated files and sorts the rest by priority, then
+// by path.
+func prioritizeFiles(files map[string]string) (paths []string, skipped []string) {
+	for path := range files {
+		if isGeneratedDartFile(path) {
+			skipped = append(skipped, path)
+			continue
+		}
+		paths = append(paths, path)
+	}
+
+	sort.Slice(paths, func(i, j int) bool {
+		pi, pj := filePriority(paths[i]), filePriority(paths[j])
+		if pi != pj {
+			return pi < pj
+		}
+		return paths[i] < paths[j]
+	})
+	sort.Strings(skipped)
+
+	return paths, skipped
+}
+
+// groupFilesByBudget packs files in priority order into groups that each fit
+// into budget tokens. A file larger than the budget on its own cannot be
+// reviewed; it is left out and returned in oversized.
+func groupFilesByBudget(files map[string]string, paths []string, budget int) (groups []map[string]string, oversized []string) {
+	budget = max(budget, minFileBudget)
+
+	current := make(map[string]string)
+	used := 0
+
+	for _, path := range paths {
+		content := files[path]
+		cost := estimateTokens(path) + estimateTokens(content) + fileHeaderTokens
+
+		if cost > budget {
+			oversized = append(oversized, path)
+			continue
+		}
+
+		if used+cost > budget && len(current) > 0 {
+			groups = append(groups, current)
+			current = make(map[string]string)
+			used = 0
+		}
+
+		current[path] = content
+		used += cost
+	}
+
+	if len(current) > 0 {
+		groups = append(groups, current)
+	}
+
+	return groups, oversized
+}
+
+const fileHeaderTokens = 10
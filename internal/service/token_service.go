@@ -0,0 +1,145 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/ilyin-ad/flutter-code-mentor/internal/config"
+	"github.com/ilyin-ad/flutter-code-mentor/internal/domain"
+)
+
+var ErrInvalidToken = errors.New("invalid token")
+
+const (
+	tokenTypeAccess  = "access"
+	tokenTypeRefresh = "refresh"
+)
+
+type TokenService interface {
+	IssueTokens(user *domain.User) (*TokenPair, error)
+	ParseAccessToken(token string) (*domain.Principal, error)
+	ParseRefreshToken(token string) (int, error)
+}
+
+type TokenPair struct {
+	AccessToken  string
+	RefreshToken string
+	ExpiresIn    int
+}
+
+type tokenService struct {
+	secret     []byte
+	issuer     string
+	accessTTL  time.Duration
+	refreshTTL time.Duration
+}
+
+type tokenClaims struct {
+	Role string `json:"role,omitempty"`
+	Type string `json:"typ"`
+	jwt.RegisteredClaims
+}
+
+func NewTokenService(cfg *config.Config) TokenService {
+	return &tokenService{
+		secret:     []byte(cfg.Auth.JWTSecret),
+		issuer:     cfg.Auth.Issuer,
+		accessTTL:  cfg.Auth.AccessTokenTTL,
+		refreshTTL: cfg.Auth.RefreshTokenTTL,
+	}
+}
+
+func (s *tokenService) IssueTokens(user *domain.User) (*TokenPair, error) {
+	accessToken, err := s.sign(user, tokenTypeAccess, s.accessTTL)
+	if err != nil {
+		return nil, err
+	}
+
+	refreshToken, err := s.sign(user, tokenTypeRefresh, s.refreshTTL)
+	if err != nil {
+		return nil, err
+	}
+
+	return &TokenPair{
+		AccessToken:  accessToken,
+		RefreshToken: refreshToken,
+		ExpiresIn:    int(s.accessTTL.Seconds()),
+	}, nil
+}
+
+func (s *tokenService) ParseAccessToken(token string) (*domain.Principal, error) {
+	claims, err := s.parse(token, tokenTypeAccess)
+	if err != nil {
+		return nil, err
+	}
+
+	userID, err := strconv.Atoi(claims.Subject)
+	if err != nil {
+		return nil, ErrInvalidToken
+	}
+
+	return &domain.Principal{
+		UserID: userID,
+		Role:   claims.Role,
+	}, nil
+}
+
+func (s *tokenService) ParseRefreshToken(token string) (int, error) {
+	claims, err := s.parse(token, tokenTypeRefresh)
+	if err != nil {
+		return 0, err
+	}
+
+	userID, err := strconv.Atoi(claims.Subject)
+	if err != nil {
+		return 0, ErrInvalidToken
+	}
+
+	return userID, nil
+}
+
+func (s *tokenService) sign(user *domain.User, tokenType string, ttl time.Duration) (string, error) {
+	now := time.Now()
+	claims := tokenClaims{
+		Type: tokenType,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Subject:   strconv.Itoa(user.ID),
+			Issuer:    s.issuer,
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(ttl)),
+		},
+	}
+	if tokenType == tokenTypeAccess {
+		claims.Role = user.Role
+	}
+
+	signed, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(s.secret)
+	if err != nil {
+		return "", fmt.Errorf("failed to sign token: %w", err)
+	}
+
+	return signed, nil
+}
+
+func (s *tokenService) parse(token, tokenType string) (*tokenClaims, error) {
+	claims := &tokenClaims{}
+	_, err := jwt.ParseWithClaims(token, claims, func(t *jwt.Token) (any, error) {
+		return s.secret, nil
+	},
+		jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}),
+		jwt.WithIssuer(s.issuer),
+		jwt.WithExpirationRequired(),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidToken, err)
+	}
+
+	if claims.Type != tokenType {
+		return nil, ErrInvalidToken
+	}
+
+	return claims, nil
+}
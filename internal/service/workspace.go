@@ -0,0 +1,233 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+var (
+	ErrWorkspaceQuotaExceeded = errors.New("clone workspace disk quota exceeded")
+	ErrCloneTooLarge          = errors.New("repository exceeds the clone size limit")
+	ErrCloneTimeout           = errors.New("repository clone timed out")
+)
+
+// workspacePrefix marks directories created by the service, so the janitor
+// never touches anything else in a shared temp directory.
+const workspacePrefix = "ws-"
+
+const cloneSizeCheckInterval = 500 * time.Millisecond
+
+// hardenedGitConfig is passed to every git call on student repositories:
+// their hooks, symlinks, submodules and filters must not run or resolve on
+// the review host.
+var hardenedGitConfig = []string{
+	"-c", "core.hooksPath=/dev/null",
+	"-c", "core.symlinks=false",
+	"-c", "core.fsmonitor=false",
+	"-c", "submodule.recurse=false",
+	"-c", "credential.helper=",
+	"-c", "protocol.ext.allow=never",
+	"-c", "filter.lfs.process=",
+	"-c", "filter.lfs.smudge=",
+	"-c", "filter.lfs.required=false",
+}
+
+var hardenedGitEnv = []string{
+	"GIT_TERMINAL_PROMPT=0",
+	"GIT_ASKPASS=",
+	"SSH_ASKPASS=",
+	"GIT_CONFIG_NOSYSTEM=1",
+	"GIT_CONFIG_GLOBAL=" + os.DevNull,
+	"GIT_LFS_SKIP_SMUDGE=1",
+}
+
+// gitCommand builds a git invocation with the hardened config. Local file
+// transport stays enabled only for file:// repositories.
+func gitCommand(ctx context.Context, dir string, allowFileProtocol bool, args ...string) *exec.Cmd {
+	fileProtocol := "never"
+	if allowFileProtocol {
+		fileProtocol = "always"
+	}
+
+	full := append([]string{}, hardenedGitConfig...)
+	full = append(full, "-c", "protocol.file.allow="+fileProtocol)
+	full = append(full, args...)
+
+	cmd := exec.CommandContext(ctx, "git", full...)
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), hardenedGitEnv...)
+	return cmd
+}
+
+// newWorkspace creates a unique directory for one clone or archive and
+// reserves up to reserve bytes of the quota for it until releaseWorkspace is
+// called. The check and the reservation happen under one lock, so parallel
+// workers cannot all pass the check and overrun the quota together.
+func (s *githubService) newWorkspace(name string, reserve int64) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.workspace.DiskQuotaMB > 0 {
+		used, err := s.workspaceUsage()
+		if err != nil {
+			return "", fmt.Errorf("failed to measure workspace usage: %w", err)
+		}
+		if used+reserve > int64(s.workspace.DiskQuotaMB)<<20 {
+			return "", fmt.Errorf("%w: %d MB in use", ErrWorkspaceQuotaExceeded, used>>20)
+		}
+	}
+
+	path, err := os.MkdirTemp(s.tempDir, workspacePrefix+name+"-")
+	if err != nil {
+		return "", fmt.Errorf("failed to create clone workspace: %w", err)
+	}
+	s.reserved[path] = reserve
+	return path, nil
+}
+
+// releaseWorkspace drops the reservation of a workspace once it is filled;
+// from then on the files on disk count against the quota instead.
+func (s *githubService) releaseWorkspace(path string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.reserved, path)
+}
+
+// workspaceUsage is the size of all workspaces on disk, with every workspace
+// still being filled counted at its full reservation. The caller holds s.mu.
+func (s *githubService) workspaceUsage() (int64, error) {
+	used, err := dirSize(s.tempDir)
+	if err != nil {
+		return 0, err
+	}
+
+	for path, reserve := range s.reserved {
+		size, err := dirSize(path)
+		if err != nil {
+			return 0, err
+		}
+		if reserve > size {
+			used += reserve - size
+		}
+	}
+	return used, nil
+}
+
+// withCloneLimits runs fn under the clone timeout and cancels it as soon as
+// the workspace grows past the clone size limit.
+func (s *githubService) withCloneLimits(ctx context.Context, repoPath string, fn func(ctx context.Context) error) error {
+	if s.workspace.CloneTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, s.workspace.CloneTimeout)
+		defer cancel()
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	limit := int64(s.workspace.CloneMaxSizeMB) << 20
+	tooLarge := make(chan struct{})
+	done := make(chan struct{})
+	defer close(done)
+
+	if limit > 0 {
+		go func() {
+			ticker := time.NewTicker(cloneSizeCheckInterval)
+			defer ticker.Stop()
+			for {
+				select {
+				case <-done:
+					return
+				case <-ticker.C:
+					if size, err := dirSize(repoPath); err == nil && size > limit {
+						close(tooLarge)
+						cancel()
+						return
+					}
+				}
+			}
+		}()
+	}
+
+	err := fn(ctx)
+
+	select {
+	case <-tooLarge:
+		return fmt.Errorf("%w of %d MB", ErrCloneTooLarge, s.workspace.CloneMaxSizeMB)
+	default:
+	}
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return fmt.Errorf("%w after %s", ErrCloneTimeout, s.workspace.CloneTimeout)
+	}
+	if err != nil {
+		return err
+	}
+
+	if limit > 0 {
+		if size, err := dirSize(repoPath); err == nil && size > limit {
+			return fmt.Errorf("%w of %d MB", ErrCloneTooLarge, s.workspace.CloneMaxSizeMB)
+		}
+	}
+	return nil
+}
+
+// ReclaimWorkspaces removes clone workspaces left behind by a crashed or
+// killed process. Only directories older than the orphan age are removed,
+// so a second instance sharing the directory keeps its running clones.
+func (s *githubService) ReclaimWorkspaces() (int, error) {
+	entries, err := os.ReadDir(s.tempDir)
+	if err != nil {
+		return 0, fmt.Errorf("failed to list workspaces: %w", err)
+	}
+
+	cutoff := time.Now().Add(-s.workspace.OrphanAge)
+	removed := 0
+	for _, entry := range entries {
+		if !entry.IsDir() || !strings.HasPrefix(entry.Name(), workspacePrefix) {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil || info.ModTime().After(cutoff) {
+			continue
+		}
+
+		path := filepath.Join(s.tempDir, entry.Name())
+		if err := os.RemoveAll(path); err != nil {
+			s.logger.Warn("Failed to remove orphaned workspace", zap.String("path", path), zap.Error(err))
+			continue
+		}
+		removed++
+	}
+
+	return removed, nil
+}
+
+func dirSize(root string) (int64, error) {
+	var size int64
+	err := filepath.WalkDir(root, func(_ string, d fs.DirEntry, err error) error {
+		if err != nil {
+			// Files vanish while a clone or cleanup is running.
+			if errors.Is(err, fs.ErrNotExist) {
+				return nil
+			}
+			return err
+		}
+		if d.Type().IsRegular() {
+			if info, err := d.Info(); err == nil {
+				size += info.Size()
+			}
+		}
+		return nil
+	})
+	return size, err
+}
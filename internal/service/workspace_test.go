@@ -0,0 +1,38 @@
+package service
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestNewWorkspaceReservesQuota(t *testing.T) {
+	s := newTestGitHubService(t)
+	reserve := int64(s.workspace.CloneMaxSizeMB) << 20
+
+	first, err := s.newWorkspace("a", reserve)
+	if err != nil {
+		t.Fatalf("first workspace: %v", err)
+	}
+	second, err := s.newWorkspace("b", reserve)
+	if err != nil {
+		t.Fatalf("second workspace: %v", err)
+	}
+
+	// Both clones may still grow to the limit, so a third must wait.
+	if _, err := s.newWorkspace("c", reserve); !errors.Is(err, ErrWorkspaceQuotaExceeded) {
+		t.Fatalf("third workspace error = %v, want ErrWorkspaceQuotaExceeded", err)
+	}
+
+	// A finished clone counts with its size on disk only.
+	s.releaseWorkspace(first)
+	third, err := s.newWorkspace("c", reserve)
+	if err != nil {
+		t.Fatalf("workspace after release: %v", err)
+	}
+
+	s.releaseWorkspace(second)
+	s.releaseWorkspace(third)
+	if len(s.reserved) != 0 {
+		t.Errorf("%d reservation(s) left after release", len(s.reserved))
+	}
+}
@@ -0,0 +1,72 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/ilyin-ad/flutter-code-mentor/internal/domain"
+	"github.com/ilyin-ad/flutter-code-mentor/internal/repository"
+)
+
+func principalFromContext(ctx context.Context) (*domain.Principal, error) {
+	principal, ok := domain.PrincipalFromContext(ctx)
+	if !ok {
+		return nil, ErrUnauthorized
+	}
+	return principal, nil
+}
+
+// authorizeCourse allows admins and the teacher who owns the course.
+func authorizeCourse(principal *domain.Principal, course *domain.Course) error {
+	if principal.HasRole(domain.RoleAdmin) {
+		return nil
+	}
+	if principal.HasRole(domain.RoleTeacher) && course.TeacherID == principal.UserID {
+		return nil
+	}
+	return ErrUnauthorized
+}
+
+// authorizeTask resolves the task's course and applies authorizeCourse.
+func authorizeTask(ctx context.Context, courseRepo repository.CourseRepository, principal *domain.Principal, task *domain.Task) error {
+	if principal.HasRole(domain.RoleAdmin) {
+		return nil
+	}
+
+	course, err := courseRepo.GetByID(ctx, task.CourseID)
+	if err != nil {
+		return fmt.Errorf("failed to get course: %w", err)
+	}
+	if course == nil {
+		return ErrCourseNotFound
+	}
+
+	return authorizeCourse(principal, course)
+}
+
+// authorizeSubmission lets students see only their own submissions and
+// teachers only submissions to tasks of their courses.
+func authorizeSubmission(
+	ctx context.Context,
+	taskRepo repository.TaskRepository,
+	courseRepo repository.CourseRepository,
+	principal *domain.Principal,
+	submission *domain.Submission,
+) error {
+	if principal.HasRole(domain.RoleStudent) {
+		if submission.StudentID != principal.UserID {
+			return ErrUnauthorized
+		}
+		return nil
+	}
+
+	task, err := taskRepo.GetByID(ctx, submission.TaskID)
+	if err != nil {
+		return fmt.Errorf("failed to get task: %w", err)
+	}
+	if task == nil {
+		return ErrTaskNotFound
+	}
+
+	return authorizeTask(ctx, courseRepo, principal, task)
+}
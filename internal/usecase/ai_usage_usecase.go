@@ -0,0 +1,222 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/ilyin-ad/flutter-code-mentor/internal/config"
+	"github.com/ilyin-ad/flutter-code-mentor/internal/domain"
+	"github.com/ilyin-ad/flutter-code-mentor/internal/repository"
+	"go.uber.org/zap"
+)
+
+type AIUsageUseCase interface {
+	GetCourseUsage(ctx context.Context, courseID int, from, to *time.Time) (*CourseAIUsage, error)
+	SetCourseBudget(ctx context.Context, req *SetAIBudgetRequest) (*AIBudgetStatus, error)
+}
+
+type aiUsageUseCase struct {
+	aiUsageRepo repository.AIUsageRepository
+	courseRepo  repository.CourseRepository
+	budgetCfg   config.AIBudgetConfig
+	logger      *zap.Logger
+}
+
+func NewAIUsageUseCase(
+	cfg *config.Config,
+	aiUsageRepo repository.AIUsageRepository,
+	courseRepo repository.CourseRepository,
+	logger *zap.Logger,
+) AIUsageUseCase {
+	return &aiUsageUseCase{
+		aiUsageRepo: aiUsageRepo,
+		courseRepo:  courseRepo,
+		budgetCfg:   cfg.AIBudget,
+		logger:      logger,
+	}
+}
+
+// CourseAIUsage is the AI consumption of a course in the requested window,
+// in total and per student, with the state of its budget.
+type CourseAIUsage struct {
+	CourseID int
+	From     *time.Time
+	To       *time.Time
+	Total    *domain.AIUsage
+	Students []*domain.StudentAIUsage
+	Budget   *AIBudgetStatus
+}
+
+// AIBudgetStatus is the budget in effect for a course and what it has spent
+// against it; a zero limit means no limit. While Paused, AI reviews of the
+// course wait in the queue until ResumesAt or until the budget is raised.
+type AIBudgetStatus struct {
+	DailyLimit     float64
+	MonthlyLimit   float64
+	SpentToday     float64
+	SpentThisMonth float64
+	Paused         bool
+	ResumesAt      *time.Time
+}
+
+// SetAIBudgetRequest sets the course's limits; a nil limit restores the
+// configured default.
+type SetAIBudgetRequest struct {
+	CourseID     int
+	DailyLimit   *float64
+	MonthlyLimit *float64
+}
+
+func (uc *aiUsageUseCase) GetCourseUsage(ctx context.Context, courseID int, from, to *time.Time) (*CourseAIUsage, error) {
+	if err := uc.authorize(ctx, courseID); err != nil {
+		return nil, err
+	}
+
+	if from != nil && to != nil && !to.After(*from) {
+		return nil, &ValidationError{
+			Message: "Validation failed",
+			Details: []ValidationErrorDetail{{
+				Field:   "to",
+				Message: "Must be after from",
+			}},
+		}
+	}
+
+	total, err := uc.aiUsageRepo.GetCourseUsage(ctx, courseID, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	students, err := uc.aiUsageRepo.ListStudentUsage(ctx, courseID, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	budget, err := courseBudgetStatus(ctx, uc.aiUsageRepo, uc.budgetCfg, courseID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &CourseAIUsage{
+		CourseID: courseID,
+		From:     from,
+		To:       to,
+		Total:    total,
+		Students: students,
+		Budget:   budget,
+	}, nil
+}
+
+func (uc *aiUsageUseCase) SetCourseBudget(ctx context.Context, req *SetAIBudgetRequest) (*AIBudgetStatus, error) {
+	if err := uc.authorize(ctx, req.CourseID); err != nil {
+		return nil, err
+	}
+
+	var details []ValidationErrorDetail
+	if req.DailyLimit != nil && *req.DailyLimit < 0 {
+		details = append(details, ValidationErrorDetail{
+			Field:   "daily_limit",
+			Message: "Must not be negative",
+		})
+	}
+	if req.MonthlyLimit != nil && *req.MonthlyLimit < 0 {
+		details = append(details, ValidationErrorDetail{
+			Field:   "monthly_limit",
+			Message: "Must not be negative",
+		})
+	}
+	if len(details) > 0 {
+		return nil, &ValidationError{
+			Message: "Validation failed",
+			Details: details,
+		}
+	}
+
+	err := uc.aiUsageRepo.SetBudget(ctx, &domain.AIBudget{
+		CourseID:     req.CourseID,
+		DailyLimit:   req.DailyLimit,
+		MonthlyLimit: req.MonthlyLimit,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	uc.logger.Info("Set course AI budget",
+		zap.Int("course_id", req.CourseID),
+		zap.Float64p("daily_limit", req.DailyLimit),
+		zap.Float64p("monthly_limit", req.MonthlyLimit),
+	)
+
+	return courseBudgetStatus(ctx, uc.aiUsageRepo, uc.budgetCfg, req.CourseID)
+}
+
+func (uc *aiUsageUseCase) authorize(ctx context.Context, courseID int) error {
+	principal, err := principalFromContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	course, err := uc.courseRepo.GetByID(ctx, courseID)
+	if err != nil {
+		return fmt.Errorf("failed to get course: %w", err)
+	}
+	if course == nil {
+		return ErrCourseNotFound
+	}
+
+	return authorizeCourse(principal, course)
+}
+
+// courseBudgetStatus compares the course's spend with its limits, or the
+// configured ones where the course has none.
+func courseBudgetStatus(ctx context.Context, aiUsageRepo repository.AIUsageRepository, cfg config.AIBudgetConfig, courseID int) (*AIBudgetStatus, error) {
+	status := &AIBudgetStatus{
+		DailyLimit:   cfg.CourseDailyLimit,
+		MonthlyLimit: cfg.CourseMonthlyLimit,
+	}
+
+	budget, err := aiUsageRepo.GetBudget(ctx, courseID)
+	if err != nil {
+		return nil, err
+	}
+	if budget != nil {
+		if budget.DailyLimit != nil {
+			status.DailyLimit = *budget.DailyLimit
+		}
+		if budget.MonthlyLimit != nil {
+			status.MonthlyLimit = *budget.MonthlyLimit
+		}
+	}
+
+	spend, err := aiUsageRepo.GetCourseSpend(ctx, courseID)
+	if err != nil {
+		return nil, err
+	}
+	status.SpentToday = spend.Today
+	status.SpentThisMonth = spend.ThisMonth
+
+	// The periods end by the database clock the spend is counted with,
+	// whatever the time zone of this process.
+	switch {
+	case status.MonthlyLimit > 0 && spend.ThisMonth >= status.MonthlyLimit:
+		status.Paused = true
+		status.ResumesAt = &spend.NextMonth
+	case status.DailyLimit > 0 && spend.Today >= status.DailyLimit:
+		status.Paused = true
+		status.ResumesAt = &spend.NextDay
+	}
+
+	return status, nil
+}
+
+// budgetExceededError stops a review whose course has used up its AI
+// budget; the job waits in the queue instead of failing.
+type budgetExceededError struct {
+	courseID  int
+	resumesAt time.Time
+}
+
+func (e *budgetExceededError) Error() string {
+	return fmt.Sprintf("AI budget of course %d is used up until %s", e.courseID, e.resumesAt.Format(time.RFC3339))
+}
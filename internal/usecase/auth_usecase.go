@@ -0,0 +1,94 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/ilyin-ad/flutter-code-mentor/internal/domain"
+	"github.com/ilyin-ad/flutter-code-mentor/internal/repository"
+	"github.com/ilyin-ad/flutter-code-mentor/internal/service"
+	"golang.org/x/crypto/bcrypt"
+)
+
+var (
+	ErrInvalidCredentials = errors.New("invalid email or password")
+	ErrInvalidToken       = errors.New("invalid or expired token")
+)
+
+type AuthUseCase interface {
+	Login(ctx context.Context, email, password string) (*AuthResponse, error)
+	Refresh(ctx context.Context, refreshToken string) (*AuthResponse, error)
+}
+
+type authUseCase struct {
+	userRepo     repository.UserRepository
+	tokenService service.TokenService
+}
+
+func NewAuthUseCase(userRepo repository.UserRepository, tokenService service.TokenService) AuthUseCase {
+	return &authUseCase{
+		userRepo:     userRepo,
+		tokenService: tokenService,
+	}
+}
+
+type AuthResponse struct {
+	UserID       int
+	Role         string
+	AccessToken  string
+	RefreshToken string
+	ExpiresIn    int
+}
+
+func (uc *authUseCase) Login(ctx context.Context, email, password string) (*AuthResponse, error) {
+	user, err := uc.userRepo.GetByEmail(ctx, email)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+	if user == nil {
+		return nil, ErrInvalidCredentials
+	}
+
+	if err := bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(password)); err != nil {
+		return nil, ErrInvalidCredentials
+	}
+
+	if err := uc.userRepo.UpdateLastLogin(ctx, user.ID); err != nil {
+		return nil, err
+	}
+
+	return uc.issue(user)
+}
+
+func (uc *authUseCase) Refresh(ctx context.Context, refreshToken string) (*AuthResponse, error) {
+	userID, err := uc.tokenService.ParseRefreshToken(refreshToken)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidToken, err)
+	}
+
+	user, err := uc.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+	if user == nil {
+		return nil, ErrInvalidToken
+	}
+
+	return uc.issue(user)
+}
+
+func (uc *authUseCase) issue(user *domain.User) (*AuthResponse, error) {
+	tokens, err := uc.tokenService.IssueTokens(user)
+	if err != nil {
+		return nil, err
+	}
+
+	return &AuthResponse{
+		UserID:       user.ID,
+		Role:         user.Role,
+		AccessToken:  tokens.AccessToken,
+		RefreshToken: tokens.RefreshToken,
+		ExpiresIn:    tokens.ExpiresIn,
+	}, nil
+}
@@ -11,6 +11,7 @@ import (
 
 type CourseUseCase interface {
 	CreateCourse(ctx context.Context, req *CreateCourseRequest) (*CreateCourseResponse, error)
+	SetLanguage(ctx context.Context, courseID int, language *string) (*domain.Course, error)
 }
 
 type courseUseCase struct {
@@ -35,6 +36,7 @@ type CreateCourseRequest struct {
 	StartDate   time.Time
 	EndDate     *time.Time
 	IsActive    bool
+	Language    *string
 }
 
 type CreateCourseResponse struct {
@@ -45,6 +47,7 @@ type CreateCourseResponse struct {
 	StartDate   time.Time
 	EndDate     *time.Time
 	IsActive    bool
+	Language    *domain.Language
 	CreatedAt   time.Time
 }
 
@@ -53,6 +56,17 @@ func (uc *courseUseCase) CreateCourse(ctx context.Context, req *CreateCourseRequ
 		return nil, err
 	}
 
+	principal, err := principalFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if principal.HasRole(domain.RoleTeacher) {
+		req.TeacherID = principal.UserID
+	} else if !principal.HasRole(domain.RoleAdmin) {
+		return nil, ErrUnauthorized
+	}
+
 	teacher, err := uc.userRepo.GetByID(ctx, req.TeacherID)
 	if err != nil {
 		return nil, fmt.Errorf("%w: %v", ErrUserNotFound, err)
@@ -61,10 +75,18 @@ func (uc *courseUseCase) CreateCourse(ctx context.Context, req *CreateCourseRequ
 		return nil, ErrUserNotFound
 	}
 
-	if teacher.Role != "teacher" {
-		return nil, ErrUnauthorized
+	if teacher.Role != domain.RoleTeacher {
+		return nil, &ValidationError{
+			Message: "Validation failed",
+			Details: []ValidationErrorDetail{{
+				Field:   "teacher_id",
+				Message: "Must reference a user with the teacher role",
+			}},
+		}
 	}
 
+	language, _ := parseLanguage(req.Language)
+
 	course := &domain.Course{
 		TeacherID:   req.TeacherID,
 		Title:       req.Title,
@@ -72,6 +94,7 @@ func (uc *courseUseCase) CreateCourse(ctx context.Context, req *CreateCourseRequ
 		StartDate:   req.StartDate,
 		EndDate:     req.EndDate,
 		IsActive:    req.IsActive,
+		Language:    language,
 	}
 
 	courseID, err := uc.courseRepo.Create(ctx, course)
@@ -87,17 +110,54 @@ func (uc *courseUseCase) CreateCourse(ctx context.Context, req *CreateCourseRequ
 		StartDate:   req.StartDate,
 		EndDate:     req.EndDate,
 		IsActive:    req.IsActive,
+		Language:    language,
 		CreatedAt:   course.CreatedAt,
 	}, nil
 }
 
+// SetLanguage changes the feedback language of the course. Students who
+// chose their own language keep it.
+func (uc *courseUseCase) SetLanguage(ctx context.Context, courseID int, language *string) (*domain.Course, error) {
+	course, err := uc.courseRepo.GetByID(ctx, courseID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get course: %w", err)
+	}
+	if course == nil {
+		return nil, ErrCourseNotFound
+	}
+
+	principal, err := principalFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err := authorizeCourse(principal, course); err != nil {
+		return nil, err
+	}
+
+	parsed, detail := parseLanguage(language)
+	if detail != nil {
+		return nil, &ValidationError{
+			Message: "Validation failed",
+			Details: []ValidationErrorDetail{*detail},
+		}
+	}
+
+	if err := uc.courseRepo.SetLanguage(ctx, courseID, parsed); err != nil {
+		return nil, err
+	}
+
+	course.Language = parsed
+	return course, nil
+}
+
 func (uc *courseUseCase) validateCourseRequest(req *CreateCourseRequest) error {
 	var details []ValidationErrorDetail
 
 	if len(req.Title) < 3 || len(req.Title) > 100 {
 		details = append(details, ValidationErrorDetail{
 			Field:   "title",
-			Message: "Must be between 3 and 100 characters",
+			Message: "Must be between %d and %d characters",
+			Args:    []any{3, 100},
 		})
 	}
 
@@ -108,6 +168,10 @@ func (uc *courseUseCase) validateCourseRequest(req *CreateCourseRequest) error {
 		})
 	}
 
+	if _, detail := parseLanguage(req.Language); detail != nil {
+		details = append(details, *detail)
+	}
+
 	if len(details) > 0 {
 		return &ValidationError{
 			Message: "Validation failed",
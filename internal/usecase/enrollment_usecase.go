@@ -0,0 +1,247 @@
+package usecase
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/ilyin-ad/flutter-code-mentor/internal/domain"
+	"github.com/ilyin-ad/flutter-code-mentor/internal/repository"
+)
+
+var (
+	ErrEnrollmentNotFound      = errors.New("enrollment not found")
+	ErrNotEnrolled             = errors.New("student is not enrolled in the course")
+	ErrInvalidInviteCode       = errors.New("invalid invite code")
+	ErrInvalidStatusTransition = errors.New("invalid status transition")
+)
+
+// enrollmentTransitions lists the completion statuses reachable from each
+// status. completed and failed are final.
+var enrollmentTransitions = map[domain.EnrollmentStatus][]domain.EnrollmentStatus{
+	domain.EnrollmentActive:  {domain.EnrollmentCompleted, domain.EnrollmentDropped, domain.EnrollmentFailed},
+	domain.EnrollmentDropped: {domain.EnrollmentActive},
+}
+
+type EnrollmentUseCase interface {
+	EnrollStudent(ctx context.Context, courseID, studentID int) (*domain.CourseEnrollment, error)
+	UnenrollStudent(ctx context.Context, courseID, studentID int) error
+	ListRoster(ctx context.Context, courseID int) ([]*domain.RosterEntry, error)
+	UpdateStatus(ctx context.Context, req *UpdateEnrollmentRequest) (*domain.CourseEnrollment, error)
+	RotateInviteCode(ctx context.Context, courseID int) (string, error)
+	JoinByInviteCode(ctx context.Context, inviteCode string) (*domain.CourseEnrollment, error)
+}
+
+type enrollmentUseCase struct {
+	enrollmentRepo repository.EnrollmentRepository
+	courseRepo     repository.CourseRepository
+	userRepo       repository.UserRepository
+}
+
+func NewEnrollmentUseCase(
+	enrollmentRepo repository.EnrollmentRepository,
+	courseRepo repository.CourseRepository,
+	userRepo repository.UserRepository,
+) EnrollmentUseCase {
+	return &enrollmentUseCase{
+		enrollmentRepo: enrollmentRepo,
+		courseRepo:     courseRepo,
+		userRepo:       userRepo,
+	}
+}
+
+type UpdateEnrollmentRequest struct {
+	CourseID         int
+	StudentID        int
+	CompletionStatus string
+	FinalScore       *float64
+}
+
+func (uc *enrollmentUseCase) EnrollStudent(ctx context.Context, courseID, studentID int) (*domain.CourseEnrollment, error) {
+	if _, err := uc.authorizedCourse(ctx, courseID); err != nil {
+		return nil, err
+	}
+
+	student, err := uc.userRepo.GetByID(ctx, studentID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrUserNotFound, err)
+	}
+	if student == nil {
+		return nil, ErrUserNotFound
+	}
+
+	if student.Role != domain.RoleStudent {
+		return nil, &ValidationError{
+			Message: "Validation failed",
+			Details: []ValidationErrorDetail{{
+				Field:   "student_id",
+				Message: "Must reference a user with the student role",
+			}},
+		}
+	}
+
+	return uc.enrollmentRepo.Enroll(ctx, courseID, studentID)
+}
+
+func (uc *enrollmentUseCase) UnenrollStudent(ctx context.Context, courseID, studentID int) error {
+	if _, err := uc.authorizedCourse(ctx, courseID); err != nil {
+		return err
+	}
+
+	deleted, err := uc.enrollmentRepo.Delete(ctx, courseID, studentID)
+	if err != nil {
+		return err
+	}
+	if !deleted {
+		return ErrEnrollmentNotFound
+	}
+
+	return nil
+}
+
+func (uc *enrollmentUseCase) ListRoster(ctx context.Context, courseID int) ([]*domain.RosterEntry, error) {
+	if _, err := uc.authorizedCourse(ctx, courseID); err != nil {
+		return nil, err
+	}
+
+	return uc.enrollmentRepo.ListByCourse(ctx, courseID)
+}
+
+func (uc *enrollmentUseCase) UpdateStatus(ctx context.Context, req *UpdateEnrollmentRequest) (*domain.CourseEnrollment, error) {
+	if err := validateEnrollmentUpdate(req); err != nil {
+		return nil, err
+	}
+
+	if _, err := uc.authorizedCourse(ctx, req.CourseID); err != nil {
+		return nil, err
+	}
+
+	enrollment, err := uc.enrollmentRepo.Get(ctx, req.CourseID, req.StudentID)
+	if err != nil {
+		return nil, err
+	}
+	if enrollment == nil {
+		return nil, ErrEnrollmentNotFound
+	}
+
+	status := domain.EnrollmentStatus(req.CompletionStatus)
+	if status != enrollment.CompletionStatus && !canTransitionEnrollment(enrollment.CompletionStatus, status) {
+		return nil, fmt.Errorf("%w: %s -> %s", ErrInvalidStatusTransition, enrollment.CompletionStatus, status)
+	}
+
+	if err := uc.enrollmentRepo.UpdateStatus(ctx, req.CourseID, req.StudentID, status, req.FinalScore); err != nil {
+		return nil, err
+	}
+
+	enrollment.CompletionStatus = status
+	if req.FinalScore != nil {
+		enrollment.FinalScore = req.FinalScore
+	}
+
+	return enrollment, nil
+}
+
+func (uc *enrollmentUseCase) RotateInviteCode(ctx context.Context, courseID int) (string, error) {
+	if _, err := uc.authorizedCourse(ctx, courseID); err != nil {
+		return "", err
+	}
+
+	buf := make([]byte, 5)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("failed to generate invite code: %w", err)
+	}
+	inviteCode := strings.ToUpper(hex.EncodeToString(buf))
+
+	if err := uc.courseRepo.SetInviteCode(ctx, courseID, inviteCode); err != nil {
+		return "", err
+	}
+
+	return inviteCode, nil
+}
+
+func (uc *enrollmentUseCase) JoinByInviteCode(ctx context.Context, inviteCode string) (*domain.CourseEnrollment, error) {
+	principal, err := principalFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if !principal.HasRole(domain.RoleStudent) {
+		return nil, ErrUnauthorized
+	}
+
+	inviteCode = strings.ToUpper(strings.TrimSpace(inviteCode))
+	if inviteCode == "" {
+		return nil, ErrInvalidInviteCode
+	}
+
+	course, err := uc.courseRepo.GetByInviteCode(ctx, inviteCode)
+	if err != nil {
+		return nil, err
+	}
+	if course == nil || !course.IsActive {
+		return nil, ErrInvalidInviteCode
+	}
+
+	return uc.enrollmentRepo.Enroll(ctx, course.ID, principal.UserID)
+}
+
+func (uc *enrollmentUseCase) authorizedCourse(ctx context.Context, courseID int) (*domain.Course, error) {
+	course, err := uc.courseRepo.GetByID(ctx, courseID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrCourseNotFound, err)
+	}
+	if course == nil {
+		return nil, ErrCourseNotFound
+	}
+
+	principal, err := principalFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err := authorizeCourse(principal, course); err != nil {
+		return nil, err
+	}
+
+	return course, nil
+}
+
+func canTransitionEnrollment(from, to domain.EnrollmentStatus) bool {
+	for _, allowed := range enrollmentTransitions[from] {
+		if allowed == to {
+			return true
+		}
+	}
+	return false
+}
+
+func validateEnrollmentUpdate(req *UpdateEnrollmentRequest) error {
+	var details []ValidationErrorDetail
+
+	switch domain.EnrollmentStatus(req.CompletionStatus) {
+	case domain.EnrollmentActive, domain.EnrollmentCompleted, domain.EnrollmentDropped, domain.EnrollmentFailed:
+	default:
+		details = append(details, ValidationErrorDetail{
+			Field:   "completion_status",
+			Message: "Must be one of 'active', 'completed', 'dropped', 'failed'",
+		})
+	}
+
+	if req.FinalScore != nil && (*req.FinalScore < 0 || *req.FinalScore > 100) {
+		details = append(details, ValidationErrorDetail{
+			Field:   "final_score",
+			Message: "Must be between %d and %d",
+			Args:    []any{0, 100},
+		})
+	}
+
+	if len(details) > 0 {
+		return &ValidationError{
+			Message: "Validation failed",
+			Details: details,
+		}
+	}
+
+	return nil
+}
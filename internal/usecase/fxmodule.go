@@ -13,6 +13,11 @@ func FxModule() fx.Option {
 			NewUserUseCase,
 			NewCourseUseCase,
 			NewReviewUseCase,
+			NewAuthUseCase,
+			NewEnrollmentUseCase,
+			NewTeacherReviewUseCase,
+			NewPromptTemplateUseCase,
+			NewAIUsageUseCase,
 		),
 	)
 }
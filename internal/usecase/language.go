@@ -0,0 +1,21 @@
+package usecase
+
+import (
+	"github.com/ilyin-ad/flutter-code-mentor/internal/domain"
+)
+
+// parseLanguage checks an optional language setting; nil or empty clears it.
+func parseLanguage(language *string) (*domain.Language, *ValidationErrorDetail) {
+	if language == nil || *language == "" {
+		return nil, nil
+	}
+
+	parsed := domain.Language(*language)
+	if !parsed.Valid() {
+		return nil, &ValidationErrorDetail{
+			Field:   "language",
+			Message: "Must be one of 'en', 'ru'",
+		}
+	}
+	return &parsed, nil
+}
@@ -0,0 +1,229 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/ilyin-ad/flutter-code-mentor/internal/domain"
+	"github.com/ilyin-ad/flutter-code-mentor/internal/repository"
+	"github.com/ilyin-ad/flutter-code-mentor/internal/service"
+	"go.uber.org/zap"
+)
+
+var ErrPromptTemplateNotFound = errors.New("prompt template not found")
+
+const maxPromptTemplateLength = 20000
+
+// templateErrorMessage is returned for a template that does not parse or
+// render. The parser's own error is logged, as it is only in English.
+const templateErrorMessage = "Must be a valid template that includes the code with {{.Code}}"
+
+type PromptTemplateUseCase interface {
+	GetTemplate(ctx context.Context, scope domain.PromptScope, scopeID int) (*domain.PromptTemplate, error)
+	ListVersions(ctx context.Context, scope domain.PromptScope, scopeID int) ([]*domain.PromptTemplate, error)
+	SaveTemplate(ctx context.Context, req *SavePromptTemplateRequest) (*domain.PromptTemplate, error)
+	DeleteTemplate(ctx context.Context, scope domain.PromptScope, scopeID int) error
+}
+
+type promptTemplateUseCase struct {
+	promptTemplateRepo repository.PromptTemplateRepository
+	taskRepo           repository.TaskRepository
+	courseRepo         repository.CourseRepository
+	logger             *zap.Logger
+}
+
+func NewPromptTemplateUseCase(
+	promptTemplateRepo repository.PromptTemplateRepository,
+	taskRepo repository.TaskRepository,
+	courseRepo repository.CourseRepository,
+	logger *zap.Logger,
+) PromptTemplateUseCase {
+	return &promptTemplateUseCase{
+		promptTemplateRepo: promptTemplateRepo,
+		taskRepo:           taskRepo,
+		courseRepo:         courseRepo,
+		logger:             logger,
+	}
+}
+
+type SavePromptTemplateRequest struct {
+	Scope        domain.PromptScope
+	ScopeID      int
+	SystemPrompt *string
+	UserPrompt   string
+}
+
+func (uc *promptTemplateUseCase) GetTemplate(ctx context.Context, scope domain.PromptScope, scopeID int) (*domain.PromptTemplate, error) {
+	if err := uc.authorizeScope(ctx, scope, scopeID); err != nil {
+		return nil, err
+	}
+
+	tmpl, err := uc.promptTemplateRepo.GetActive(ctx, scope, scopeID)
+	if err != nil {
+		return nil, err
+	}
+	if tmpl == nil {
+		return nil, ErrPromptTemplateNotFound
+	}
+
+	return tmpl, nil
+}
+
+func (uc *promptTemplateUseCase) ListVersions(ctx context.Context, scope domain.PromptScope, scopeID int) ([]*domain.PromptTemplate, error) {
+	if err := uc.authorizeScope(ctx, scope, scopeID); err != nil {
+		return nil, err
+	}
+
+	return uc.promptTemplateRepo.ListVersions(ctx, scope, scopeID)
+}
+
+// SaveTemplate stores the template as a new active version. Earlier
+// versions stay available for the reviews made with them.
+func (uc *promptTemplateUseCase) SaveTemplate(ctx context.Context, req *SavePromptTemplateRequest) (*domain.PromptTemplate, error) {
+	if err := uc.authorizeScope(ctx, req.Scope, req.ScopeID); err != nil {
+		return nil, err
+	}
+
+	systemPrompt, err := validatePromptTemplate(req.SystemPrompt, req.UserPrompt, uc.logger)
+	if err != nil {
+		return nil, err
+	}
+
+	principal, err := principalFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	tmpl := &domain.PromptTemplate{
+		SystemPrompt: systemPrompt,
+		UserPrompt:   req.UserPrompt,
+		CreatedBy:    &principal.UserID,
+	}
+	if req.Scope == domain.PromptScopeTask {
+		tmpl.TaskID = &req.ScopeID
+	} else {
+		tmpl.CourseID = &req.ScopeID
+	}
+
+	if err := uc.promptTemplateRepo.CreateVersion(ctx, tmpl); err != nil {
+		return nil, err
+	}
+
+	uc.logger.Info("Saved prompt template",
+		zap.String("scope", string(req.Scope)),
+		zap.Int("scope_id", req.ScopeID),
+		zap.Int("template_id", tmpl.ID),
+		zap.Int("version", tmpl.Version),
+	)
+
+	return tmpl, nil
+}
+
+// DeleteTemplate makes the scope fall back to the course template or the
+// built-in prompt.
+func (uc *promptTemplateUseCase) DeleteTemplate(ctx context.Context, scope domain.PromptScope, scopeID int) error {
+	if err := uc.authorizeScope(ctx, scope, scopeID); err != nil {
+		return err
+	}
+
+	deactivated, err := uc.promptTemplateRepo.Deactivate(ctx, scope, scopeID)
+	if err != nil {
+		return err
+	}
+	if !deactivated {
+		return ErrPromptTemplateNotFound
+	}
+
+	return nil
+}
+
+func (uc *promptTemplateUseCase) authorizeScope(ctx context.Context, scope domain.PromptScope, scopeID int) error {
+	principal, err := principalFromContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	if scope == domain.PromptScopeTask {
+		task, err := uc.taskRepo.GetByID(ctx, scopeID)
+		if err != nil {
+			return fmt.Errorf("failed to get task: %w", err)
+		}
+		if task == nil {
+			return ErrTaskNotFound
+		}
+		return authorizeTask(ctx, uc.courseRepo, principal, task)
+	}
+
+	course, err := uc.courseRepo.GetByID(ctx, scopeID)
+	if err != nil {
+		return fmt.Errorf("failed to get course: %w", err)
+	}
+	if course == nil {
+		return ErrCourseNotFound
+	}
+	return authorizeCourse(principal, course)
+}
+
+// validatePromptTemplate checks a template before it is saved or previewed
+// and returns the system prompt to store, nil when it is left empty.
+func validatePromptTemplate(systemPrompt *string, userPrompt string, logger *zap.Logger) (*string, error) {
+	var details []ValidationErrorDetail
+
+	var system *string
+	if systemPrompt != nil {
+		if trimmed := strings.TrimSpace(*systemPrompt); trimmed != "" {
+			system = &trimmed
+		}
+	}
+	if system != nil && utf8.RuneCountInString(*system) > maxPromptTemplateLength {
+		details = append(details, ValidationErrorDetail{
+			Field:   "system_prompt",
+			Message: "Must be at most %d characters",
+			Args:    []any{maxPromptTemplateLength},
+		})
+	}
+
+	switch {
+	case strings.TrimSpace(userPrompt) == "":
+		details = append(details, ValidationErrorDetail{
+			Field:   "user_prompt",
+			Message: "Required",
+		})
+	case utf8.RuneCountInString(userPrompt) > maxPromptTemplateLength:
+		details = append(details, ValidationErrorDetail{
+			Field:   "user_prompt",
+			Message: "Must be at most %d characters",
+			Args:    []any{maxPromptTemplateLength},
+		})
+	default:
+		if _, err := service.ParsePromptTemplate("", userPrompt); err != nil {
+			logger.Info("Rejected prompt template", zap.Error(err))
+			details = append(details, ValidationErrorDetail{
+				Field:   "user_prompt",
+				Message: templateErrorMessage,
+			})
+		}
+	}
+
+	if len(details) > 0 {
+		return nil, &ValidationError{
+			Message: "Validation failed",
+			Details: details,
+		}
+	}
+
+	return system, nil
+}
+
+// effectivePromptTemplate returns the template reviews of the task use: the
+// task's own, else the course's, nil for the built-in prompt.
+func effectivePromptTemplate(ctx context.Context, promptTemplateRepo repository.PromptTemplateRepository, task *domain.Task) (*domain.PromptTemplate, error) {
+	tmpl, err := promptTemplateRepo.GetActive(ctx, domain.PromptScopeTask, task.ID)
+	if err != nil || tmpl != nil {
+		return tmpl, err
+	}
+	return promptTemplateRepo.GetActive(ctx, domain.PromptScopeCourse, task.CourseID)
+}
@@ -0,0 +1,32 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/ilyin-ad/flutter-code-mentor/internal/domain"
+	"github.com/ilyin-ad/flutter-code-mentor/internal/repository"
+)
+
+// codeSnapshot keys pasted code by an empty path, so code and project
+// submissions can be diffed the same way.
+func codeSnapshot(code string) map[string]string {
+	return map[string]string{"": code}
+}
+
+// submissionSnapshot returns the reviewed files of a submission. Project
+// submissions only have a snapshot once their review has run.
+func submissionSnapshot(ctx context.Context, submissionRepo repository.SubmissionRepository, submission *domain.Submission) (map[string]string, error) {
+	if submission.SubmissionType == domain.SubmissionTypeCode && !submission.MultiFile {
+		if submission.Code == nil {
+			return nil, nil
+		}
+		return codeSnapshot(*submission.Code), nil
+	}
+
+	files, err := submissionRepo.GetFiles(ctx, submission.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get submission files: %w", err)
+	}
+	return files, nil
+}
@@ -2,81 +2,510 @@ package usecase
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"os"
 	"path/filepath"
-	"regexp"
-	"sync"
+	"sort"
+	"time"
 
+	"github.com/ilyin-ad/flutter-code-mentor/internal/config"
 	"github.com/ilyin-ad/flutter-code-mentor/internal/domain"
 	"github.com/ilyin-ad/flutter-code-mentor/internal/repository"
 	"github.com/ilyin-ad/flutter-code-mentor/internal/service"
 	"go.uber.org/zap"
 )
 
-var githubURLPattern = regexp.MustCompile(`^https?://github\.com/[\w-]+/[\w.-]+(?:\.git)?$`)
+// ReviewDispatcher hands a submission to the background review workers.
+type ReviewDispatcher interface {
+	Dispatch(submissionID int)
+}
 
 type ReviewUseCase interface {
-	ProcessPendingSubmissions(ctx context.Context) error
+	SweepReviewJobs(ctx context.Context) ([]int, error)
+	ProcessSubmission(ctx context.Context, submissionID int) error
+	ListFailedJobs(ctx context.Context, limit, offset int) ([]*domain.ReviewJob, error)
+	GetSubmissionReview(ctx context.Context, submissionID int) (*SubmissionReview, error)
+	PreviewPrompt(ctx context.Context, submissionID int, draft *PromptTemplateDraft) (*PromptPreview, error)
+}
+
+type SubmissionReview struct {
+	SubmissionID int
+	ReviewJob    *domain.ReviewJob
+	Review       *domain.CodeReview
+	Files        []FileFeedback
+	Criteria     []*domain.CriterionReport
+}
+
+type FileFeedback struct {
+	FilePath  *string
+	Feedbacks []*domain.ReviewFeedback
+}
+
+// PromptTemplateDraft is an unsaved template to preview instead of the one
+// the task's reviews use.
+type PromptTemplateDraft struct {
+	SystemPrompt *string
+	UserPrompt   string
+}
+
+// PromptPreview is the prompt a review of the submission would send, one
+// entry per model call. Source is "builtin", "draft", or the scope of the
+// template that applies.
+type PromptPreview struct {
+	SubmissionID int
+	Source       string
+	Template     *domain.PromptTemplate
+	Parts        []service.RenderedPrompt
+}
+
+// reviewInput is what a submission is reviewed with.
+type reviewInput struct {
+	prompt         service.ReviewPrompt
+	files          map[string]string
+	lintIssues     []service.LintIssue
+	template       *domain.PromptTemplate
+	language       domain.Language
+	previousReview *domain.CodeReview
 }
 
 type reviewUseCase struct {
-	submissionRepo repository.SubmissionRepository
-	reviewRepo     repository.ReviewRepository
-	taskRepo       repository.TaskRepository
-	aiService      service.AIService
-	githubService  service.GitHubService
-	logger         *zap.Logger
+	submissionRepo     repository.SubmissionRepository
+	reviewRepo         repository.ReviewRepository
+	reviewJobRepo      repository.ReviewJobRepository
+	taskRepo           repository.TaskRepository
+	courseRepo         repository.CourseRepository
+	userRepo           repository.UserRepository
+	promptTemplateRepo repository.PromptTemplateRepository
+	reviewCacheRepo    repository.ReviewCacheRepository
+	aiUsageRepo        repository.AIUsageRepository
+	aiService          service.AIService
+	githubService      service.GitHubService
+	repoSources        service.RepositorySources
+	linter             service.DartLinter
+	queueCfg           config.ReviewQueueConfig
+	cacheCfg           config.ReviewCacheConfig
+	budgetCfg          config.AIBudgetConfig
+	scoringCfg         config.ScoringConfig
+	workerID           string
+	logger             *zap.Logger
 }
 
 func NewReviewUseCase(
+	cfg *config.Config,
 	submissionRepo repository.SubmissionRepository,
 	reviewRepo repository.ReviewRepository,
+	reviewJobRepo repository.ReviewJobRepository,
 	taskRepo repository.TaskRepository,
+	courseRepo repository.CourseRepository,
+	userRepo repository.UserRepository,
+	promptTemplateRepo repository.PromptTemplateRepository,
+	reviewCacheRepo repository.ReviewCacheRepository,
+	aiUsageRepo repository.AIUsageRepository,
 	aiService service.AIService,
 	githubService service.GitHubService,
+	repoSources service.RepositorySources,
+	linter service.DartLinter,
 	logger *zap.Logger,
 ) ReviewUseCase {
+	hostname, _ := os.Hostname()
+
 	return &reviewUseCase{
-		submissionRepo: submissionRepo,
-		reviewRepo:     reviewRepo,
-		taskRepo:       taskRepo,
-		aiService:      aiService,
-		githubService:  githubService,
-		logger:         logger,
+		submissionRepo:     submissionRepo,
+		reviewRepo:         reviewRepo,
+		reviewJobRepo:      reviewJobRepo,
+		taskRepo:           taskRepo,
+		courseRepo:         courseRepo,
+		userRepo:           userRepo,
+		promptTemplateRepo: promptTemplateRepo,
+		reviewCacheRepo:    reviewCacheRepo,
+		aiUsageRepo:        aiUsageRepo,
+		aiService:          aiService,
+		githubService:      githubService,
+		repoSources:        repoSources,
+		linter:             linter,
+		queueCfg:           cfg.ReviewQueue,
+		cacheCfg:           cfg.ReviewCache,
+		budgetCfg:          cfg.AIBudget,
+		scoringCfg:         cfg.Scoring,
+		workerID:           fmt.Sprintf("%s-%d", hostname, os.Getpid()),
+		logger:             logger,
 	}
 }
 
-func (uc *reviewUseCase) ProcessPendingSubmissions(ctx context.Context) error {
-	submissions, err := uc.submissionRepo.GetPendingSubmissions(ctx)
+// SweepReviewJobs enqueues pending submissions that have no job yet and
+// returns the submissions whose jobs are due, so they can be dispatched.
+func (uc *reviewUseCase) SweepReviewJobs(ctx context.Context) ([]int, error) {
+	enqueued, err := uc.reviewJobRepo.EnqueueMissing(ctx)
 	if err != nil {
-		return fmt.Errorf("failed to get pending submissions: %w", err)
+		return nil, fmt.Errorf("failed to enqueue pending submissions: %w", err)
+	}
+	if enqueued > 0 {
+		uc.logger.Info("Enqueued pending submissions without review job", zap.Int("count", enqueued))
 	}
 
-	uc.logger.Info("Processing pending submissions", zap.Int("count", len(submissions)))
+	expired, err := uc.reviewCacheRepo.DeleteExpired(ctx)
+	if err != nil {
+		uc.logger.Warn("Failed to delete expired cached reviews", zap.Error(err))
+	} else if expired > 0 {
+		uc.logger.Info("Deleted expired cached reviews", zap.Int("count", expired))
+	}
 
-	var wg sync.WaitGroup
-	semaphore := make(chan struct{}, 3)
+	submissionIDs, err := uc.reviewJobRepo.ListDue(ctx, uc.queueCfg.BatchSize)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list due review jobs: %w", err)
+	}
 
-	for _, submission := range submissions {
-		wg.Add(1)
-		go func(sub *domain.Submission) {
-			defer wg.Done()
-			semaphore <- struct{}{}
-			defer func() { <-semaphore }()
+	return submissionIDs, nil
+}
 
-			if err := uc.processSubmission(ctx, sub); err != nil {
-				uc.logger.Error("Failed to process submission",
-					zap.Int("submission_id", sub.ID),
-					zap.Error(err),
-				)
-			}
-		}(submission)
+func (uc *reviewUseCase) ProcessSubmission(ctx context.Context, submissionID int) error {
+	job, err := uc.reviewJobRepo.ClaimBySubmissionID(ctx, submissionID, uc.workerID, uc.queueCfg.LockTimeout)
+	if err != nil {
+		return fmt.Errorf("failed to claim review job: %w", err)
+	}
+	if job == nil {
+		uc.logger.Debug("Review job is not due or already claimed", zap.Int("submission_id", submissionID))
+		return nil
 	}
 
-	wg.Wait()
+	uc.logger.Info("Claimed review job",
+		zap.String("worker_id", uc.workerID),
+		zap.Int("job_id", job.ID),
+		zap.Int("claim_seq", job.ClaimSeq),
+		zap.Int("submission_id", submissionID),
+		zap.Int("attempt", job.Attempts),
+	)
+
+	uc.processJob(ctx, job)
 	return nil
 }
 
+func (uc *reviewUseCase) ListFailedJobs(ctx context.Context, limit, offset int) ([]*domain.ReviewJob, error) {
+	jobs, err := uc.reviewJobRepo.ListFailed(ctx, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list failed review jobs: %w", err)
+	}
+	return jobs, nil
+}
+
+func (uc *reviewUseCase) GetSubmissionReview(ctx context.Context, submissionID int) (*SubmissionReview, error) {
+	submission, err := uc.submissionRepo.GetByID(ctx, submissionID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get submission: %w", err)
+	}
+	if submission == nil {
+		return nil, ErrSubmissionNotFound
+	}
+
+	principal, err := principalFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err := authorizeSubmission(ctx, uc.taskRepo, uc.courseRepo, principal, submission); err != nil {
+		return nil, err
+	}
+
+	job, err := uc.reviewJobRepo.GetBySubmissionID(ctx, submissionID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get review job: %w", err)
+	}
+
+	result := &SubmissionReview{
+		SubmissionID: submissionID,
+		ReviewJob:    job,
+	}
+
+	review, err := uc.reviewRepo.GetCodeReviewBySubmissionID(ctx, submissionID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get code review: %w", err)
+	}
+	if review == nil {
+		return result, nil
+	}
+	result.Review = review
+
+	feedbacks, err := uc.reviewRepo.GetReviewFeedbackByReviewID(ctx, review.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get review feedback: %w", err)
+	}
+
+	result.Files = groupFeedbackByFile(feedbacks)
+
+	result.Criteria, err = uc.reviewRepo.GetCriterionReportsByReviewID(ctx, review.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get criterion results: %w", err)
+	}
+
+	return result, nil
+}
+
+// PreviewPrompt renders the prompt a review of the submission would send,
+// with the draft template if one is given. It is built from the stored
+// files of the submission, so nothing is cloned or extracted during the
+// request; a draft that fails to render on them is a validation error.
+func (uc *reviewUseCase) PreviewPrompt(ctx context.Context, submissionID int, draft *PromptTemplateDraft) (*PromptPreview, error) {
+	submission, err := uc.submissionRepo.GetByID(ctx, submissionID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get submission: %w", err)
+	}
+	if submission == nil {
+		return nil, ErrSubmissionNotFound
+	}
+
+	principal, err := principalFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err := authorizeSubmission(ctx, uc.taskRepo, uc.courseRepo, principal, submission); err != nil {
+		return nil, err
+	}
+
+	task, err := uc.taskRepo.GetByID(ctx, submission.TaskID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get task: %w", err)
+	}
+	if task == nil {
+		return nil, ErrTaskNotFound
+	}
+
+	criteria, err := uc.taskRepo.GetCriteriaByTaskID(ctx, submission.TaskID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get task criteria: %w", err)
+	}
+
+	preview := &PromptPreview{SubmissionID: submissionID, Source: "builtin"}
+
+	prompt, files, err := uc.storedSubmission(ctx, submission)
+	if err != nil {
+		return nil, err
+	}
+
+	if draft != nil {
+		systemPrompt, err := validatePromptTemplate(draft.SystemPrompt, draft.UserPrompt, uc.logger)
+		if err != nil {
+			return nil, err
+		}
+
+		input, err := uc.buildReviewInput(ctx, submission, task, criteria, nil, prompt, files)
+		if err != nil {
+			return nil, err
+		}
+		tmpl := &domain.PromptTemplate{SystemPrompt: systemPrompt, UserPrompt: draft.UserPrompt}
+		if err := uc.applyPromptTemplate(input, tmpl, task, criteria); err != nil {
+			uc.logger.Info("Draft prompt template failed to render",
+				zap.Int("submission_id", submissionID),
+				zap.Error(err),
+			)
+			return nil, &ValidationError{
+				Message: "Validation failed",
+				Details: []ValidationErrorDetail{{Field: "user_prompt", Message: templateErrorMessage}},
+			}
+		}
+
+		preview.Source = "draft"
+		preview.Parts = uc.aiService.RenderPrompt(input.prompt, task, criteria)
+		return preview, nil
+	}
+
+	tmpl, err := effectivePromptTemplate(ctx, uc.promptTemplateRepo, task)
+	if err != nil {
+		return nil, err
+	}
+
+	input, err := uc.buildReviewInput(ctx, submission, task, criteria, tmpl, prompt, files)
+	if err != nil {
+		return nil, err
+	}
+
+	if input.template != nil {
+		preview.Template = input.template
+		preview.Source = string(domain.PromptScopeCourse)
+		if input.template.TaskID != nil {
+			preview.Source = string(domain.PromptScopeTask)
+		}
+	}
+	preview.Parts = uc.aiService.RenderPrompt(input.prompt, task, criteria)
+
+	return preview, nil
+}
+
+// groupFeedbackByFile keeps the repository order (severity first) inside each
+// file and lists files by path, with file-less feedback first.
+func groupFeedbackByFile(feedbacks []*domain.ReviewFeedback) []FileFeedback {
+	byPath := make(map[string]*FileFeedback)
+	var paths []string
+
+	for _, fb := range feedbacks {
+		key := ""
+		if fb.FilePath != nil {
+			key = *fb.FilePath
+		}
+
+		group, ok := byPath[key]
+		if !ok {
+			group = &FileFeedback{FilePath: fb.FilePath}
+			byPath[key] = group
+			paths = append(paths, key)
+		}
+		group.Feedbacks = append(group.Feedbacks, fb)
+	}
+
+	sort.Strings(paths)
+
+	files := make([]FileFeedback, 0, len(paths))
+	for _, path := range paths {
+		files = append(files, *byPath[path])
+	}
+	return files
+}
+
+func (uc *reviewUseCase) processJob(ctx context.Context, job *domain.ReviewJob) {
+	err := uc.runJobWithinLease(ctx, job)
+
+	// The job's state is recorded even if the worker is shutting down.
+	interrupted := err != nil && ctx.Err() != nil
+	ctx = context.WithoutCancel(ctx)
+
+	if interrupted {
+		// Put the job back without counting the attempt, so the next start
+		// picks it up right away.
+		uc.logger.Info("Review interrupted, returning job to the queue",
+			zap.Int("job_id", job.ID),
+			zap.Int("submission_id", job.SubmissionID),
+		)
+		if postponeErr := uc.reviewJobRepo.Postpone(ctx, job, "review interrupted by shutdown", time.Now()); postponeErr != nil {
+			uc.logJobUpdateError(job, "Failed to postpone review job", postponeErr)
+		}
+		return
+	}
+
+	if err == nil {
+		if err := uc.reviewJobRepo.MarkDone(ctx, job); err != nil {
+			uc.logJobUpdateError(job, "Failed to mark review job done", err)
+		}
+		return
+	}
+
+	var budgetErr *budgetExceededError
+	if errors.As(err, &budgetErr) {
+		// Check again at least every RetryMaxDelay, so a raised budget
+		// takes effect without waiting for the next day or month.
+		nextAttemptAt := time.Now().Add(uc.queueCfg.RetryMaxDelay)
+		if budgetErr.resumesAt.Before(nextAttemptAt) {
+			nextAttemptAt = budgetErr.resumesAt
+		}
+		uc.logger.Info("Course AI budget used up, postponing review",
+			zap.Int("job_id", job.ID),
+			zap.Int("submission_id", job.SubmissionID),
+			zap.Int("course_id", budgetErr.courseID),
+			zap.Time("next_attempt_at", nextAttemptAt),
+		)
+		if postponeErr := uc.reviewJobRepo.Postpone(ctx, job, err.Error(), nextAttemptAt); postponeErr != nil {
+			uc.logJobUpdateError(job, "Failed to postpone review job", postponeErr)
+		}
+		return
+	}
+
+	var unavailableErr *service.ProviderUnavailableError
+	if errors.As(err, &unavailableErr) {
+		uc.logger.Info("AI provider unavailable, postponing review",
+			zap.Int("job_id", job.ID),
+			zap.Int("submission_id", job.SubmissionID),
+			zap.Time("next_attempt_at", unavailableErr.Until),
+		)
+		if postponeErr := uc.reviewJobRepo.Postpone(ctx, job, err.Error(), unavailableErr.Until); postponeErr != nil {
+			uc.logJobUpdateError(job, "Failed to postpone review job", postponeErr)
+		}
+		return
+	}
+
+	if job.Attempts >= uc.queueCfg.MaxAttempts {
+		uc.logger.Error("Review job failed permanently",
+			zap.Int("job_id", job.ID),
+			zap.Int("submission_id", job.SubmissionID),
+			zap.Int("attempts", job.Attempts),
+			zap.Error(err),
+		)
+		if markErr := uc.reviewJobRepo.MarkFailed(ctx, job, err.Error()); markErr != nil {
+			uc.logJobUpdateError(job, "Failed to mark review job failed", markErr)
+		}
+		return
+	}
+
+	nextAttemptAt := time.Now().Add(uc.retryDelay(job.Attempts))
+	uc.logger.Warn("Review job failed, scheduling retry",
+		zap.Int("job_id", job.ID),
+		zap.Int("submission_id", job.SubmissionID),
+		zap.Int("attempts", job.Attempts),
+		zap.Time("next_attempt_at", nextAttemptAt),
+		zap.Error(err),
+	)
+	if retryErr := uc.reviewJobRepo.Retry(ctx, job, err.Error(), nextAttemptAt); retryErr != nil {
+		uc.logJobUpdateError(job, "Failed to reschedule review job", retryErr)
+	}
+}
+
+// logJobUpdateError reports a failed state change of a job; losing the lock
+// is expected when a review outlives its lease and is only a warning.
+func (uc *reviewUseCase) logJobUpdateError(job *domain.ReviewJob, msg string, err error) {
+	if errors.Is(err, repository.ErrReviewJobLockLost) {
+		uc.logger.Warn("Review job lock expired and the job was claimed again, leaving its state alone",
+			zap.String("worker_id", uc.workerID),
+			zap.Int("job_id", job.ID),
+			zap.Int("claim_seq", job.ClaimSeq),
+			zap.Int("submission_id", job.SubmissionID),
+		)
+		return
+	}
+
+	uc.logger.Error(msg,
+		zap.Int("job_id", job.ID),
+		zap.Error(err),
+	)
+}
+
+// runJobWithinLease stops the review before the job's lock expires: past
+// that, another worker may claim the job and review the submission again.
+// Retries of AI calls and the parts of a split project all share this
+// deadline, so a review that runs out of it fails and is retried later.
+func (uc *reviewUseCase) runJobWithinLease(ctx context.Context, job *domain.ReviewJob) error {
+	if uc.queueCfg.LockTimeout <= 0 {
+		return uc.runJob(ctx, job)
+	}
+
+	limit := uc.queueCfg.LockTimeout * 9 / 10
+	runCtx, cancel := context.WithTimeout(ctx, limit)
+	defer cancel()
+
+	err := uc.runJob(runCtx, job)
+	if err != nil && ctx.Err() == nil && errors.Is(runCtx.Err(), context.DeadlineExceeded) {
+		return fmt.Errorf("review did not finish within %s of the %s job lock: %w", limit, uc.queueCfg.LockTimeout, err)
+	}
+	return err
+}
+
+func (uc *reviewUseCase) runJob(ctx context.Context, job *domain.ReviewJob) error {
+	submission, err := uc.submissionRepo.GetByID(ctx, job.SubmissionID)
+	if err != nil {
+		return fmt.Errorf("failed to get submission: %w", err)
+	}
+	if submission == nil {
+		return fmt.Errorf("submission %d not found", job.SubmissionID)
+	}
+
+	return uc.processSubmission(ctx, submission)
+}
+
+func (uc *reviewUseCase) retryDelay(attempts int) time.Duration {
+	delay := uc.queueCfg.RetryBaseDelay
+	for i := 1; i < attempts && delay < uc.queueCfg.RetryMaxDelay; i++ {
+		delay *= 2
+	}
+	return min(delay, uc.queueCfg.RetryMaxDelay)
+}
+
 func (uc *reviewUseCase) processSubmission(ctx context.Context, submission *domain.Submission) error {
 	uc.logger.Info("Processing submission",
 		zap.Int("submission_id", submission.ID),
@@ -93,6 +522,11 @@ func (uc *reviewUseCase) processSubmission(ctx context.Context, submission *doma
 		return nil
 	}
 
+	if submission.Status == domain.StatusResubmitted {
+		uc.logger.Info("Skipping review of superseded submission", zap.Int("submission_id", submission.ID))
+		return nil
+	}
+
 	task, err := uc.taskRepo.GetByID(ctx, submission.TaskID)
 	if err != nil {
 		return fmt.Errorf("failed to get task: %w", err)
@@ -106,70 +540,431 @@ func (uc *reviewUseCase) processSubmission(ctx context.Context, submission *doma
 		return fmt.Errorf("failed to get task criteria: %w", err)
 	}
 
-	var result *service.CodeReviewResult
+	// Checked before the repository is cloned, so a paused course does not
+	// fetch projects it cannot review.
+	budget, err := courseBudgetStatus(ctx, uc.aiUsageRepo, uc.budgetCfg, task.CourseID)
+	if err != nil {
+		return err
+	}
+	if budget.Paused {
+		return &budgetExceededError{courseID: task.CourseID, resumesAt: *budget.ResumesAt}
+	}
+
+	tmpl, err := effectivePromptTemplate(ctx, uc.promptTemplateRepo, task)
+	if err != nil {
+		return err
+	}
+
+	input, err := uc.prepareReview(ctx, submission, task, criteria, tmpl)
+	if err != nil {
+		return err
+	}
+	files := input.files
+
+	ctx = service.WithModelCallRecorder(ctx, func(call service.ModelCall) {
+		uc.recordModelCall(ctx, submission.ID, call)
+	})
+
+	result, err := uc.review(ctx, submission, task, criteria, input)
+	if err != nil {
+		return err
+	}
+
+	var anchorNotes []string
+	result.Feedbacks, anchorNotes = service.AnchorFeedback(files, result.Feedbacks)
+	if len(anchorNotes) > 0 {
+		uc.logger.Info("AI feedback anchored to submission files",
+			zap.Int("submission_id", submission.ID),
+			zap.Strings("corrections", anchorNotes),
+		)
+	}
+
+	if submission.SubmissionType != domain.SubmissionTypeCode {
+		if err := uc.submissionRepo.SaveFiles(ctx, submission.ID, files); err != nil {
+			uc.logger.Warn("Failed to save submission files",
+				zap.Int("submission_id", submission.ID),
+				zap.Error(err),
+			)
+		}
+	}
+
+	if err := uc.saveReviewResult(ctx, submission.ID, task, criteria, result, input); err != nil {
+		return err
+	}
+
+	previousReview := input.previousReview
+	if previousReview == nil {
+		return nil
+	}
+
+	resolvedIDs := append(result.ResolvedFeedbackIDs, uc.fixedLintIssues(ctx, previousReview.ID, input.lintIssues)...)
+	if len(resolvedIDs) > 0 {
+		resolved, err := uc.reviewRepo.MarkFeedbackResolved(ctx, previousReview.ID, resolvedIDs)
+		if err != nil {
+			uc.logger.Error("Failed to mark previous feedback resolved",
+				zap.Int("submission_id", submission.ID),
+				zap.Int("previous_review_id", previousReview.ID),
+				zap.Error(err),
+			)
+		} else {
+			uc.logger.Info("Marked previous feedback resolved",
+				zap.Int("submission_id", submission.ID),
+				zap.Int("previous_review_id", previousReview.ID),
+				zap.Int("resolved_count", resolved),
+			)
+		}
+	}
+
+	return nil
+}
+
+// prepareReview fetches the files of a submission and builds its prompt.
+func (uc *reviewUseCase) prepareReview(ctx context.Context, submission *domain.Submission, task *domain.Task, criteria []*domain.TaskCriteria, tmpl *domain.PromptTemplate) (*reviewInput, error) {
+	var prompt service.ReviewPrompt
+	var files map[string]string
+	var err error
 
 	switch submission.SubmissionType {
 	case domain.SubmissionTypeCode:
-		result, err = uc.processCodeSubmission(ctx, submission, task, criteria)
+		prompt, files, err = uc.prepareCodeSubmission(ctx, submission)
 	case domain.SubmissionTypeGithubLink:
-		result, err = uc.processGitHubSubmission(ctx, submission, task, criteria)
+		prompt, files, err = uc.prepareGitHubSubmission(ctx, submission, task)
+	case domain.SubmissionTypeZipArchive:
+		prompt, files, err = uc.prepareArchiveSubmission(ctx, submission, task)
 	default:
-		return fmt.Errorf("unknown submission type: %s", submission.SubmissionType)
+		return nil, fmt.Errorf("unknown submission type: %s", submission.SubmissionType)
 	}
 
 	if err != nil {
+		return nil, err
+	}
+
+	return uc.buildReviewInput(ctx, submission, task, criteria, tmpl, prompt, files)
+}
+
+// buildReviewInput adds the lint findings, the previous attempt of a
+// resubmission and the feedback language to the prompt of the submission
+// files, and renders it with the prompt template if that works for this
+// submission.
+func (uc *reviewUseCase) buildReviewInput(ctx context.Context, submission *domain.Submission, task *domain.Task, criteria []*domain.TaskCriteria, tmpl *domain.PromptTemplate, prompt service.ReviewPrompt, files map[string]string) (*reviewInput, error) {
+	input := &reviewInput{files: files}
+
+	input.lintIssues = uc.linter.Lint(files)
+	if len(input.lintIssues) > 0 {
+		uc.logger.Info("Static analysis found issues",
+			zap.Int("submission_id", submission.ID),
+			zap.Int("issues_count", len(input.lintIssues)),
+		)
+		prompt = service.NewStaticAnalysisPrompt(prompt, input.lintIssues)
+	}
+
+	var err error
+	if submission.PreviousSubmissionID != nil {
+		prompt, input.previousReview, err = uc.withPreviousAttempt(ctx, submission, files, prompt)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	language, err := uc.feedbackLanguage(ctx, submission, task)
+	if err != nil {
+		return nil, err
+	}
+	if language != "" {
+		prompt = service.NewLanguagePrompt(prompt, language)
+	}
+
+	input.prompt = prompt
+	input.language = language
+
+	if tmpl != nil {
+		if err := uc.applyPromptTemplate(input, tmpl, task, criteria); err != nil {
+			// Templates are checked when saved, but a template can still
+			// fail on the data of one submission. The review then uses, and
+			// records, the built-in prompt.
+			uc.logger.Warn("Prompt template cannot be used, using the built-in prompt",
+				zap.Int("submission_id", submission.ID),
+				zap.Int("template_id", tmpl.ID),
+				zap.Error(err),
+			)
+		} else {
+			uc.logger.Info("Using prompt template",
+				zap.Int("submission_id", submission.ID),
+				zap.Int("template_id", tmpl.ID),
+				zap.Int("version", tmpl.Version),
+			)
+		}
+	}
+
+	return input, nil
+}
+
+// applyPromptTemplate makes input use tmpl if it parses and renders on every
+// part of the review. Otherwise input is left unchanged.
+func (uc *reviewUseCase) applyPromptTemplate(input *reviewInput, tmpl *domain.PromptTemplate, task *domain.Task, criteria []*domain.TaskCriteria) error {
+	systemPrompt := ""
+	if tmpl.SystemPrompt != nil {
+		systemPrompt = *tmpl.SystemPrompt
+	}
+	parsed, err := service.ParsePromptTemplate(systemPrompt, tmpl.UserPrompt)
+	if err != nil {
+		return err
+	}
+
+	prompt := service.WithPromptTemplate(input.prompt, parsed)
+	if err := uc.aiService.CheckPromptTemplate(prompt, task, criteria); err != nil {
 		return err
 	}
 
-	return uc.saveReviewResult(ctx, submission.ID, result)
+	input.prompt = prompt
+	input.template = tmpl
+	return nil
+}
+
+// storedSubmission builds the prompt of a submission from its stored files
+// instead of fetching the repository or extracting the archive again.
+// Project submissions only have stored files once they have been reviewed.
+func (uc *reviewUseCase) storedSubmission(ctx context.Context, submission *domain.Submission) (service.ReviewPrompt, map[string]string, error) {
+	files, err := submissionSnapshot(ctx, uc.submissionRepo, submission)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(files) == 0 {
+		return nil, nil, ErrSubmissionFilesNotStored
+	}
+
+	if submission.SubmissionType == domain.SubmissionTypeCode && !submission.MultiFile {
+		return service.NewCodePrompt(*submission.Code), files, nil
+	}
+	return service.NewProjectPrompt(files), files, nil
 }
 
-func (uc *reviewUseCase) processCodeSubmission(ctx context.Context, submission *domain.Submission, task *domain.Task, criteria []*domain.TaskCriteria) (*service.CodeReviewResult, error) {
+// review returns the AI review of the prepared input, reusing the cached
+// result of an identical submission when there is one. Resubmissions are
+// always sent to the model, since their review refers to the feedback of
+// the previous attempt.
+func (uc *reviewUseCase) review(ctx context.Context, submission *domain.Submission, task *domain.Task, criteria []*domain.TaskCriteria, input *reviewInput) (*service.CodeReviewResult, error) {
+	if !uc.cacheCfg.Enabled || input.previousReview != nil {
+		return uc.aiService.Review(ctx, input.prompt, task, criteria)
+	}
+
+	keyInput := service.ReviewCacheKeyInput{
+		Kind:       input.prompt.Kind(),
+		Files:      input.files,
+		LintIssues: input.lintIssues,
+		Task:       task,
+		Criteria:   criteria,
+		Language:   input.language,
+		Model:      uc.aiService.Model(),
+	}
+	if input.template != nil {
+		keyInput.TemplateID = input.template.ID
+		keyInput.TemplateVersion = input.template.Version
+	}
+	cacheKey := service.ReviewCacheKey(keyInput)
+
+	cached, err := uc.reviewCacheRepo.Get(ctx, cacheKey)
+	if err != nil {
+		uc.logger.Warn("Failed to look up cached review",
+			zap.Int("submission_id", submission.ID),
+			zap.Error(err),
+		)
+	} else if cached != nil {
+		var result service.CodeReviewResult
+		if err = json.Unmarshal(cached.Result, &result); err == nil {
+			uc.logger.Info("Reusing cached AI review",
+				zap.Int("submission_id", submission.ID),
+				zap.String("cache_key", cacheKey),
+				zap.Int("hit_count", cached.HitCount),
+			)
+			// The reused review cost nothing this time.
+			result.PromptTokens, result.CompletionTokens, result.EstimatedCost = 0, 0, 0
+			return &result, nil
+		}
+		uc.logger.Warn("Cached review is unreadable, reviewing again",
+			zap.Int("submission_id", submission.ID),
+			zap.String("cache_key", cacheKey),
+			zap.Error(err),
+		)
+	}
+
+	result, err := uc.aiService.Review(ctx, input.prompt, task, criteria)
+	if err != nil {
+		return nil, err
+	}
+
+	encoded, err := json.Marshal(result)
+	if err == nil {
+		err = uc.reviewCacheRepo.Put(ctx, &domain.ReviewCacheEntry{
+			CacheKey:  cacheKey,
+			TaskID:    task.ID,
+			AIModel:   result.AIModel,
+			Result:    encoded,
+			ExpiresAt: time.Now().Add(uc.cacheCfg.TTL),
+		})
+	}
+	if err != nil {
+		uc.logger.Warn("Failed to cache AI review",
+			zap.Int("submission_id", submission.ID),
+			zap.Error(err),
+		)
+	}
+
+	return result, nil
+}
+
+// feedbackLanguage returns the language the student chose, else the one of
+// the course, or "" to leave the prompt as it is.
+func (uc *reviewUseCase) feedbackLanguage(ctx context.Context, submission *domain.Submission, task *domain.Task) (domain.Language, error) {
+	student, err := uc.userRepo.GetByID(ctx, submission.StudentID)
+	if err != nil {
+		return "", fmt.Errorf("failed to get student: %w", err)
+	}
+	if student != nil && student.Language != nil {
+		return *student.Language, nil
+	}
+
+	course, err := uc.courseRepo.GetByID(ctx, task.CourseID)
+	if err != nil {
+		return "", fmt.Errorf("failed to get course: %w", err)
+	}
+	if course != nil && course.Language != nil {
+		return *course.Language, nil
+	}
+
+	return "", nil
+}
+
+// recordModelCall adds a model call to the usage ledger the course budget is
+// checked against. It is recorded even if the review is being interrupted,
+// since the call has been paid for.
+func (uc *reviewUseCase) recordModelCall(ctx context.Context, submissionID int, call service.ModelCall) {
+	err := uc.aiUsageRepo.RecordCall(context.WithoutCancel(ctx), &domain.AIModelCall{
+		SubmissionID:     submissionID,
+		AIModel:          call.Model,
+		PromptTokens:     call.Usage.PromptTokens,
+		CompletionTokens: call.Usage.CompletionTokens,
+		EstimatedCost:    call.EstimatedCost,
+	})
+	if err != nil {
+		uc.logger.Error("Failed to record AI model call",
+			zap.Int("submission_id", submissionID),
+			zap.Int("prompt_tokens", call.Usage.PromptTokens),
+			zap.Int("completion_tokens", call.Usage.CompletionTokens),
+			zap.Error(err),
+		)
+	}
+}
+
+func (uc *reviewUseCase) prepareCodeSubmission(ctx context.Context, submission *domain.Submission) (service.ReviewPrompt, map[string]string, error) {
+	if submission.MultiFile {
+		files, err := uc.submissionRepo.GetFiles(ctx, submission.ID)
+		if err != nil {
+			return nil, nil, err
+		}
+		if len(files) == 0 {
+			return nil, nil, fmt.Errorf("submission has no files to review")
+		}
+
+		uc.logger.Info("Reviewing multi-file code submission",
+			zap.Int("submission_id", submission.ID),
+			zap.Int("files_count", len(files)),
+		)
+		return service.NewProjectPrompt(files), files, nil
+	}
+
 	if submission.Code == nil || *submission.Code == "" {
-		return nil, fmt.Errorf("submission has no code to review")
+		return nil, nil, fmt.Errorf("submission has no code to review")
 	}
 
 	uc.logger.Info("Reviewing code submission", zap.Int("submission_id", submission.ID))
-	return uc.aiService.ReviewCode(ctx, submission.Code, task, criteria)
+	return service.NewCodePrompt(*submission.Code), codeSnapshot(*submission.Code), nil
 }
 
-func (uc *reviewUseCase) processGitHubSubmission(ctx context.Context, submission *domain.Submission, task *domain.Task, criteria []*domain.TaskCriteria) (*service.CodeReviewResult, error) {
+func (uc *reviewUseCase) prepareGitHubSubmission(ctx context.Context, submission *domain.Submission, task *domain.Task) (service.ReviewPrompt, map[string]string, error) {
 	if submission.GithubURL == nil || *submission.GithubURL == "" {
-		return nil, fmt.Errorf("submission has no GitHub URL to review")
+		return nil, nil, fmt.Errorf("submission has no repository URL to review")
 	}
 
-	if !githubURLPattern.MatchString(*submission.GithubURL) {
-		return nil, fmt.Errorf("invalid GitHub URL format: %s", *submission.GithubURL)
+	repo, err := uc.repoSources.Parse(*submission.GithubURL)
+	if err != nil {
+		return nil, nil, err
 	}
 
-	uc.logger.Info("Reviewing GitHub submission",
+	uc.logger.Info("Reviewing repository submission",
 		zap.Int("submission_id", submission.ID),
-		zap.String("github_url", *submission.GithubURL),
+		zap.String("provider", repo.Provider),
+		zap.String("repository_url", repo.URL),
+		zap.Stringp("commit_sha", submission.CommitSHA),
 	)
 
-	repoPath, err := uc.githubService.CloneRepository(ctx, *submission.GithubURL)
+	commitSHA := ""
+	if submission.CommitSHA != nil {
+		commitSHA = *submission.CommitSHA
+	}
+
+	repoPath, err := uc.githubService.CloneRepository(ctx, repo, commitSHA)
 	if err != nil {
-		return nil, fmt.Errorf("failed to clone repository: %w", err)
+		return nil, nil, fmt.Errorf("failed to clone repository: %w", err)
 	}
 	defer uc.githubService.Cleanup(repoPath)
 
-	dartFiles, err := uc.githubService.GetDartFiles(repoPath)
+	return uc.prepareProject(submission, task, repoPath)
+}
+
+func (uc *reviewUseCase) prepareArchiveSubmission(ctx context.Context, submission *domain.Submission, task *domain.Task) (service.ReviewPrompt, map[string]string, error) {
+	if submission.ArchiveSHA256 == nil {
+		return nil, nil, fmt.Errorf("submission has no archive to review")
+	}
+
+	content, err := uc.submissionRepo.GetArchive(ctx, *submission.ArchiveSHA256)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get Dart files: %w", err)
+		return nil, nil, err
+	}
+	if content == nil {
+		return nil, nil, fmt.Errorf("archive %s not found", *submission.ArchiveSHA256)
 	}
 
-	if len(dartFiles) == 0 {
-		return nil, fmt.Errorf("no Dart files found in repository")
+	name := ""
+	if submission.ArchiveName != nil {
+		name = *submission.ArchiveName
 	}
 
-	uc.logger.Info("Found Dart files in repository",
+	uc.logger.Info("Reviewing archive submission",
 		zap.Int("submission_id", submission.ID),
-		zap.Int("files_count", len(dartFiles)),
+		zap.String("archive_name", name),
+		zap.Int("archive_size", len(content)),
+	)
+
+	workspace, root, err := uc.githubService.ExtractArchive(content, name)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to extract archive: %w", err)
+	}
+	defer uc.githubService.Cleanup(workspace)
+
+	return uc.prepareProject(submission, task, root)
+}
+
+// prepareProject reads the files selected by the task rules from a cloned
+// or extracted project.
+func (uc *reviewUseCase) prepareProject(submission *domain.Submission, task *domain.Task, projectPath string) (service.ReviewPrompt, map[string]string, error) {
+	selectedFiles, err := uc.githubService.SelectFiles(projectPath, task.FileRules)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to select project files: %w", err)
+	}
+
+	if len(selectedFiles) == 0 {
+		return nil, nil, fmt.Errorf("no Dart files matching the task file rules found in the project")
+	}
+
+	uc.logger.Info("Selected files in project",
+		zap.Int("submission_id", submission.ID),
+		zap.Int("files_count", len(selectedFiles)),
 	)
 
 	files := make(map[string]string)
-	for _, relPath := range dartFiles {
-		fullPath := filepath.Join(repoPath, relPath)
+	for _, relPath := range selectedFiles {
+		fullPath := filepath.Join(projectPath, relPath)
 		content, err := uc.githubService.ReadFile(fullPath)
 		if err != nil {
 			uc.logger.Warn("Failed to read file",
@@ -182,40 +977,129 @@ func (uc *reviewUseCase) processGitHubSubmission(ctx context.Context, submission
 	}
 
 	if len(files) == 0 {
-		return nil, fmt.Errorf("failed to read any Dart files from repository")
+		return nil, nil, fmt.Errorf("failed to read any selected files from the project")
 	}
 
-	return uc.aiService.ReviewGitHubProject(ctx, files, task, criteria)
+	return service.NewProjectPrompt(files), files, nil
 }
 
-func (uc *reviewUseCase) saveReviewResult(ctx context.Context, submissionID int, result *service.CodeReviewResult) error {
+// withPreviousAttempt wraps the prompt with the diff against the previous
+// attempt and its unresolved feedback. It returns the previous review so
+// the caller can mark fixed issues.
+func (uc *reviewUseCase) withPreviousAttempt(ctx context.Context, submission *domain.Submission, files map[string]string, prompt service.ReviewPrompt) (service.ReviewPrompt, *domain.CodeReview, error) {
+	previous, err := uc.submissionRepo.GetByID(ctx, *submission.PreviousSubmissionID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get previous submission: %w", err)
+	}
+	if previous == nil {
+		return prompt, nil, nil
+	}
+
+	var diffs []service.FileDiff
+	previousFiles, err := submissionSnapshot(ctx, uc.submissionRepo, previous)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(previousFiles) > 0 {
+		diffs = service.DiffFiles(previousFiles, files)
+	}
+
+	previousReview, err := uc.reviewRepo.GetCodeReviewBySubmissionID(ctx, previous.ID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get previous review: %w", err)
+	}
+
+	var prior []*domain.ReviewFeedback
+	if previousReview != nil {
+		feedbacks, err := uc.reviewRepo.GetReviewFeedbackByReviewID(ctx, previousReview.ID)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to get previous review feedback: %w", err)
+		}
+		for _, fb := range feedbacks {
+			// Lint findings are checked again by the pre-pass, not by the model.
+			if fb.Origin == domain.FeedbackOriginStaticAnalysis {
+				continue
+			}
+			if !fb.IsResolved && (fb.TeacherApproved == nil || *fb.TeacherApproved) {
+				prior = append(prior, fb)
+			}
+		}
+	}
+
+	uc.logger.Info("Re-reviewing resubmission",
+		zap.Int("submission_id", submission.ID),
+		zap.Int("previous_submission_id", previous.ID),
+		zap.Int("prior_feedbacks_count", len(prior)),
+	)
+
+	return service.NewReReviewPrompt(prompt, diffs, prior), previousReview, nil
+}
+
+// fixedLintIssues returns the open lint findings of the previous review that
+// the new lint run no longer reports. Findings are matched by rule, file and
+// line text, since line numbers shift between attempts.
+func (uc *reviewUseCase) fixedLintIssues(ctx context.Context, previousReviewID int, issues []service.LintIssue) []int {
+	feedbacks, err := uc.reviewRepo.GetReviewFeedbackByReviewID(ctx, previousReviewID)
+	if err != nil {
+		uc.logger.Warn("Failed to get previous lint findings",
+			zap.Int("previous_review_id", previousReviewID),
+			zap.Error(err),
+		)
+		return nil
+	}
+
+	current := make(map[string]bool, len(issues))
+	for _, issue := range issues {
+		current[lintIssueKey(issue.Rule, issue.FilePath, issue.CodeSnippet)] = true
+	}
+
+	var fixed []int
+	for _, fb := range feedbacks {
+		if fb.Origin != domain.FeedbackOriginStaticAnalysis || fb.IsResolved || fb.LintRule == nil {
+			continue
+		}
+		filePath := ""
+		if fb.FilePath != nil {
+			filePath = *fb.FilePath
+		}
+		if !current[lintIssueKey(*fb.LintRule, filePath, fb.CodeSnippet)] {
+			fixed = append(fixed, fb.ID)
+		}
+	}
+	return fixed
+}
+
+func lintIssueKey(rule, filePath, snippet string) string {
+	return rule + "\x00" + filePath + "\x00" + snippet
+}
+
+func (uc *reviewUseCase) saveReviewResult(ctx context.Context, submissionID int, task *domain.Task, criteria []*domain.TaskCriteria, result *service.CodeReviewResult, input *reviewInput) error {
 	review := &domain.CodeReview{
 		SubmissionID:    submissionID,
-		AIModel:         "deepseek",
+		AIModel:         result.AIModel,
 		OverallStatus:   result.OverallStatus,
 		AIConfidence:    &result.AIConfidence,
 		ExecutionTimeMs: &result.ExecutionTimeMs,
-	}
+		SuggestedScore:  suggestScore(uc.scoringCfg, task.MaxScore, criteria, result.Criteria),
 
-	reviewID, err := uc.reviewRepo.CreateCodeReview(ctx, review)
-	if err != nil {
-		return fmt.Errorf("failed to create code review: %w", err)
+		PromptTokens:     &result.PromptTokens,
+		CompletionTokens: &result.CompletionTokens,
+		EstimatedCost:    &result.EstimatedCost,
 	}
+	if input.template != nil {
+		review.PromptTemplateID = &input.template.ID
+		review.PromptTemplateVersion = &input.template.Version
+	}
+	lintIssues := input.lintIssues
 
-	uc.logger.Info("Created code review",
-		zap.Int("submission_id", submissionID),
-		zap.Int("review_id", reviewID),
-		zap.String("status", result.OverallStatus),
-	)
-
+	feedbacks := make([]*domain.ReviewFeedback, 0, len(result.Feedbacks)+len(lintIssues))
 	for _, fb := range result.Feedbacks {
 		var filePath *string
 		if fb.FilePath != "" {
 			filePath = &fb.FilePath
 		}
 
-		feedback := &domain.ReviewFeedback{
-			ReviewID:     reviewID,
+		feedbacks = append(feedbacks, &domain.ReviewFeedback{
 			FeedbackType: fb.FeedbackType,
 			FilePath:     filePath,
 			LineStart:    fb.LineStart,
@@ -225,24 +1109,64 @@ func (uc *reviewUseCase) saveReviewResult(ctx context.Context, submissionID int,
 			Description:  fb.Description,
 			Severity:     fb.Severity,
 			IsResolved:   false,
+			Origin:       domain.FeedbackOriginAI,
+			IsAnchored:   &fb.Anchored,
+		})
+	}
+
+	anchored := true
+	for _, issue := range lintIssues {
+		var filePath *string
+		if issue.FilePath != "" {
+			filePath = &issue.FilePath
 		}
 
-		if err := uc.reviewRepo.CreateReviewFeedback(ctx, feedback); err != nil {
-			uc.logger.Error("Failed to create review feedback",
-				zap.Int("review_id", reviewID),
-				zap.Error(err),
-			)
-			continue
+		feedbacks = append(feedbacks, &domain.ReviewFeedback{
+			FeedbackType: issue.FeedbackType,
+			FilePath:     filePath,
+			LineStart:    issue.Line,
+			LineEnd:      &issue.Line,
+			CodeSnippet:  issue.CodeSnippet,
+			Description:  issue.Message,
+			Severity:     issue.Severity,
+			Origin:       domain.FeedbackOriginStaticAnalysis,
+			LintRule:     &issue.Rule,
+			IsAnchored:   &anchored,
+		})
+	}
+
+	criterionResults := make([]*domain.CriterionResult, 0, len(result.Criteria))
+	for _, verdict := range result.Criteria {
+		var evidence *string
+		if verdict.Evidence != "" {
+			evidence = &verdict.Evidence
 		}
+
+		criterionResults = append(criterionResults, &domain.CriterionResult{
+			CriterionID: verdict.CriterionID,
+			Verdict:     verdict.Verdict,
+			Evidence:    evidence,
+		})
 	}
 
-	if err := uc.submissionRepo.UpdateStatus(ctx, submissionID, domain.StatusAIReviewed); err != nil {
-		return fmt.Errorf("failed to update submission status: %w", err)
+	// The review, its feedback and the status change are saved together: a
+	// retry after a failed save starts over instead of finding a review of a
+	// submission that is still pending.
+	reviewID, err := uc.reviewRepo.SaveReview(ctx, review, feedbacks, criterionResults)
+	if err != nil {
+		return fmt.Errorf("failed to save code review: %w", err)
 	}
 
+	uc.logger.Info("Created code review",
+		zap.Int("submission_id", submissionID),
+		zap.Int("review_id", reviewID),
+		zap.String("status", result.OverallStatus),
+	)
+
 	uc.logger.Info("Successfully processed submission",
 		zap.Int("submission_id", submissionID),
 		zap.Int("feedbacks_count", len(result.Feedbacks)),
+		zap.Int("lint_issues_count", len(lintIssues)),
 	)
 
 	return nil
@@ -0,0 +1,54 @@
+package usecase
+
+import (
+	"math"
+
+	"github.com/ilyin-ad/flutter-code-mentor/internal/config"
+	"github.com/ilyin-ad/flutter-code-mentor/internal/domain"
+	"github.com/ilyin-ad/flutter-code-mentor/internal/service"
+)
+
+// suggestScore turns criterion verdicts into a score out of maxScore. Each
+// criterion contributes its weight times the verdict credit; a mandatory
+// criterion that is not met caps the total at cfg.MandatoryFailureCap of
+// maxScore. Tasks without criteria get no suggestion.
+func suggestScore(cfg config.ScoringConfig, maxScore int, criteria []*domain.TaskCriteria, verdicts []service.CriterionVerdictItem) *float64 {
+	if len(criteria) == 0 || maxScore <= 0 {
+		return nil
+	}
+
+	byID := make(map[int]domain.CriterionVerdict, len(verdicts))
+	for _, v := range verdicts {
+		byID[v.CriterionID] = v.Verdict
+	}
+
+	var earned, total float64
+	mandatoryFailed := false
+	for _, c := range criteria {
+		weight := float64(c.Weight)
+		total += weight
+
+		switch byID[c.ID] {
+		case domain.VerdictMet:
+			earned += weight
+		case domain.VerdictPartiallyMet:
+			earned += weight * cfg.PartialCredit
+		default:
+			if c.IsMandatory {
+				mandatoryFailed = true
+			}
+		}
+	}
+
+	if total == 0 {
+		return nil
+	}
+
+	score := float64(maxScore) * earned / total
+	if mandatoryFailed {
+		score = min(score, float64(maxScore)*cfg.MandatoryFailureCap)
+	}
+
+	score = math.Round(score*100) / 100
+	return &score
+}
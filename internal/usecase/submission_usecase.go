@@ -2,43 +2,88 @@ package usecase
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"errors"
 	"fmt"
+	"path"
 	"regexp"
+	"sort"
+	"strings"
 	"time"
 
+	"github.com/ilyin-ad/flutter-code-mentor/internal/config"
 	"github.com/ilyin-ad/flutter-code-mentor/internal/domain"
 	"github.com/ilyin-ad/flutter-code-mentor/internal/repository"
+	"github.com/ilyin-ad/flutter-code-mentor/internal/service"
+	"go.uber.org/zap"
 )
 
 var (
-	ErrInvalidSubmissionType = errors.New("invalid submission type")
-	ErrMissingCode           = errors.New("code is required when submission_type is 'code'")
-	ErrMissingGithubURL      = errors.New("github_url is required when submission_type is 'github_link'")
-	ErrInvalidGithubURL      = errors.New("invalid github URL format")
-	ErrTaskNotFound          = errors.New("task not found")
-	ErrUserNotFound          = errors.New("user not found")
+	ErrInvalidSubmissionType    = errors.New("invalid submission type")
+	ErrMissingCode              = errors.New("code is required when submission_type is 'code'")
+	ErrMissingGithubURL         = errors.New("github_url is required when submission_type is 'github_link'")
+	ErrInvalidGithubURL         = errors.New("invalid github URL format")
+	ErrTaskNotFound             = errors.New("task not found")
+	ErrUserNotFound             = errors.New("user not found")
+	ErrSubmissionNotFound       = errors.New("submission not found")
+	ErrTaskAlreadyAccepted      = errors.New("a submission for this task has already been accepted")
+	ErrSubmissionFilesNotStored = errors.New("submission files are not stored until the submission is reviewed")
 )
 
+const (
+	maxPastedFiles = 50
+	maxPastedSize  = 1 << 20
+)
+
+var pastedFilePathPattern = regexp.MustCompile(`^[A-Za-z0-9_./-]+$`)
+
 type SubmissionUseCase interface {
 	CreateSubmission(ctx context.Context, req *CreateSubmissionRequest) (*CreateSubmissionResponse, error)
+	GetSubmission(ctx context.Context, submissionID int) (*SubmissionDetails, error)
+	ListTaskSubmissions(ctx context.Context, taskID int) ([]*domain.Submission, error)
+	GetSubmissionDiff(ctx context.Context, submissionID int) (*SubmissionDiff, error)
 }
 
 type submissionUseCase struct {
 	submissionRepo repository.SubmissionRepository
+	reviewJobRepo  repository.ReviewJobRepository
 	taskRepo       repository.TaskRepository
+	courseRepo     repository.CourseRepository
 	userRepo       repository.UserRepository
+	enrollmentRepo repository.EnrollmentRepository
+	repoSources    service.RepositorySources
+	githubService  service.GitHubService
+	dispatcher     ReviewDispatcher
+	archiveCfg     config.ArchiveConfig
+	logger         *zap.Logger
 }
 
 func NewSubmissionUseCase(
+	cfg *config.Config,
 	submissionRepo repository.SubmissionRepository,
+	reviewJobRepo repository.ReviewJobRepository,
 	taskRepo repository.TaskRepository,
+	courseRepo repository.CourseRepository,
 	userRepo repository.UserRepository,
+	enrollmentRepo repository.EnrollmentRepository,
+	repoSources service.RepositorySources,
+	githubService service.GitHubService,
+	dispatcher ReviewDispatcher,
+	logger *zap.Logger,
 ) SubmissionUseCase {
 	return &submissionUseCase{
 		submissionRepo: submissionRepo,
+		reviewJobRepo:  reviewJobRepo,
 		taskRepo:       taskRepo,
+		courseRepo:     courseRepo,
 		userRepo:       userRepo,
+		enrollmentRepo: enrollmentRepo,
+		repoSources:    repoSources,
+		githubService:  githubService,
+		dispatcher:     dispatcher,
+		archiveCfg:     cfg.Archive,
+		logger:         logger,
 	}
 }
 
@@ -48,16 +93,41 @@ type CreateSubmissionRequest struct {
 	SubmissionType string
 	Code           *string
 	GithubURL      *string
+	GitRef         *string
+	Archive        *SubmissionArchive
+	Files          map[string]string
+}
+
+type SubmissionArchive struct {
+	Name    string
+	Content []byte
 }
 
 type CreateSubmissionResponse struct {
 	SubmissionID int
+	CommitSHA    *string
 	CreatedAt    time.Time
 }
 
+type SubmissionDetails struct {
+	Submission *domain.Submission
+	ReviewJob  *domain.ReviewJob
+	Files      map[string]string
+}
+
+type SubmissionDiff struct {
+	SubmissionID         int
+	PreviousSubmissionID *int
+	Files                []service.FileDiff
+}
+
+// ValidationErrorDetail describes an invalid field. Message is in English;
+// when Args is set it is a fmt format, so handlers can translate it before
+// filling in the values.
 type ValidationErrorDetail struct {
 	Field   string
 	Message string
+	Args    []any
 }
 
 type ValidationError struct {
@@ -70,6 +140,12 @@ func (e *ValidationError) Error() string {
 }
 
 func (uc *submissionUseCase) CreateSubmission(ctx context.Context, req *CreateSubmissionRequest) (*CreateSubmissionResponse, error) {
+	principal, err := principalFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	req.UserID = principal.UserID
+
 	if err := uc.validateSubmissionRequest(req); err != nil {
 		return nil, err
 	}
@@ -82,6 +158,14 @@ func (uc *submissionUseCase) CreateSubmission(ctx context.Context, req *CreateSu
 		return nil, ErrTaskNotFound
 	}
 
+	enrollment, err := uc.enrollmentRepo.Get(ctx, task.CourseID, req.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if enrollment == nil || enrollment.CompletionStatus != domain.EnrollmentActive {
+		return nil, ErrNotEnrolled
+	}
+
 	user, err := uc.userRepo.GetByID(ctx, req.UserID)
 	if err != nil {
 		return nil, fmt.Errorf("%w: %v", ErrUserNotFound, err)
@@ -90,6 +174,19 @@ func (uc *submissionUseCase) CreateSubmission(ctx context.Context, req *CreateSu
 		return nil, ErrUserNotFound
 	}
 
+	attempts, err := uc.submissionRepo.GetByTaskAndStudent(ctx, req.TaskID, req.UserID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get previous submissions: %w", err)
+	}
+
+	var previous *domain.Submission
+	if len(attempts) > 0 {
+		previous = attempts[0]
+	}
+	if previous != nil && previous.Status == domain.StatusAccepted {
+		return nil, ErrTaskAlreadyAccepted
+	}
+
 	submission := &domain.Submission{
 		StudentID:      req.UserID,
 		TaskID:         req.TaskID,
@@ -97,35 +194,378 @@ func (uc *submissionUseCase) CreateSubmission(ctx context.Context, req *CreateSu
 		GithubURL:      req.GithubURL,
 		Status:         domain.StatusPending,
 		SubmissionType: domain.SubmissionType(req.SubmissionType),
+		GitRef:         req.GitRef,
+		MultiFile:      len(req.Files) > 0,
+	}
+	switch submission.SubmissionType {
+	case domain.SubmissionTypeGithubLink:
+		commitSHA, err := uc.resolveCommit(ctx, req)
+		if err != nil {
+			return nil, err
+		}
+		submission.CommitSHA = &commitSHA
+	case domain.SubmissionTypeZipArchive:
+		sum := sha256.Sum256(req.Archive.Content)
+		archiveSHA := hex.EncodeToString(sum[:])
+		if err := uc.submissionRepo.SaveArchive(ctx, archiveSHA, req.Archive.Content); err != nil {
+			return nil, err
+		}
+		submission.ArchiveName = &req.Archive.Name
+		submission.ArchiveSHA256 = &archiveSHA
+	}
+	if previous != nil {
+		submission.PreviousSubmissionID = &previous.ID
 	}
 
-	submissionID, err := uc.submissionRepo.Create(ctx, submission)
+	var submissionID int
+	if submission.MultiFile {
+		submissionID, err = uc.submissionRepo.CreateWithFiles(ctx, submission, req.Files)
+	} else {
+		submissionID, err = uc.submissionRepo.Create(ctx, submission)
+	}
 	if err != nil {
 		return nil, fmt.Errorf("failed to create submission: %w", err)
 	}
 
+	if previous != nil {
+		if err := uc.submissionRepo.UpdateStatus(ctx, previous.ID, domain.StatusResubmitted); err != nil {
+			uc.logger.Warn("Failed to mark previous submission resubmitted",
+				zap.Int("submission_id", submissionID),
+				zap.Int("previous_submission_id", previous.ID),
+				zap.Error(err),
+			)
+		}
+	}
+
+	// The scheduler picks up pending submissions without a job, so a failed
+	// enqueue only delays the review.
+	if err := uc.reviewJobRepo.Enqueue(ctx, submissionID); err != nil {
+		uc.logger.Warn("Failed to enqueue review job",
+			zap.Int("submission_id", submissionID),
+			zap.Error(err),
+		)
+	} else {
+		uc.dispatcher.Dispatch(submissionID)
+	}
+
 	return &CreateSubmissionResponse{
 		SubmissionID: submissionID,
+		CommitSHA:    submission.CommitSHA,
 		CreatedAt:    submission.SubmittedAt,
 	}, nil
 }
 
+// resolveCommit pins a repository submission to the commit its ref points
+// to now, so the review sees exactly what was submitted even if the student
+// pushes later.
+func (uc *submissionUseCase) resolveCommit(ctx context.Context, req *CreateSubmissionRequest) (string, error) {
+	repo, err := uc.repoSources.Parse(*req.GithubURL)
+	if err != nil {
+		return "", err
+	}
+
+	ref := ""
+	if req.GitRef != nil {
+		ref = *req.GitRef
+	}
+
+	commitSHA, err := uc.githubService.ResolveRef(ctx, repo, ref)
+	if err == nil {
+		return commitSHA, nil
+	}
+
+	detail := ValidationErrorDetail{
+		Field:   "github_url",
+		Message: "Repository is not reachable. Make sure it exists and is public",
+	}
+	if errors.Is(err, service.ErrRefNotFound) {
+		detail = ValidationErrorDetail{
+			Field:   "ref",
+			Message: "Branch, tag or commit not found in the repository",
+		}
+	}
+
+	uc.logger.Warn("Failed to resolve repository ref",
+		zap.String("repository_url", repo.URL),
+		zap.String("ref", ref),
+		zap.Error(err),
+	)
+
+	return "", &ValidationError{
+		Message: "Validation failed",
+		Details: []ValidationErrorDetail{detail},
+	}
+}
+
+func (uc *submissionUseCase) GetSubmission(ctx context.Context, submissionID int) (*SubmissionDetails, error) {
+	submission, err := uc.submissionRepo.GetByID(ctx, submissionID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get submission: %w", err)
+	}
+	if submission == nil {
+		return nil, ErrSubmissionNotFound
+	}
+
+	principal, err := principalFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err := authorizeSubmission(ctx, uc.taskRepo, uc.courseRepo, principal, submission); err != nil {
+		return nil, err
+	}
+
+	job, err := uc.reviewJobRepo.GetBySubmissionID(ctx, submissionID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get review job: %w", err)
+	}
+
+	details := &SubmissionDetails{
+		Submission: submission,
+		ReviewJob:  job,
+	}
+	if submission.MultiFile {
+		details.Files, err = uc.submissionRepo.GetFiles(ctx, submissionID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get submission files: %w", err)
+		}
+	}
+
+	return details, nil
+}
+
+func (uc *submissionUseCase) ListTaskSubmissions(ctx context.Context, taskID int) ([]*domain.Submission, error) {
+	task, err := uc.taskRepo.GetByID(ctx, taskID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrTaskNotFound, err)
+	}
+	if task == nil {
+		return nil, ErrTaskNotFound
+	}
+
+	principal, err := principalFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err := authorizeTask(ctx, uc.courseRepo, principal, task); err != nil {
+		return nil, err
+	}
+
+	submissions, err := uc.submissionRepo.GetByTaskID(ctx, taskID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list submissions: %w", err)
+	}
+
+	return submissions, nil
+}
+
+func (uc *submissionUseCase) GetSubmissionDiff(ctx context.Context, submissionID int) (*SubmissionDiff, error) {
+	submission, err := uc.submissionRepo.GetByID(ctx, submissionID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get submission: %w", err)
+	}
+	if submission == nil {
+		return nil, ErrSubmissionNotFound
+	}
+
+	principal, err := principalFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err := authorizeSubmission(ctx, uc.taskRepo, uc.courseRepo, principal, submission); err != nil {
+		return nil, err
+	}
+
+	result := &SubmissionDiff{
+		SubmissionID:         submission.ID,
+		PreviousSubmissionID: submission.PreviousSubmissionID,
+	}
+	if submission.PreviousSubmissionID == nil {
+		return result, nil
+	}
+
+	previous, err := uc.submissionRepo.GetByID(ctx, *submission.PreviousSubmissionID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get previous submission: %w", err)
+	}
+	if previous == nil {
+		return result, nil
+	}
+
+	current, err := submissionSnapshot(ctx, uc.submissionRepo, submission)
+	if err != nil {
+		return nil, err
+	}
+	before, err := submissionSnapshot(ctx, uc.submissionRepo, previous)
+	if err != nil {
+		return nil, err
+	}
+
+	// Project snapshots are taken during review; until both exist there is
+	// nothing meaningful to compare.
+	if len(current) == 0 || len(before) == 0 {
+		return result, nil
+	}
+
+	result.Files = service.DiffFiles(before, current)
+	return result, nil
+}
+
+// validatePastedFiles checks the paths and sizes of a multi-file code
+// submission. Paths become file_path values in feedback, so they have to be
+// plain relative paths.
+func validatePastedFiles(files map[string]string) []ValidationErrorDetail {
+	var details []ValidationErrorDetail
+
+	if len(files) > maxPastedFiles {
+		details = append(details, ValidationErrorDetail{
+			Field:   "files",
+			Message: "Must contain at most %d files",
+			Args:    []any{maxPastedFiles},
+		})
+	}
+
+	paths := make([]string, 0, len(files))
+	for p := range files {
+		paths = append(paths, p)
+	}
+	sort.Strings(paths)
+
+	total := 0
+	for _, p := range paths {
+		total += len(files[p])
+		clean := path.Clean(p)
+		if len(p) > 500 || !pastedFilePathPattern.MatchString(p) || clean != p ||
+			strings.HasPrefix(p, "/") || clean == ".." || strings.HasPrefix(clean, "../") {
+			details = append(details, ValidationErrorDetail{
+				Field:   fmt.Sprintf("files[%s]", p),
+				Message: "Must be a relative path like lib/main.dart",
+			})
+			continue
+		}
+		if !strings.HasSuffix(p, ".dart") && !strings.HasSuffix(p, ".yaml") {
+			details = append(details, ValidationErrorDetail{
+				Field:   fmt.Sprintf("files[%s]", p),
+				Message: "Only .dart and .yaml files can be submitted",
+			})
+		}
+	}
+
+	if total > maxPastedSize {
+		details = append(details, ValidationErrorDetail{
+			Field:   "files",
+			Message: "Total size must not exceed %d KB",
+			Args:    []any{maxPastedSize >> 10},
+		})
+	}
+
+	return details
+}
+
+func (uc *submissionUseCase) validateArchive(req *CreateSubmissionRequest) []ValidationErrorDetail {
+	var details []ValidationErrorDetail
+
+	if req.Code != nil && *req.Code != "" {
+		details = append(details, ValidationErrorDetail{
+			Field:   "code",
+			Message: "Should not be provided when submission_type is 'zip_archive'",
+		})
+	}
+	if req.GithubURL != nil && *req.GithubURL != "" {
+		details = append(details, ValidationErrorDetail{
+			Field:   "github_url",
+			Message: "Should not be provided when submission_type is 'zip_archive'",
+		})
+	}
+	if req.GitRef != nil {
+		details = append(details, ValidationErrorDetail{
+			Field:   "ref",
+			Message: "Should not be provided when submission_type is 'zip_archive'",
+		})
+	}
+
+	if req.Archive == nil || len(req.Archive.Content) == 0 {
+		return append(details, ValidationErrorDetail{
+			Field:   "archive",
+			Message: "Required when submission_type is 'zip_archive'",
+		})
+	}
+
+	if !strings.HasSuffix(strings.ToLower(req.Archive.Name), ".zip") || len(req.Archive.Name) > 255 {
+		details = append(details, ValidationErrorDetail{
+			Field:   "archive",
+			Message: "Must be a .zip file with a name of at most 255 characters",
+		})
+	}
+
+	if len(req.Archive.Content) > uc.archiveCfg.MaxUploadMB<<20 {
+		return append(details, ValidationErrorDetail{
+			Field:   "archive",
+			Message: "Must not be larger than %d MB",
+			Args:    []any{uc.archiveCfg.MaxUploadMB},
+		})
+	}
+
+	err := service.ValidateArchive(req.Archive.Content, uc.archiveCfg.MaxEntries, int64(uc.archiveCfg.MaxUncompressedMB)<<20)
+	if err != nil {
+		uc.logger.Info("Rejected submission archive",
+			zap.String("name", req.Archive.Name),
+			zap.Error(err),
+		)
+		details = append(details, ValidationErrorDetail{
+			Field:   "archive",
+			Message: "Must be a zip archive of at most %d files and %d MB unpacked",
+			Args:    []any{uc.archiveCfg.MaxEntries, uc.archiveCfg.MaxUncompressedMB},
+		})
+	}
+
+	return details
+}
+
 func (uc *submissionUseCase) validateSubmissionRequest(req *CreateSubmissionRequest) error {
 	var details []ValidationErrorDetail
 
-	if req.SubmissionType != string(domain.SubmissionTypeCode) && req.SubmissionType != string(domain.SubmissionTypeGithubLink) {
+	switch domain.SubmissionType(req.SubmissionType) {
+	case domain.SubmissionTypeCode, domain.SubmissionTypeGithubLink, domain.SubmissionTypeZipArchive:
+	default:
 		details = append(details, ValidationErrorDetail{
 			Field:   "submission_type",
-			Message: "Must be either 'code' or 'github_link'",
+			Message: "Must be one of 'code', 'github_link' or 'zip_archive'",
+		})
+	}
+
+	if req.SubmissionType != string(domain.SubmissionTypeZipArchive) && req.Archive != nil {
+		details = append(details, ValidationErrorDetail{
+			Field:   "archive",
+			Message: "Should only be provided when submission_type is 'zip_archive'",
+		})
+	}
+
+	if req.SubmissionType == string(domain.SubmissionTypeZipArchive) {
+		details = append(details, uc.validateArchive(req)...)
+	}
+
+	if req.SubmissionType != string(domain.SubmissionTypeCode) && len(req.Files) > 0 {
+		details = append(details, ValidationErrorDetail{
+			Field:   "files",
+			Message: "Should only be provided when submission_type is 'code'",
 		})
 	}
 
 	if req.SubmissionType == string(domain.SubmissionTypeCode) {
-		if req.Code == nil || *req.Code == "" {
+		hasCode := req.Code != nil && *req.Code != ""
+		switch {
+		case hasCode && len(req.Files) > 0:
+			details = append(details, ValidationErrorDetail{
+				Field:   "files",
+				Message: "Provide either code or files, not both",
+			})
+		case !hasCode && len(req.Files) == 0:
 			details = append(details, ValidationErrorDetail{
 				Field:   "code",
-				Message: "Required when submission_type is 'code'",
+				Message: "Required when submission_type is 'code' and no files are given",
 			})
+		case len(req.Files) > 0:
+			details = append(details, validatePastedFiles(req.Files)...)
 		}
 		if req.GithubURL != nil && *req.GithubURL != "" {
 			details = append(details, ValidationErrorDetail{
@@ -133,6 +573,12 @@ func (uc *submissionUseCase) validateSubmissionRequest(req *CreateSubmissionRequ
 				Message: "Should not be provided when submission_type is 'code'",
 			})
 		}
+		if req.GitRef != nil {
+			details = append(details, ValidationErrorDetail{
+				Field:   "ref",
+				Message: "Should not be provided when submission_type is 'code'",
+			})
+		}
 	}
 
 	if req.SubmissionType == string(domain.SubmissionTypeGithubLink) {
@@ -142,13 +588,19 @@ func (uc *submissionUseCase) validateSubmissionRequest(req *CreateSubmissionRequ
 				Message: "Required when submission_type is 'github_link'",
 			})
 		} else {
-			githubURLPattern := `^https://github\.com/([a-zA-Z0-9_-]+)/([a-zA-Z0-9_-]+)/?$`
-			matched, _ := regexp.MatchString(githubURLPattern, *req.GithubURL)
-			if !matched {
+			repo, err := uc.repoSources.Parse(*req.GithubURL)
+			if err != nil {
+				uc.logger.Info("Rejected repository URL",
+					zap.String("url", *req.GithubURL),
+					zap.Error(err),
+				)
 				details = append(details, ValidationErrorDetail{
 					Field:   "github_url",
-					Message: "Invalid GitHub URL format. Expected: https://github.com/username/repository",
+					Message: "Must be a repository URL on one of: %s",
+					Args:    []any{strings.Join(uc.repoSources.Hosts(), ", ")},
 				})
+			} else {
+				req.GithubURL = &repo.URL
 			}
 		}
 		if req.Code != nil && *req.Code != "" {
@@ -157,6 +609,18 @@ func (uc *submissionUseCase) validateSubmissionRequest(req *CreateSubmissionRequ
 				Message: "Should not be provided when submission_type is 'github_link'",
 			})
 		}
+		if req.GitRef != nil {
+			if err := service.ValidateGitRef(*req.GitRef); err != nil {
+				uc.logger.Info("Rejected repository ref",
+					zap.String("ref", *req.GitRef),
+					zap.Error(err),
+				)
+				details = append(details, ValidationErrorDetail{
+					Field:   "ref",
+					Message: "Must be a valid branch, tag or commit name",
+				})
+			}
+		}
 	}
 
 	if req.TaskID < 1 {
@@ -8,6 +8,7 @@ import (
 
 	"github.com/ilyin-ad/flutter-code-mentor/internal/domain"
 	"github.com/ilyin-ad/flutter-code-mentor/internal/repository"
+	"github.com/ilyin-ad/flutter-code-mentor/internal/service"
 )
 
 var (
@@ -18,6 +19,8 @@ var (
 
 type TaskUseCase interface {
 	CreateTask(ctx context.Context, req *CreateTaskRequest) (*CreateTaskResponse, error)
+	GetFileRules(ctx context.Context, taskID int) (*domain.FileSelectionRules, error)
+	SetFileRules(ctx context.Context, taskID int, rules domain.FileSelectionRules) (*domain.FileSelectionRules, error)
 }
 
 type taskUseCase struct {
@@ -45,6 +48,7 @@ type CreateTaskRequest struct {
 	Deadline    time.Time
 	MaxScore    int
 	Criteria    []TaskCriteriaRequest
+	FileRules   domain.FileSelectionRules
 }
 
 type TaskCriteriaRequest struct {
@@ -59,6 +63,7 @@ type CreateTaskResponse struct {
 	CourseID  int
 	Title     string
 	Status    string
+	FileRules domain.FileSelectionRules
 	CreatedAt time.Time
 }
 
@@ -75,12 +80,21 @@ func (uc *taskUseCase) CreateTask(ctx context.Context, req *CreateTaskRequest) (
 		return nil, ErrCourseNotFound
 	}
 
+	principal, err := principalFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err := authorizeCourse(principal, course); err != nil {
+		return nil, err
+	}
+
 	task := &domain.Task{
 		CourseID:    req.CourseID,
 		Title:       req.Title,
 		Description: req.Description,
 		Deadline:    req.Deadline,
 		MaxScore:    req.MaxScore,
+		FileRules:   req.FileRules,
 	}
 
 	taskID, err := uc.taskRepo.Create(ctx, task)
@@ -108,10 +122,62 @@ func (uc *taskUseCase) CreateTask(ctx context.Context, req *CreateTaskRequest) (
 		CourseID:  req.CourseID,
 		Title:     req.Title,
 		Status:    string(domain.TaskStatusActive),
+		FileRules: req.FileRules,
 		CreatedAt: task.CreatedAt,
 	}, nil
 }
 
+func (uc *taskUseCase) GetFileRules(ctx context.Context, taskID int) (*domain.FileSelectionRules, error) {
+	task, err := uc.authorizedTask(ctx, taskID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &task.FileRules, nil
+}
+
+// SetFileRules replaces the task's file selection rules; reviews started
+// afterwards use them.
+func (uc *taskUseCase) SetFileRules(ctx context.Context, taskID int, rules domain.FileSelectionRules) (*domain.FileSelectionRules, error) {
+	if details := validateFileRules(rules); len(details) > 0 {
+		return nil, &ValidationError{
+			Message: "Validation failed",
+			Details: details,
+		}
+	}
+
+	if _, err := uc.authorizedTask(ctx, taskID); err != nil {
+		return nil, err
+	}
+
+	if err := uc.taskRepo.UpdateFileRules(ctx, taskID, rules); err != nil {
+		return nil, err
+	}
+
+	return &rules, nil
+}
+
+func (uc *taskUseCase) authorizedTask(ctx context.Context, taskID int) (*domain.Task, error) {
+	principal, err := principalFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	task, err := uc.taskRepo.GetByID(ctx, taskID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get task: %w", err)
+	}
+	if task == nil {
+		return nil, ErrTaskNotFound
+	}
+
+	if err := authorizeTask(ctx, uc.courseRepo, principal, task); err != nil {
+		return nil, err
+	}
+
+	return task, nil
+}
+
 func (uc *taskUseCase) validateTaskRequest(req *CreateTaskRequest) error {
 	var details []ValidationErrorDetail
 
@@ -125,14 +191,16 @@ func (uc *taskUseCase) validateTaskRequest(req *CreateTaskRequest) error {
 	if len(req.Title) < 5 || len(req.Title) > 100 {
 		details = append(details, ValidationErrorDetail{
 			Field:   "title",
-			Message: "Must be between 5 and 100 characters",
+			Message: "Must be between %d and %d characters",
+			Args:    []any{5, 100},
 		})
 	}
 
 	if len(req.Description) < 10 {
 		details = append(details, ValidationErrorDetail{
 			Field:   "description",
-			Message: "Must be at least 10 characters",
+			Message: "Must be at least %d characters",
+			Args:    []any{10},
 		})
 	}
 
@@ -146,7 +214,8 @@ func (uc *taskUseCase) validateTaskRequest(req *CreateTaskRequest) error {
 	if req.MaxScore < 1 || req.MaxScore > 100 {
 		details = append(details, ValidationErrorDetail{
 			Field:   "max_score",
-			Message: "Must be between 1 and 100",
+			Message: "Must be between %d and %d",
+			Args:    []any{1, 100},
 		})
 	}
 
@@ -161,25 +230,30 @@ func (uc *taskUseCase) validateTaskRequest(req *CreateTaskRequest) error {
 		if len(criteria.CriterionName) < 3 || len(criteria.CriterionName) > 100 {
 			details = append(details, ValidationErrorDetail{
 				Field:   fmt.Sprintf("criteria[%d].criterion_name", i),
-				Message: "Must be between 3 and 100 characters",
+				Message: "Must be between %d and %d characters",
+				Args:    []any{3, 100},
 			})
 		}
 
 		if len(criteria.CriterionDescription) < 10 {
 			details = append(details, ValidationErrorDetail{
 				Field:   fmt.Sprintf("criteria[%d].criterion_description", i),
-				Message: "Must be at least 10 characters",
+				Message: "Must be at least %d characters",
+				Args:    []any{10},
 			})
 		}
 
 		if criteria.Weight < 1 || criteria.Weight > 100 {
 			details = append(details, ValidationErrorDetail{
 				Field:   fmt.Sprintf("criteria[%d].weight", i),
-				Message: "Must be between 1 and 100",
+				Message: "Must be between %d and %d",
+				Args:    []any{1, 100},
 			})
 		}
 	}
 
+	details = append(details, validateFileRules(req.FileRules)...)
+
 	if len(details) > 0 {
 		return &ValidationError{
 			Message: "Validation failed",
@@ -189,3 +263,43 @@ func (uc *taskUseCase) validateTaskRequest(req *CreateTaskRequest) error {
 
 	return nil
 }
+
+func validateFileRules(rules domain.FileSelectionRules) []ValidationErrorDetail {
+	var details []ValidationErrorDetail
+
+	for i, pattern := range rules.IncludePatterns {
+		if err := service.ValidateGlobPattern(pattern); err != nil {
+			details = append(details, ValidationErrorDetail{
+				Field:   fmt.Sprintf("file_rules.include_patterns[%d]", i),
+				Message: "Must be a glob pattern relative to the repository root",
+			})
+		}
+	}
+
+	for i, pattern := range rules.ExcludePatterns {
+		if err := service.ValidateGlobPattern(pattern); err != nil {
+			details = append(details, ValidationErrorDetail{
+				Field:   fmt.Sprintf("file_rules.exclude_patterns[%d]", i),
+				Message: "Must be a glob pattern relative to the repository root",
+			})
+		}
+	}
+
+	if rules.MaxFileSizeKB != nil && (*rules.MaxFileSizeKB < 1 || *rules.MaxFileSizeKB > 1024) {
+		details = append(details, ValidationErrorDetail{
+			Field:   "file_rules.max_file_size_kb",
+			Message: "Must be between %d and %d",
+			Args:    []any{1, 1024},
+		})
+	}
+
+	if rules.MaxFiles != nil && (*rules.MaxFiles < 1 || *rules.MaxFiles > 1000) {
+		details = append(details, ValidationErrorDetail{
+			Field:   "file_rules.max_files",
+			Message: "Must be between %d and %d",
+			Args:    []any{1, 1000},
+		})
+	}
+
+	return details
+}
@@ -0,0 +1,360 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/ilyin-ad/flutter-code-mentor/internal/domain"
+	"github.com/ilyin-ad/flutter-code-mentor/internal/repository"
+	"go.uber.org/zap"
+)
+
+var (
+	ErrReviewNotFound    = errors.New("review not found")
+	ErrFeedbackNotFound  = errors.New("feedback not found")
+	ErrReviewNotEditable = errors.New("review cannot be changed in the current submission status")
+)
+
+// teacherReviewTransitions lists the statuses a teacher may move a
+// submission to. accepted is final.
+var teacherReviewTransitions = map[domain.SubmissionStatus][]domain.SubmissionStatus{
+	domain.StatusAIReviewed:      {domain.StatusTeacherReviewed, domain.StatusAccepted},
+	domain.StatusTeacherReviewed: {domain.StatusTeacherReviewed, domain.StatusAccepted},
+}
+
+type TeacherReviewUseCase interface {
+	UpdateFeedback(ctx context.Context, req *UpdateFeedbackRequest) (*domain.ReviewFeedback, error)
+	AddFeedback(ctx context.Context, req *AddFeedbackRequest) (*domain.ReviewFeedback, error)
+	CompleteReview(ctx context.Context, req *CompleteReviewRequest) (*domain.Submission, error)
+}
+
+type teacherReviewUseCase struct {
+	submissionRepo repository.SubmissionRepository
+	reviewRepo     repository.ReviewRepository
+	taskRepo       repository.TaskRepository
+	courseRepo     repository.CourseRepository
+	logger         *zap.Logger
+}
+
+func NewTeacherReviewUseCase(
+	submissionRepo repository.SubmissionRepository,
+	reviewRepo repository.ReviewRepository,
+	taskRepo repository.TaskRepository,
+	courseRepo repository.CourseRepository,
+	logger *zap.Logger,
+) TeacherReviewUseCase {
+	return &teacherReviewUseCase{
+		submissionRepo: submissionRepo,
+		reviewRepo:     reviewRepo,
+		taskRepo:       taskRepo,
+		courseRepo:     courseRepo,
+		logger:         logger,
+	}
+}
+
+type UpdateFeedbackRequest struct {
+	SubmissionID    int
+	FeedbackID      int
+	TeacherApproved *bool
+	TeacherComment  *string
+	IsResolved      *bool
+}
+
+type AddFeedbackRequest struct {
+	SubmissionID   int
+	FeedbackType   string
+	FilePath       *string
+	LineStart      int
+	LineEnd        *int
+	CodeSnippet    string
+	SuggestedFix   *string
+	Description    string
+	Severity       int
+	TeacherComment *string
+}
+
+type CompleteReviewRequest struct {
+	SubmissionID int
+	Status       string
+	Score        *float64
+}
+
+func (uc *teacherReviewUseCase) UpdateFeedback(ctx context.Context, req *UpdateFeedbackRequest) (*domain.ReviewFeedback, error) {
+	_, review, err := uc.editableReview(ctx, req.SubmissionID)
+	if err != nil {
+		return nil, err
+	}
+
+	feedback, err := uc.reviewRepo.GetReviewFeedbackByID(ctx, req.FeedbackID)
+	if err != nil {
+		return nil, err
+	}
+	if feedback == nil || feedback.ReviewID != review.ID {
+		return nil, ErrFeedbackNotFound
+	}
+
+	if req.TeacherApproved != nil {
+		feedback.TeacherApproved = req.TeacherApproved
+	}
+	if req.TeacherComment != nil {
+		comment := strings.TrimSpace(*req.TeacherComment)
+		if comment == "" {
+			feedback.TeacherComment = nil
+		} else {
+			feedback.TeacherComment = &comment
+		}
+	}
+	if req.IsResolved != nil {
+		feedback.IsResolved = *req.IsResolved
+	}
+
+	if err := uc.reviewRepo.UpdateReviewFeedback(ctx, feedback); err != nil {
+		return nil, err
+	}
+
+	return feedback, nil
+}
+
+func (uc *teacherReviewUseCase) AddFeedback(ctx context.Context, req *AddFeedbackRequest) (*domain.ReviewFeedback, error) {
+	if err := validateAddFeedbackRequest(req); err != nil {
+		return nil, err
+	}
+
+	_, review, err := uc.editableReview(ctx, req.SubmissionID)
+	if err != nil {
+		return nil, err
+	}
+
+	principal, err := principalFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	approved := true
+	feedback := &domain.ReviewFeedback{
+		ReviewID:        review.ID,
+		FeedbackType:    req.FeedbackType,
+		FilePath:        req.FilePath,
+		LineStart:       req.LineStart,
+		LineEnd:         req.LineEnd,
+		CodeSnippet:     req.CodeSnippet,
+		SuggestedFix:    req.SuggestedFix,
+		Description:     req.Description,
+		Severity:        req.Severity,
+		TeacherComment:  req.TeacherComment,
+		TeacherApproved: &approved,
+		AuthorID:        &principal.UserID,
+		Origin:          domain.FeedbackOriginTeacher,
+	}
+
+	if err := uc.reviewRepo.CreateReviewFeedback(ctx, feedback); err != nil {
+		return nil, err
+	}
+
+	uc.logger.Info("Teacher feedback added",
+		zap.Int("submission_id", req.SubmissionID),
+		zap.Int("feedback_id", feedback.ID),
+		zap.Int("author_id", principal.UserID),
+	)
+
+	return feedback, nil
+}
+
+func (uc *teacherReviewUseCase) CompleteReview(ctx context.Context, req *CompleteReviewRequest) (*domain.Submission, error) {
+	submission, err := uc.reviewableSubmission(ctx, req.SubmissionID)
+	if err != nil {
+		return nil, err
+	}
+
+	task, err := uc.taskRepo.GetByID(ctx, submission.TaskID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get task: %w", err)
+	}
+	if task == nil {
+		return nil, ErrTaskNotFound
+	}
+
+	if err := validateCompleteReviewRequest(req, submission, task); err != nil {
+		return nil, err
+	}
+
+	status := domain.SubmissionStatus(req.Status)
+	if !canTransitionSubmission(submission.Status, status) {
+		return nil, fmt.Errorf("%w: %s -> %s", ErrInvalidStatusTransition, submission.Status, status)
+	}
+
+	updated, err := uc.submissionRepo.CompleteReview(ctx, submission.ID, submission.Status, status, req.Score)
+	if err != nil {
+		return nil, err
+	}
+	if !updated {
+		return nil, fmt.Errorf("%w: submission status changed concurrently", ErrInvalidStatusTransition)
+	}
+
+	uc.logger.Info("Submission review completed",
+		zap.Int("submission_id", submission.ID),
+		zap.String("from", string(submission.Status)),
+		zap.String("to", string(status)),
+	)
+
+	submission.Status = status
+	if req.Score != nil {
+		submission.Score = req.Score
+	}
+
+	return submission, nil
+}
+
+// reviewableSubmission loads the submission and checks that the caller is a
+// teacher of its course or an admin.
+func (uc *teacherReviewUseCase) reviewableSubmission(ctx context.Context, submissionID int) (*domain.Submission, error) {
+	principal, err := principalFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if !principal.HasRole(domain.RoleTeacher, domain.RoleAdmin) {
+		return nil, ErrUnauthorized
+	}
+
+	submission, err := uc.submissionRepo.GetByID(ctx, submissionID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get submission: %w", err)
+	}
+	if submission == nil {
+		return nil, ErrSubmissionNotFound
+	}
+
+	if err := authorizeSubmission(ctx, uc.taskRepo, uc.courseRepo, principal, submission); err != nil {
+		return nil, err
+	}
+
+	return submission, nil
+}
+
+// editableReview returns the submission's AI review while the submission is
+// still open for teacher review.
+func (uc *teacherReviewUseCase) editableReview(ctx context.Context, submissionID int) (*domain.Submission, *domain.CodeReview, error) {
+	submission, err := uc.reviewableSubmission(ctx, submissionID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if _, ok := teacherReviewTransitions[submission.Status]; !ok {
+		return nil, nil, ErrReviewNotEditable
+	}
+
+	review, err := uc.reviewRepo.GetCodeReviewBySubmissionID(ctx, submissionID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get code review: %w", err)
+	}
+	if review == nil {
+		return nil, nil, ErrReviewNotFound
+	}
+
+	return submission, review, nil
+}
+
+func canTransitionSubmission(from, to domain.SubmissionStatus) bool {
+	for _, allowed := range teacherReviewTransitions[from] {
+		if allowed == to {
+			return true
+		}
+	}
+	return false
+}
+
+func validateAddFeedbackRequest(req *AddFeedbackRequest) error {
+	var details []ValidationErrorDetail
+
+	if !domain.FeedbackTypes[req.FeedbackType] {
+		details = append(details, ValidationErrorDetail{
+			Field:   "feedback_type",
+			Message: "Must be one of 'critical_error', 'logic_error', 'style_issue', 'performance', 'security_risk', 'improvement'",
+		})
+	}
+
+	if req.LineStart < 1 {
+		details = append(details, ValidationErrorDetail{
+			Field:   "line_start",
+			Message: "Must be greater than 0",
+		})
+	}
+
+	if req.LineEnd != nil && *req.LineEnd < req.LineStart {
+		details = append(details, ValidationErrorDetail{
+			Field:   "line_end",
+			Message: "Must not be less than line_start",
+		})
+	}
+
+	if strings.TrimSpace(req.CodeSnippet) == "" {
+		details = append(details, ValidationErrorDetail{
+			Field:   "code_snippet",
+			Message: "Code snippet is required",
+		})
+	}
+
+	if strings.TrimSpace(req.Description) == "" {
+		details = append(details, ValidationErrorDetail{
+			Field:   "description",
+			Message: "Description is required",
+		})
+	}
+
+	if req.Severity < 1 || req.Severity > 5 {
+		details = append(details, ValidationErrorDetail{
+			Field:   "severity",
+			Message: "Must be between %d and %d",
+			Args:    []any{1, 5},
+		})
+	}
+
+	if len(details) > 0 {
+		return &ValidationError{
+			Message: "Validation failed",
+			Details: details,
+		}
+	}
+
+	return nil
+}
+
+func validateCompleteReviewRequest(req *CompleteReviewRequest, submission *domain.Submission, task *domain.Task) error {
+	var details []ValidationErrorDetail
+
+	status := domain.SubmissionStatus(req.Status)
+	if status != domain.StatusTeacherReviewed && status != domain.StatusAccepted {
+		details = append(details, ValidationErrorDetail{
+			Field:   "status",
+			Message: "Must be one of 'teacher_reviewed', 'accepted'",
+		})
+	}
+
+	if req.Score != nil && (*req.Score < 0 || *req.Score > float64(task.MaxScore)) {
+		details = append(details, ValidationErrorDetail{
+			Field:   "score",
+			Message: "Must be between 0 and %d",
+			Args:    []any{task.MaxScore},
+		})
+	}
+
+	// Only teachers set submission scores; the AI's suggested score stays on
+	// the review, so accepting needs a score given by the teacher.
+	if status == domain.StatusAccepted && req.Score == nil && submission.Score == nil {
+		details = append(details, ValidationErrorDetail{
+			Field:   "score",
+			Message: "Score is required to accept a submission",
+		})
+	}
+
+	if len(details) > 0 {
+		return &ValidationError{
+			Message: "Validation failed",
+			Details: details,
+		}
+	}
+
+	return nil
+}
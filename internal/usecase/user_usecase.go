@@ -16,10 +16,13 @@ var (
 	ErrEmailAlreadyExists = errors.New("email already exists")
 	ErrInvalidEmail       = errors.New("invalid email format")
 	ErrWeakPassword       = errors.New("password must be at least 12 characters")
+	ErrTeacherSignUp      = errors.New("only admins can create teacher accounts")
 )
 
 type UserUseCase interface {
 	CreateUser(ctx context.Context, req *CreateUserRequest) (*CreateUserResponse, error)
+	SetLanguage(ctx context.Context, language *string) (*domain.User, error)
+	Language(ctx context.Context, userID int) (domain.Language, error)
 }
 
 type userUseCase struct {
@@ -38,12 +41,14 @@ type CreateUserRequest struct {
 	Role      string
 	FirstName string
 	LastName  string
+	Language  *string
 }
 
 type CreateUserResponse struct {
 	UserID    int
 	Email     string
 	Role      string
+	Language  *domain.Language
 	CreatedAt time.Time
 }
 
@@ -52,6 +57,14 @@ func (uc *userUseCase) CreateUser(ctx context.Context, req *CreateUserRequest) (
 		return nil, err
 	}
 
+	// Sign-up is open to students only; teachers are created by an admin.
+	if req.Role == domain.RoleTeacher {
+		principal, ok := domain.PrincipalFromContext(ctx)
+		if !ok || !principal.HasRole(domain.RoleAdmin) {
+			return nil, ErrTeacherSignUp
+		}
+	}
+
 	existingUser, err := uc.userRepo.GetByEmail(ctx, req.Email)
 	if err == nil && existingUser != nil {
 		return nil, ErrEmailAlreadyExists
@@ -62,12 +75,15 @@ func (uc *userUseCase) CreateUser(ctx context.Context, req *CreateUserRequest) (
 		return nil, fmt.Errorf("failed to hash password: %w", err)
 	}
 
+	language, _ := parseLanguage(req.Language)
+
 	user := &domain.User{
 		Email:        req.Email,
 		PasswordHash: string(hashedPassword),
 		Role:         req.Role,
 		FirstName:    req.FirstName,
 		LastName:     req.LastName,
+		Language:     language,
 	}
 
 	userID, err := uc.userRepo.Create(ctx, user)
@@ -79,10 +95,53 @@ func (uc *userUseCase) CreateUser(ctx context.Context, req *CreateUserRequest) (
 		UserID:    userID,
 		Email:     req.Email,
 		Role:      req.Role,
+		Language:  language,
 		CreatedAt: user.CreatedAt,
 	}, nil
 }
 
+// SetLanguage changes the language of the current user's feedback and API
+// messages. It applies from the next request on.
+func (uc *userUseCase) SetLanguage(ctx context.Context, language *string) (*domain.User, error) {
+	principal, err := principalFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	parsed, detail := parseLanguage(language)
+	if detail != nil {
+		return nil, &ValidationError{
+			Message: "Validation failed",
+			Details: []ValidationErrorDetail{*detail},
+		}
+	}
+
+	if err := uc.userRepo.SetLanguage(ctx, principal.UserID, parsed); err != nil {
+		return nil, err
+	}
+
+	user, err := uc.userRepo.GetByID(ctx, principal.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, ErrUserNotFound
+	}
+
+	return user, nil
+}
+
+// Language returns the language a user has chosen, or "" if they have not
+// chosen one. It is read when a response is localized rather than stored in
+// the access token, so a change applies at once.
+func (uc *userUseCase) Language(ctx context.Context, userID int) (domain.Language, error) {
+	language, err := uc.userRepo.GetLanguage(ctx, userID)
+	if err != nil || language == nil {
+		return "", err
+	}
+	return *language, nil
+}
+
 func (uc *userUseCase) validateUserRequest(req *CreateUserRequest) error {
 	var details []ValidationErrorDetail
 
@@ -98,13 +157,14 @@ func (uc *userUseCase) validateUserRequest(req *CreateUserRequest) error {
 	if len(req.Password) < 12 {
 		details = append(details, ValidationErrorDetail{
 			Field:   "password",
-			Message: "Must be at least 12 characters",
+			Message: "Must be at least %d characters",
+			Args:    []any{12},
 		})
 	}
 
 	validRoles := map[string]bool{
-		"student": true,
-		"teacher": true,
+		domain.RoleStudent: true,
+		domain.RoleTeacher: true,
 	}
 	if !validRoles[req.Role] {
 		details = append(details, ValidationErrorDetail{
@@ -116,17 +176,23 @@ func (uc *userUseCase) validateUserRequest(req *CreateUserRequest) error {
 	if len(req.FirstName) < 2 || len(req.FirstName) > 50 {
 		details = append(details, ValidationErrorDetail{
 			Field:   "first_name",
-			Message: "Must be between 2 and 50 characters",
+			Message: "Must be between %d and %d characters",
+			Args:    []any{2, 50},
 		})
 	}
 
 	if len(req.LastName) < 2 || len(req.LastName) > 50 {
 		details = append(details, ValidationErrorDetail{
 			Field:   "last_name",
-			Message: "Must be between 2 and 50 characters",
+			Message: "Must be between %d and %d characters",
+			Args:    []any{2, 50},
 		})
 	}
 
+	if _, detail := parseLanguage(req.Language); detail != nil {
+		details = append(details, *detail)
+	}
+
 	if len(details) > 0 {
 		return &ValidationError{
 			Message: "Validation failed",
@@ -0,0 +1,31 @@
+package worker
+
+import (
+	"context"
+
+	"github.com/ilyin-ad/flutter-code-mentor/internal/usecase"
+	"go.uber.org/fx"
+)
+
+func FxModule() fx.Option {
+	return fx.Module(
+		"worker",
+		fx.Provide(
+			NewPool,
+			func(p *Pool) usecase.ReviewDispatcher {
+				return p
+			},
+		),
+		fx.Invoke(func(lc fx.Lifecycle, p *Pool) {
+			lc.Append(fx.Hook{
+				OnStart: func(ctx context.Context) error {
+					p.Start()
+					return nil
+				},
+				OnStop: func(ctx context.Context) error {
+					return p.Stop(ctx)
+				},
+			})
+		}),
+	)
+}
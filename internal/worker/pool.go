@@ -0,0 +1,142 @@
+package worker
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/ilyin-ad/flutter-code-mentor/internal/config"
+	"github.com/ilyin-ad/flutter-code-mentor/internal/service"
+	"github.com/ilyin-ad/flutter-code-mentor/internal/usecase"
+	"go.uber.org/zap"
+)
+
+// Pool runs AI reviews in-process as soon as submissions are dispatched.
+// The review_jobs table stays the source of truth: a dropped dispatch is
+// picked up again by the scheduler sweep. While the AI provider is
+// unavailable the workers pause instead of claiming jobs. Stopping the pool
+// cancels running reviews and drops queued ones, which the sweep re-enqueues.
+type Pool struct {
+	reviewUC usecase.ReviewUseCase
+	health   service.ProviderHealth
+	workers  int
+	queue    chan int
+	ctx      context.Context
+	cancel   context.CancelFunc
+	logger   *zap.Logger
+
+	mu       sync.Mutex
+	inFlight map[int]struct{}
+	closed   bool
+	wg       sync.WaitGroup
+}
+
+func NewPool(cfg *config.Config, reviewUC usecase.ReviewUseCase, health service.ProviderHealth, logger *zap.Logger) *Pool {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	return &Pool{
+		reviewUC: reviewUC,
+		health:   health,
+		workers:  max(cfg.ReviewQueue.Workers, 1),
+		queue:    make(chan int, max(cfg.ReviewQueue.DispatchBuffer, 1)),
+		ctx:      ctx,
+		cancel:   cancel,
+		logger:   logger,
+		inFlight: make(map[int]struct{}),
+	}
+}
+
+func (p *Pool) Dispatch(submissionID int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.closed {
+		return
+	}
+
+	if _, ok := p.inFlight[submissionID]; ok {
+		return
+	}
+
+	select {
+	case p.queue <- submissionID:
+		p.inFlight[submissionID] = struct{}{}
+	default:
+		p.logger.Warn("Review dispatch queue is full, leaving submission for the sweeper",
+			zap.Int("submission_id", submissionID),
+		)
+	}
+}
+
+func (p *Pool) Start() {
+	p.logger.Info("Starting review worker pool", zap.Int("workers", p.workers))
+
+	for i := 0; i < p.workers; i++ {
+		p.wg.Add(1)
+		go p.run()
+	}
+}
+
+func (p *Pool) Stop(ctx context.Context) error {
+	p.mu.Lock()
+	if !p.closed {
+		p.closed = true
+		close(p.queue)
+		p.cancel()
+	}
+	p.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		p.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		p.logger.Info("Review worker pool stopped")
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (p *Pool) run() {
+	defer p.wg.Done()
+
+	for submissionID := range p.queue {
+		if p.ctx.Err() == nil && p.waitForProvider() {
+			if err := p.reviewUC.ProcessSubmission(p.ctx, submissionID); err != nil {
+				p.logger.Error("Failed to process submission",
+					zap.Int("submission_id", submissionID),
+					zap.Error(err),
+				)
+			}
+		}
+
+		p.mu.Lock()
+		delete(p.inFlight, submissionID)
+		p.mu.Unlock()
+	}
+}
+
+// waitForProvider blocks while the AI provider is unavailable. It returns
+// false if the pool stops first; the job stays queued for the sweeper.
+func (p *Pool) waitForProvider() bool {
+	for {
+		until, paused := p.health.PausedUntil()
+		if !paused {
+			return true
+		}
+
+		p.logger.Info("AI provider unavailable, pausing review worker", zap.Time("until", until))
+
+		timer := time.NewTimer(time.Until(until))
+		select {
+		case <-timer.C:
+		case <-p.ctx.Done():
+			timer.Stop()
+			return false
+		}
+	}
+}
@@ -0,0 +1,117 @@
+package worker
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ilyin-ad/flutter-code-mentor/internal/config"
+	"github.com/ilyin-ad/flutter-code-mentor/internal/usecase"
+	"go.uber.org/zap"
+)
+
+// pausedHealth reports the provider as unavailable until a fixed time.
+type pausedHealth struct {
+	until time.Time
+}
+
+func (h pausedHealth) PausedUntil() (time.Time, bool) {
+	if time.Now().Before(h.until) {
+		return h.until, true
+	}
+	return time.Time{}, false
+}
+
+// recordingReviewUseCase records when each submission was processed.
+type recordingReviewUseCase struct {
+	usecase.ReviewUseCase
+
+	mu        sync.Mutex
+	processed map[int]time.Time
+	done      chan int
+}
+
+func (uc *recordingReviewUseCase) ProcessSubmission(ctx context.Context, submissionID int) error {
+	uc.mu.Lock()
+	uc.processed[submissionID] = time.Now()
+	uc.mu.Unlock()
+
+	uc.done <- submissionID
+	return nil
+}
+
+func newTestPool(reviewUC usecase.ReviewUseCase, pauseFor time.Duration) *Pool {
+	cfg := &config.Config{
+		ReviewQueue: config.ReviewQueueConfig{Workers: 1, DispatchBuffer: 10},
+	}
+	return NewPool(cfg, reviewUC, pausedHealth{until: time.Now().Add(pauseFor)}, zap.NewNop())
+}
+
+func TestPoolWaitForProvider(t *testing.T) {
+	t.Run("returns once the pause ends", func(t *testing.T) {
+		p := newTestPool(nil, 100*time.Millisecond)
+		defer p.Stop(context.Background())
+
+		start := time.Now()
+		if !p.waitForProvider() {
+			t.Fatal("waitForProvider() = false, want true")
+		}
+		if elapsed := time.Since(start); elapsed < 100*time.Millisecond {
+			t.Errorf("waitForProvider returned after %s, before the pause ended", elapsed)
+		}
+	})
+
+	t.Run("returns immediately when the provider is available", func(t *testing.T) {
+		p := newTestPool(nil, 0)
+		defer p.Stop(context.Background())
+
+		if !p.waitForProvider() {
+			t.Fatal("waitForProvider() = false, want true")
+		}
+	})
+
+	t.Run("gives up when the pool stops", func(t *testing.T) {
+		p := newTestPool(nil, time.Hour)
+
+		result := make(chan bool, 1)
+		go func() { result <- p.waitForProvider() }()
+
+		time.Sleep(20 * time.Millisecond)
+		if err := p.Stop(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+
+		select {
+		case ok := <-result:
+			if ok {
+				t.Error("waitForProvider() = true after Stop, want false")
+			}
+		case <-time.After(time.Second):
+			t.Fatal("waitForProvider kept waiting after Stop")
+		}
+	})
+}
+
+func TestPoolPausesReviewsWhileProviderIsUnavailable(t *testing.T) {
+	reviewUC := &recordingReviewUseCase{processed: make(map[int]time.Time), done: make(chan int, 1)}
+	p := newTestPool(reviewUC, 100*time.Millisecond)
+	pausedUntil, _ := p.health.PausedUntil()
+
+	p.Start()
+	defer p.Stop(context.Background())
+	p.Dispatch(42)
+
+	select {
+	case <-reviewUC.done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("submission was not processed after the pause")
+	}
+
+	reviewUC.mu.Lock()
+	processedAt := reviewUC.processed[42]
+	reviewUC.mu.Unlock()
+	if processedAt.Before(pausedUntil) {
+		t.Errorf("submission processed at %s, before the pause ended at %s", processedAt, pausedUntil)
+	}
+}
@@ -0,0 +1,30 @@
+-- +goose Up
+-- +goose StatementBegin
+begin;
+
+--- Очередь задач на AI-проверку
+CREATE TABLE review_jobs (
+  id SERIAL PRIMARY KEY,
+  submission_id INT NOT NULL UNIQUE REFERENCES submissions(id) ON DELETE CASCADE,
+  status VARCHAR(15) NOT NULL DEFAULT 'queued' CHECK (
+    status IN ('queued', 'running', 'done', 'failed')
+  ),
+  attempts INT NOT NULL DEFAULT 0 CHECK (attempts >= 0),
+  last_error TEXT,
+  next_attempt_at TIMESTAMP NOT NULL DEFAULT NOW(),
+  locked_by VARCHAR(100),
+  locked_until TIMESTAMP,
+  created_at TIMESTAMP DEFAULT NOW(),
+  updated_at TIMESTAMP DEFAULT NOW()
+);
+
+CREATE INDEX idx_review_jobs_claim ON review_jobs(status, next_attempt_at);
+
+INSERT INTO review_jobs (submission_id)
+SELECT id FROM submissions WHERE status = 'pending';
+
+end;
+
+-- +goose StatementEnd
+
+-- +goose Down
@@ -0,0 +1,14 @@
+-- +goose Up
+-- +goose StatementBegin
+begin;
+
+--- Код приглашения для самостоятельной записи на курс
+ALTER TABLE courses ADD COLUMN invite_code VARCHAR(32) UNIQUE;
+
+CREATE INDEX idx_enrollments_course ON course_enrollments(course_id);
+
+end;
+
+-- +goose StatementEnd
+
+-- +goose Down
@@ -0,0 +1,12 @@
+-- +goose Up
+-- +goose StatementBegin
+begin;
+
+--- Автор замечания: NULL для замечаний ИИ, иначе преподаватель
+ALTER TABLE review_feedback ADD COLUMN author_id INT REFERENCES users(id) ON DELETE SET NULL;
+
+end;
+
+-- +goose StatementEnd
+
+-- +goose Down
@@ -0,0 +1,27 @@
+-- +goose Up
+-- +goose StatementBegin
+begin;
+
+--- Вердикт ИИ по каждому критерию задачи
+CREATE TABLE review_criteria_results (
+  id SERIAL PRIMARY KEY,
+  review_id INT NOT NULL REFERENCES code_reviews(id) ON DELETE CASCADE,
+  criterion_id INT NOT NULL REFERENCES task_criteria(id) ON DELETE CASCADE,
+  verdict VARCHAR(20) NOT NULL CHECK (
+    verdict IN ('met', 'partially_met', 'not_met')
+  ),
+  evidence TEXT,
+  created_at TIMESTAMP DEFAULT NOW(),
+  UNIQUE (review_id, criterion_id)
+);
+
+CREATE INDEX idx_criteria_results_review ON review_criteria_results(review_id);
+
+--- Оценка, рассчитанная по весам критериев
+ALTER TABLE code_reviews ADD COLUMN suggested_score NUMERIC(6,2);
+
+end;
+
+-- +goose StatementEnd
+
+-- +goose Down
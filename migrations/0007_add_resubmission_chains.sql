@@ -0,0 +1,22 @@
+-- +goose Up
+-- +goose StatementBegin
+begin;
+
+--- Ссылка на предыдущую попытку студента по той же задаче
+ALTER TABLE submissions ADD COLUMN previous_submission_id INT REFERENCES submissions(id) ON DELETE SET NULL;
+
+CREATE INDEX idx_submissions_previous ON submissions(previous_submission_id);
+
+--- Снимок проверенных файлов посылки, нужен для сравнения попыток
+CREATE TABLE submission_files (
+  submission_id INT NOT NULL REFERENCES submissions(id) ON DELETE CASCADE,
+  file_path VARCHAR(500) NOT NULL,
+  content TEXT NOT NULL,
+  PRIMARY KEY (submission_id, file_path)
+);
+
+end;
+
+-- +goose StatementEnd
+
+-- +goose Down
@@ -0,0 +1,15 @@
+-- +goose Up
+-- +goose StatementBegin
+begin;
+
+--- Правила отбора файлов репозитория для проверки задачи
+ALTER TABLE tasks ADD COLUMN include_patterns TEXT[] NOT NULL DEFAULT '{}';
+ALTER TABLE tasks ADD COLUMN exclude_patterns TEXT[] NOT NULL DEFAULT '{}';
+ALTER TABLE tasks ADD COLUMN max_file_size_kb INT CHECK (max_file_size_kb > 0);
+ALTER TABLE tasks ADD COLUMN max_files INT CHECK (max_files > 0);
+
+end;
+
+-- +goose StatementEnd
+
+-- +goose Down
@@ -0,0 +1,13 @@
+-- +goose Up
+-- +goose StatementBegin
+begin;
+
+--- Ветка, тег или коммит, указанные студентом, и SHA коммита на момент отправки
+ALTER TABLE submissions ADD COLUMN git_ref VARCHAR(255);
+ALTER TABLE submissions ADD COLUMN commit_sha VARCHAR(64);
+
+end;
+
+-- +goose StatementEnd
+
+-- +goose Down
@@ -0,0 +1,33 @@
+-- +goose Up
+-- +goose StatementBegin
+begin;
+
+--- Загруженные zip-архивы, адресуются по SHA-256 содержимого
+CREATE TABLE submission_archives (
+  sha256 CHAR(64) PRIMARY KEY,
+  size_bytes INT NOT NULL CHECK (size_bytes > 0),
+  content BYTEA NOT NULL,
+  created_at TIMESTAMP DEFAULT NOW()
+);
+
+ALTER TABLE submissions ADD COLUMN archive_name VARCHAR(255);
+ALTER TABLE submissions ADD COLUMN archive_sha256 CHAR(64) REFERENCES submission_archives(sha256);
+
+--- Третий тип посылки: zip-архив проекта
+ALTER TABLE submissions DROP CONSTRAINT submissions_submission_type_check;
+ALTER TABLE submissions ADD CONSTRAINT submissions_submission_type_check CHECK (
+  submission_type IN ('code', 'github_link', 'zip_archive')
+);
+
+ALTER TABLE submissions DROP CONSTRAINT valid_submission;
+ALTER TABLE submissions ADD CONSTRAINT valid_submission CHECK (
+  (submission_type = 'code' AND code IS NOT NULL) OR
+  (submission_type = 'github_link' AND github_url IS NOT NULL) OR
+  (submission_type = 'zip_archive' AND archive_sha256 IS NOT NULL)
+);
+
+end;
+
+-- +goose StatementEnd
+
+-- +goose Down
@@ -0,0 +1,19 @@
+-- +goose Up
+-- +goose StatementBegin
+begin;
+
+--- Посылка кода из нескольких файлов: содержимое хранится в submission_files
+ALTER TABLE submissions ADD COLUMN multi_file BOOLEAN NOT NULL DEFAULT false;
+
+ALTER TABLE submissions DROP CONSTRAINT valid_submission;
+ALTER TABLE submissions ADD CONSTRAINT valid_submission CHECK (
+  (submission_type = 'code' AND (code IS NOT NULL OR multi_file)) OR
+  (submission_type = 'github_link' AND github_url IS NOT NULL) OR
+  (submission_type = 'zip_archive' AND archive_sha256 IS NOT NULL)
+);
+
+end;
+
+-- +goose StatementEnd
+
+-- +goose Down
@@ -0,0 +1,17 @@
+-- +goose Up
+-- +goose StatementBegin
+begin;
+
+--- Источник замечания: ИИ, преподаватель или статический анализ
+ALTER TABLE review_feedback ADD COLUMN origin VARCHAR(20) NOT NULL DEFAULT 'ai' CHECK (
+  origin IN ('ai', 'teacher', 'static_analysis')
+);
+ALTER TABLE review_feedback ADD COLUMN lint_rule VARCHAR(100);
+
+UPDATE review_feedback SET origin = 'teacher' WHERE author_id IS NOT NULL;
+
+end;
+
+-- +goose StatementEnd
+
+-- +goose Down
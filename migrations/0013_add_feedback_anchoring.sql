@@ -0,0 +1,14 @@
+-- +goose Up
+-- +goose StatementBegin
+begin;
+
+--- Найден ли фрагмент кода замечания в файлах решения; NULL — не проверялось
+ALTER TABLE review_feedback ADD COLUMN is_anchored BOOLEAN;
+
+UPDATE review_feedback SET is_anchored = true WHERE origin = 'static_analysis';
+
+end;
+
+-- +goose StatementEnd
+
+-- +goose Down
@@ -0,0 +1,33 @@
+-- +goose Up
+-- +goose StatementBegin
+begin;
+
+--- Шаблоны промпта ИИ-проверки, заданные преподавателем для курса или задачи.
+--- Каждое изменение сохраняется новой версией, действует активная версия.
+CREATE TABLE prompt_templates (
+  id SERIAL PRIMARY KEY,
+  course_id INT REFERENCES courses(id) ON DELETE CASCADE,
+  task_id INT REFERENCES tasks(id) ON DELETE CASCADE,
+  version INT NOT NULL CHECK (version > 0),
+  system_prompt TEXT,
+  user_prompt TEXT NOT NULL,
+  is_active BOOLEAN NOT NULL DEFAULT true,
+  created_by INT REFERENCES users(id) ON DELETE SET NULL,
+  created_at TIMESTAMP DEFAULT NOW(),
+  CONSTRAINT prompt_template_scope CHECK ((course_id IS NULL) <> (task_id IS NULL))
+);
+
+CREATE UNIQUE INDEX idx_prompt_templates_course_version ON prompt_templates(course_id, version) WHERE course_id IS NOT NULL;
+CREATE UNIQUE INDEX idx_prompt_templates_task_version ON prompt_templates(task_id, version) WHERE task_id IS NOT NULL;
+CREATE UNIQUE INDEX idx_prompt_templates_course_active ON prompt_templates(course_id) WHERE course_id IS NOT NULL AND is_active;
+CREATE UNIQUE INDEX idx_prompt_templates_task_active ON prompt_templates(task_id) WHERE task_id IS NOT NULL AND is_active;
+
+--- Версия шаблона, по которому выполнена проверка; NULL — встроенный промпт
+ALTER TABLE code_reviews ADD COLUMN prompt_template_id INT REFERENCES prompt_templates(id) ON DELETE SET NULL;
+ALTER TABLE code_reviews ADD COLUMN prompt_template_version INT;
+
+end;
+
+-- +goose StatementEnd
+
+-- +goose Down
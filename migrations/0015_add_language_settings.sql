@@ -0,0 +1,14 @@
+-- +goose Up
+-- +goose StatementBegin
+begin;
+
+--- Язык замечаний ИИ-проверки. Настройка студента важнее настройки курса,
+--- NULL — язык не выбран.
+ALTER TABLE users ADD COLUMN language VARCHAR(5) CHECK (language IN ('en', 'ru'));
+ALTER TABLE courses ADD COLUMN language VARCHAR(5) CHECK (language IN ('en', 'ru'));
+
+end;
+
+-- +goose StatementEnd
+
+-- +goose Down
@@ -0,0 +1,24 @@
+-- +goose Up
+-- +goose StatementBegin
+begin;
+
+--- Результаты ИИ-проверки по хешу содержимого посылки, задачи, критериев,
+--- промпта и модели. Одинаковые посылки получают готовую проверку без запроса к API.
+CREATE TABLE review_cache (
+  cache_key CHAR(64) PRIMARY KEY,
+  task_id INT NOT NULL REFERENCES tasks(id) ON DELETE CASCADE,
+  ai_model VARCHAR(100) NOT NULL,
+  result JSONB NOT NULL,
+  hit_count INT NOT NULL DEFAULT 0,
+  created_at TIMESTAMP DEFAULT NOW(),
+  expires_at TIMESTAMP NOT NULL
+);
+
+CREATE INDEX idx_review_cache_task ON review_cache(task_id);
+CREATE INDEX idx_review_cache_expires ON review_cache(expires_at);
+
+end;
+
+-- +goose StatementEnd
+
+-- +goose Down
@@ -0,0 +1,25 @@
+-- +goose Up
+-- +goose StatementBegin
+begin;
+
+--- Токены и оценочная стоимость запросов к ИИ для каждой проверки
+ALTER TABLE code_reviews ADD COLUMN prompt_tokens INT CHECK (prompt_tokens >= 0);
+ALTER TABLE code_reviews ADD COLUMN completion_tokens INT CHECK (completion_tokens >= 0);
+ALTER TABLE code_reviews ADD COLUMN estimated_cost NUMERIC(12,6) CHECK (estimated_cost >= 0);
+
+CREATE INDEX idx_code_reviews_created_at ON code_reviews(created_at);
+
+--- Лимиты расходов на ИИ-проверку курса за день и за месяц.
+--- NULL — значение из конфигурации, 0 — без ограничения.
+CREATE TABLE course_ai_budgets (
+  course_id INT PRIMARY KEY REFERENCES courses(id) ON DELETE CASCADE,
+  daily_limit NUMERIC(12,2) CHECK (daily_limit >= 0),
+  monthly_limit NUMERIC(12,2) CHECK (monthly_limit >= 0),
+  updated_at TIMESTAMP DEFAULT NOW()
+);
+
+end;
+
+-- +goose StatementEnd
+
+-- +goose Down
@@ -0,0 +1,31 @@
+-- +goose Up
+-- +goose StatementBegin
+begin;
+
+--- Журнал запросов к ИИ: одна строка на каждый вызов модели, включая неудачные
+--- попытки и повторные запросы. Расходы и лимиты курса считаются по этому журналу.
+CREATE TABLE ai_usage (
+  id SERIAL PRIMARY KEY,
+  submission_id INT NOT NULL REFERENCES submissions(id) ON DELETE CASCADE,
+  ai_model VARCHAR(100) NOT NULL,
+  prompt_tokens INT NOT NULL CHECK (prompt_tokens >= 0),
+  completion_tokens INT NOT NULL CHECK (completion_tokens >= 0),
+  estimated_cost NUMERIC(12,6) NOT NULL CHECK (estimated_cost >= 0),
+  created_at TIMESTAMP DEFAULT NOW()
+);
+
+CREATE INDEX idx_ai_usage_submission ON ai_usage(submission_id);
+CREATE INDEX idx_ai_usage_created_at ON ai_usage(created_at);
+
+--- Расход уже сохранённых проверок переносится в журнал.
+INSERT INTO ai_usage (submission_id, ai_model, prompt_tokens, completion_tokens, estimated_cost, created_at)
+SELECT submission_id, ai_model, COALESCE(prompt_tokens, 0), COALESCE(completion_tokens, 0),
+       COALESCE(estimated_cost, 0), created_at
+FROM code_reviews
+WHERE prompt_tokens > 0 OR completion_tokens > 0 OR estimated_cost > 0;
+
+end;
+
+-- +goose StatementEnd
+
+-- +goose Down
@@ -0,0 +1,14 @@
+-- +goose Up
+-- +goose StatementBegin
+begin;
+
+--- Номер захвата задачи: увеличивается при каждом захвате. Воркер завершает
+--- задачу только со своим номером, поэтому запоздавший воркер с истёкшей
+--- блокировкой не перезапишет состояние нового захвата, даже в том же процессе.
+ALTER TABLE review_jobs ADD COLUMN claim_seq INT NOT NULL DEFAULT 0;
+
+end;
+
+-- +goose StatementEnd
+
+-- +goose Down
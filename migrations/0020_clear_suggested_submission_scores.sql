@@ -0,0 +1,14 @@
+-- +goose Up
+-- +goose StatementBegin
+begin;
+
+--- Оценка работы выставляется только преподавателем. Предложенная ИИ оценка
+--- хранится в code_reviews.suggested_score и убирается из ещё не проверенных работ.
+UPDATE submissions SET score = NULL
+WHERE status IN ('pending', 'ai_reviewed') AND score IS NOT NULL;
+
+end;
+
+-- +goose StatementEnd
+
+-- +goose Down
@@ -0,0 +1,12 @@
+-- +goose Up
+-- +goose StatementBegin
+begin;
+
+--- Имена моделей некоторых провайдеров длиннее 50 символов; длина как в review_cache и ai_usage.
+ALTER TABLE code_reviews ALTER COLUMN ai_model TYPE VARCHAR(100);
+
+end;
+
+-- +goose StatementEnd
+
+-- +goose Down
@@ -1 +1 @@
-drop table code_reviews, course_enrollments, courses, goose_db_version, review_feedback, submissions, tasks, users;
\ No newline at end of file
+drop table ai_usage, code_reviews, course_ai_budgets, course_enrollments, courses, goose_db_version, prompt_templates, review_cache, review_criteria_results, review_feedback, review_jobs, submission_archives, submission_files, submissions, tasks, users;
\ No newline at end of file